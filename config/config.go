@@ -1,26 +1,193 @@
 package config
 
 import (
+	"time"
+
 	"github.com/kelseyhightower/envconfig"
 )
 
 type (
 	Config struct {
-		Server Server
-		Mongo  Mongo
+		Server    Server
+		Mongo     Mongo
+		Admin     Admin
+		Site      Site
+		Robots    Robots
+		Media     Media
+		Comments  Comments
+		Analytics Analytics
+		Trending  Trending
+		Events    Events
+		Webhooks  Webhooks
+		Outbox    Outbox
+		Tracing   Tracing
+		RateLimit RateLimit
+		CSRF      CSRF
 	}
 
+	// Server configures the HTTP server. After a shutdown signal the
+	// readiness probe fails for DrainDelay before the server stops, so
+	// load balancers have time to take the instance out of rotation.
 	Server struct {
-		Port  string `envconfig:"SERVER_PORT"`
-		IsDev bool   `envconfig:"SERVER_IS_DEV"`
+		Port       string        `envconfig:"SERVER_PORT"`
+		IsDev      bool          `envconfig:"SERVER_IS_DEV"`
+		DrainDelay time.Duration `envconfig:"SERVER_DRAIN_DELAY" default:"5s"`
 	}
 
+	// Mongo configures the database connection. URI, when set, replaces
+	// Host, Port, User and Password and may name several hosts, an SRV
+	// record or any connection string option. The remaining settings
+	// override the URI when set and keep the driver defaults otherwise.
+	// Startup keeps retrying for StartupTimeout until the server answers.
 	Mongo struct {
+		URI      string `envconfig:"MONGO_URI"`
 		Host     string `envconfig:"MONGO_HOST"`
 		Port     string `envconfig:"MONGO_PORT"`
 		User     string `envconfig:"MONGO_USER"`
 		Password string `envconfig:"MONGO_PASSWORD"`
 		Name     string `envconfig:"MONGO_NAME"`
+
+		ReplicaSet             string        `envconfig:"MONGO_REPLICA_SET"`
+		TLS                    bool          `envconfig:"MONGO_TLS"`
+		TLSCAFile              string        `envconfig:"MONGO_TLS_CA_FILE"`
+		MinPoolSize            uint64        `envconfig:"MONGO_MIN_POOL_SIZE"`
+		MaxPoolSize            uint64        `envconfig:"MONGO_MAX_POOL_SIZE"`
+		ConnectTimeout         time.Duration `envconfig:"MONGO_CONNECT_TIMEOUT"`
+		ServerSelectionTimeout time.Duration `envconfig:"MONGO_SERVER_SELECTION_TIMEOUT"`
+		ReadPreference         string        `envconfig:"MONGO_READ_PREFERENCE"`
+		WriteConcern           string        `envconfig:"MONGO_WRITE_CONCERN"`
+		StartupTimeout         time.Duration `envconfig:"MONGO_STARTUP_TIMEOUT" default:"1m"`
+	}
+
+	// Site describes the public site for links and metadata that leave
+	// the page, e.g. feeds. URL is the absolute base without a trailing slash.
+	Site struct {
+		URL         string `envconfig:"SITE_URL" default:"http://localhost:8080"`
+		Title       string `envconfig:"SITE_TITLE" default:"News Posts"`
+		Description string `envconfig:"SITE_DESCRIPTION" default:"Latest news"`
+	}
+
+	// Robots configures robots.txt. DenyAll is meant for staging
+	// environments that must stay out of search indexes.
+	Robots struct {
+		Disallow []string `envconfig:"ROBOTS_DISALLOW" default:"/admin/"`
+		DenyAll  bool     `envconfig:"ROBOTS_DENY_ALL"`
+	}
+
+	// Media configures uploads. Backend is "local" (files under Dir)
+	// or "gridfs" (stored in Mongo).
+	Media struct {
+		Backend string `envconfig:"MEDIA_BACKEND" default:"local"`
+		Dir     string `envconfig:"MEDIA_DIR" default:"./data/media"`
+		MaxSize int64  `envconfig:"MEDIA_MAX_SIZE" default:"10485760"`
+	}
+
+	// Comments configures when new comments are held for moderation
+	// instead of appearing right away. Zero disables a numeric rule.
+	// IPSalt keys the hash that stands in for commenters' IP addresses;
+	// when empty a random one is used until the next restart.
+	Comments struct {
+		MaxLinks      int           `envconfig:"COMMENT_MAX_LINKS" default:"2"`
+		BannedWords   []string      `envconfig:"COMMENT_BANNED_WORDS"`
+		HoldFirstTime bool          `envconfig:"COMMENT_HOLD_FIRST_TIME" default:"true"`
+		RateLimit     int           `envconfig:"COMMENT_RATE_LIMIT" default:"5"`
+		RateWindow    time.Duration `envconfig:"COMMENT_RATE_WINDOW" default:"10m"`
+		SpamThreshold float64       `envconfig:"COMMENT_SPAM_THRESHOLD" default:"0.9"`
+		IPSalt        string        `envconfig:"COMMENT_IP_SALT"`
+	}
+
+	// Analytics configures page view tracking. Views are buffered in
+	// memory and written every FlushInterval; once BufferSize views are
+	// waiting, further ones are dropped rather than slowing pages down.
+	Analytics struct {
+		Enabled       bool          `envconfig:"ANALYTICS_ENABLED" default:"true"`
+		BufferSize    int           `envconfig:"ANALYTICS_BUFFER_SIZE" default:"4096"`
+		FlushInterval time.Duration `envconfig:"ANALYTICS_FLUSH_INTERVAL" default:"10s"`
+	}
+
+	// Trending configures the trending ranking: posts score their views
+	// within Window plus ReactionWeight per reaction, divided by their
+	// age in hours plus two raised to Gravity. Rankings are recomputed
+	// every Interval.
+	Trending struct {
+		Interval       time.Duration `envconfig:"TRENDING_INTERVAL" default:"5m"`
+		Window         time.Duration `envconfig:"TRENDING_WINDOW" default:"72h"`
+		Gravity        float64       `envconfig:"TRENDING_GRAVITY" default:"1.8"`
+		ReactionWeight float64       `envconfig:"TRENDING_REACTION_WEIGHT" default:"3"`
+	}
+
+	// Events configures where live post events come from. "local" only
+	// sees writes made by this instance. "mongo" tails a change stream
+	// on the posts collection so every instance sees every write; on a
+	// standalone server it polls every PollInterval instead. Consumer
+	// names the saved read position and defaults to the host name.
+	Events struct {
+		Source       string        `envconfig:"EVENTS_SOURCE" default:"local"`
+		Consumer     string        `envconfig:"EVENTS_CONSUMER"`
+		PollInterval time.Duration `envconfig:"EVENTS_POLL_INTERVAL" default:"2s"`
+	}
+
+	// Outbox configures the relay that hands post events, written to
+	// the outbox together with the posts, on to listeners and webhooks.
+	// It looks for events missed by other instances, or left behind by a
+	// crash, every PollInterval. Relayed events are kept for Retention.
+	Outbox struct {
+		PollInterval time.Duration `envconfig:"OUTBOX_POLL_INTERVAL" default:"5s"`
+		Retention    time.Duration `envconfig:"OUTBOX_RETENTION" default:"24h"`
+	}
+
+	// Webhooks configures outgoing webhook deliveries. Workers send due
+	// deliveries, checking the queue every PollInterval; a failed attempt
+	// is retried after RetryBase, doubling up to RetryMax, until
+	// MaxAttempts have been made. Each request may take up to Timeout.
+	Webhooks struct {
+		Workers      int           `envconfig:"WEBHOOK_WORKERS" default:"2"`
+		PollInterval time.Duration `envconfig:"WEBHOOK_POLL_INTERVAL" default:"5s"`
+		MaxAttempts  int           `envconfig:"WEBHOOK_MAX_ATTEMPTS" default:"8"`
+		RetryBase    time.Duration `envconfig:"WEBHOOK_RETRY_BASE" default:"30s"`
+		RetryMax     time.Duration `envconfig:"WEBHOOK_RETRY_MAX" default:"6h"`
+		Timeout      time.Duration `envconfig:"WEBHOOK_TIMEOUT" default:"10s"`
+	}
+
+	// Tracing configures OpenTelemetry tracing. Exporter is "none",
+	// "stdout" or "otlp"; the OTLP endpoint and headers come from the
+	// standard OTEL_EXPORTER_OTLP_* variables. SampleRatio is the share
+	// of new traces that are recorded.
+	Tracing struct {
+		Exporter    string  `envconfig:"TRACING_EXPORTER" default:"none"`
+		ServiceName string  `envconfig:"TRACING_SERVICE_NAME" default:"news-svc"`
+		SampleRatio float64 `envconfig:"TRACING_SAMPLE_RATIO" default:"1"`
+	}
+
+	// RateLimit configures per-client request limits. Each group of
+	// requests, reads, searches and writes, has a token bucket per client
+	// that holds Burst requests and refills at PerMinute a minute.
+	// Clients are told apart by user when signed in and by address
+	// otherwise; X-Forwarded-For is only believed from TrustedProxies,
+	// given as addresses or CIDR ranges. Store is "memory" (per instance)
+	// or "mongo" (shared by every instance).
+	RateLimit struct {
+		Enabled         bool     `envconfig:"RATE_LIMIT_ENABLED" default:"true"`
+		Store           string   `envconfig:"RATE_LIMIT_STORE" default:"memory"`
+		TrustedProxies  []string `envconfig:"RATE_LIMIT_TRUSTED_PROXIES"`
+		ReadPerMinute   float64  `envconfig:"RATE_LIMIT_READ_PER_MINUTE" default:"300"`
+		ReadBurst       int      `envconfig:"RATE_LIMIT_READ_BURST" default:"60"`
+		SearchPerMinute float64  `envconfig:"RATE_LIMIT_SEARCH_PER_MINUTE" default:"30"`
+		SearchBurst     int      `envconfig:"RATE_LIMIT_SEARCH_BURST" default:"10"`
+		WritePerMinute  float64  `envconfig:"RATE_LIMIT_WRITE_PER_MINUTE" default:"20"`
+		WriteBurst      int      `envconfig:"RATE_LIMIT_WRITE_BURST" default:"10"`
+	}
+
+	// CSRF configures the tokens that guard state-changing requests.
+	// Secret signs them; when empty a random one is used until the next
+	// restart, which suits a single instance only.
+	CSRF struct {
+		Secret string `envconfig:"CSRF_SECRET"`
+	}
+
+	Admin struct {
+		User     string `envconfig:"ADMIN_USER" default:"admin"`
+		Password string `envconfig:"ADMIN_PASSWORD"`
 	}
 )
 
@@ -3,7 +3,36 @@ package config
 import "errors"
 
 var ( // Errors
-	ErrEmptyTitle   = errors.New("post title cannot be empty")
-	ErrEmptyContent = errors.New("post content cannot be empty")
-	ErrPostNotFound = errors.New("post not found")
+	ErrEmptyTitle    = errors.New("post title cannot be empty")
+	ErrEmptyContent  = errors.New("post content cannot be empty")
+	ErrInvalidStatus = errors.New("post status must be draft or published")
+
+	ErrSummaryTooLong    = errors.New("post summary cannot exceed 300 characters")
+	ErrInvalidCoverImage = errors.New("cover image must be an http(s) URL or a path starting with /")
+	ErrInvalidDate       = errors.New("date must be YYYY-MM-DD or RFC 3339")
+	ErrPostNotFound      = errors.New("post not found")
+
+	ErrMediaNotFound        = errors.New("media not found")
+	ErrMediaTooLarge        = errors.New("file is too large")
+	ErrUnsupportedMediaType = errors.New("only JPEG, PNG and GIF images are allowed")
+
+	ErrEmptyComment            = errors.New("comment cannot be empty")
+	ErrCommentTooLong          = errors.New("comment cannot exceed 5000 characters")
+	ErrAuthorTooLong           = errors.New("name cannot exceed 80 characters")
+	ErrCommentNotFound         = errors.New("comment not found")
+	ErrInvalidEmail            = errors.New("email address is not valid")
+	ErrInvalidModerationStatus = errors.New("comment status must be pending, approved or rejected")
+
+	ErrInvalidReaction = errors.New("unknown reaction")
+
+	ErrInvalidWebhookURL   = errors.New("webhook URL must be an absolute http(s) URL")
+	ErrNoWebhookEvents     = errors.New("choose at least one event")
+	ErrUnknownWebhookEvent = errors.New("unknown webhook event")
+	ErrWebhookNotFound     = errors.New("webhook not found")
+	ErrDeliveryNotFound    = errors.New("delivery not found")
+
+	ErrChangeStreamsUnsupported = errors.New("change streams need a replica set or sharded cluster")
+	ErrResumeTokenLost          = errors.New("change stream can no longer resume from the saved token")
+
+	ErrWorkerStopped = errors.New("background worker has stopped")
 )
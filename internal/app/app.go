@@ -2,19 +2,55 @@ package app
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"time"
 
+	handleradmin "news-svc/internal/controller/web/v1/admin"
+	handleranalytics "news-svc/internal/controller/web/v1/analytics"
+	handlercomment "news-svc/internal/controller/web/v1/comment"
+	handlerfeed "news-svc/internal/controller/web/v1/feed"
+	handlermedia "news-svc/internal/controller/web/v1/media"
 	handlerpost "news-svc/internal/controller/web/v1/post"
+	handlersitemap "news-svc/internal/controller/web/v1/sitemap"
+	"news-svc/internal/controller/web/v1/static"
+	handlerwebhook "news-svc/internal/controller/web/v1/webhook"
+	svcanalytics "news-svc/internal/service/analytics"
+	svccomment "news-svc/internal/service/comment"
+	svceventsource "news-svc/internal/service/eventsource"
+	svcmedia "news-svc/internal/service/media"
+	svcoutbox "news-svc/internal/service/outbox"
 	svcpost "news-svc/internal/service/post"
+	svcreaction "news-svc/internal/service/reaction"
+	svcrelated "news-svc/internal/service/related"
+	svctrending "news-svc/internal/service/trending"
+	svcwebhook "news-svc/internal/service/webhook"
+	repoanalytics "news-svc/internal/storage/mongo/analytics"
+	repocomment "news-svc/internal/storage/mongo/comment"
+	repoevent "news-svc/internal/storage/mongo/event"
+	repomedia "news-svc/internal/storage/mongo/media"
 	repopost "news-svc/internal/storage/mongo/post"
+	reporeaction "news-svc/internal/storage/mongo/reaction"
+	repowebhook "news-svc/internal/storage/mongo/webhook"
 
 	"news-svc/config"
+	"news-svc/internal/entity/event"
+	"news-svc/pkg/auth"
+	"news-svc/pkg/blob"
+	"news-svc/pkg/csrf"
+	"news-svc/pkg/health"
 	"news-svc/pkg/httpserver"
+	"news-svc/pkg/metrics"
+	"news-svc/pkg/middleware"
 	"news-svc/pkg/mongo"
+	"news-svc/pkg/pubsub"
+	"news-svc/pkg/ratelimit"
+	"news-svc/pkg/spam"
+	"news-svc/pkg/tracing"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 )
 
@@ -23,22 +59,30 @@ func Run(cfg config.Config) {
 	if cfg.Server.IsDev {
 		logLevel = slog.LevelDebug
 	}
-	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: logLevel}))
+	logger := slog.New(middleware.LogHandler(tracing.LogHandler(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: logLevel}))))
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
-	defer cancel()
-	client, err := mongo.New(ctx,
-		cfg.Mongo.User,
-		cfg.Mongo.Password,
-		cfg.Mongo.Host,
-		cfg.Mongo.Port,
-		cfg.Mongo.Name,
-	)
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing.Exporter, cfg.Tracing.ServiceName, cfg.Tracing.SampleRatio)
+	if err != nil {
+		logger.Error("unable to set up tracing", "err", err)
+		return
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			logger.Error("tracing shutdown error", "err", err)
+		}
+	}()
+
+	client, err := connectMongo(context.Background(), cfg, logger)
 	if err != nil {
 		logger.Error("unable to connect to MongoDB", "err", err)
 		return
 	}
 
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
 	logger.Info("connected to MongoDB", "db", cfg.Mongo.Name)
 
 	defer func() {
@@ -48,18 +92,146 @@ func Run(cfg config.Config) {
 	}()
 
 	postRepo := repopost.New(client.Instance())
-	postSvc := svcpost.New(postRepo)
+	if err := postRepo.EnsureIndexes(ctx); err != nil {
+		logger.Error("unable to ensure post indexes", "err", err)
+		return
+	}
+	// each live stream may fall this many events behind before it is dropped
+	events := pubsub.New[event.Event](64)
+
+	webhookRepo := repowebhook.New(client.Instance())
+	if err := webhookRepo.EnsureIndexes(ctx); err != nil {
+		logger.Error("unable to ensure webhook indexes", "err", err)
+		return
+	}
+	webhookSvc := svcwebhook.New(webhookRepo, cfg.Webhooks, cfg.Site, logger)
+	webhookSvc.Start()
+
+	eventRepo := repoevent.New(client.Instance())
+	if err := eventRepo.EnsureIndexes(ctx); err != nil {
+		logger.Error("unable to ensure event indexes", "err", err)
+		return
+	}
+
+	// with a Mongo event source every write, this instance's included,
+	// comes back through it, so the outbox must not publish to the bus too
+	var localEvents interface{ Publish(event.Event) } = events
+	if cfg.Events.Consumer == "" {
+		cfg.Events.Consumer, _ = os.Hostname()
+	}
+	eventSrc := svceventsource.New(eventRepo, postRepo, events, cfg.Events, logger)
+	switch cfg.Events.Source {
+	case "local":
+	case "mongo":
+		localEvents = nil
+		eventSrc.Start()
+	default:
+		logger.Error("unknown events source", "source", cfg.Events.Source)
+		return
+	}
+	outboxSvc := svcoutbox.New(eventRepo, localEvents, webhookSvc, cfg.Outbox, logger)
+	outboxSvc.Start()
+
+	relatedSvc := svcrelated.New(postRepo, logger)
+	postSvc := svcpost.New(postRepo, relatedSvc, outboxSvc)
+
+	commentRepo := repocomment.New(client.Instance())
+	if err := commentRepo.EnsureIndexes(ctx); err != nil {
+		logger.Error("unable to ensure comment indexes", "err", err)
+		return
+	}
+	commentSvc := svccomment.New(commentRepo, postRepo, cfg.Comments, spam.NewBayes())
+	if n, err := commentSvc.Train(ctx); err != nil {
+		logger.Error("unable to train spam filter", "err", err)
+	} else {
+		logger.Info("trained spam filter", "comments", n)
+	}
+
+	reactionRepo := reporeaction.New(client.Instance())
+	if err := reactionRepo.EnsureIndexes(ctx); err != nil {
+		logger.Error("unable to ensure reaction indexes", "err", err)
+		return
+	}
+	reactionSvc := svcreaction.New(reactionRepo, postRepo)
+
+	analyticsRepo := repoanalytics.New(client.Instance())
+	if err := analyticsRepo.EnsureIndexes(ctx); err != nil {
+		logger.Error("unable to ensure analytics indexes", "err", err)
+		return
+	}
+	analyticsSvc := svcanalytics.New(analyticsRepo, postRepo, cfg.Analytics, logger)
+	analyticsSvc.Start()
+
+	trendingSvc := svctrending.New(postRepo, analyticsRepo, cfg.Trending, logger)
+	trendingSvc.Start()
+
+	blobs, err := newBlobStore(cfg.Media, client)
+	if err != nil {
+		logger.Error("unable to init media storage", "err", err)
+		return
+	}
+	mediaRepo := repomedia.New(client.Instance())
+	if err := mediaRepo.EnsureIndexes(ctx); err != nil {
+		logger.Error("unable to ensure media indexes", "err", err)
+		return
+	}
+	mediaSvc := svcmedia.New(mediaRepo, blobs, cfg.Media.MaxSize)
+
+	adminAuth := auth.NewBasic(cfg.Admin.User, cfg.Admin.Password)
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("/ping", func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte("pong"))
 	})
+	mux.Handle("GET /metrics", metrics.Handler())
+
+	probes := health.New()
+	probes.Add("mongo", client.Ping)
+	probes.Add("analytics", analyticsSvc.Check)
+	probes.Add("trending", trendingSvc.Check)
+	probes.Add("events", eventSrc.Check)
+	probes.Add("outbox", outboxSvc.Check)
+	probes.Add("webhooks", webhookSvc.Check)
+	mux.HandleFunc("GET /healthz", probes.Live)
+	mux.HandleFunc("GET /readyz", probes.Ready)
 
-	handlerpost.InitHandler(mux, postSvc, logger)
+	handlerpost.InitHandler(mux, postSvc, commentSvc, reactionSvc, analyticsSvc, trendingSvc, relatedSvc, events, cfg.Site, logger)
+	handlercomment.InitHandler(mux, commentSvc, adminAuth, logger)
+	handleradmin.InitHandler(mux, postSvc, adminAuth, logger)
+	handleranalytics.InitHandler(mux, analyticsSvc, adminAuth, logger)
+	handlermedia.InitHandler(mux, mediaSvc, adminAuth, cfg.Media.MaxSize, logger)
+	handlerfeed.InitHandler(mux, postSvc, cfg.Site, logger)
+	handlersitemap.InitHandler(mux, postSvc, cfg.Site, cfg.Robots, logger)
+	handlerwebhook.InitHandler(mux, webhookSvc, adminAuth, logger)
+	static.InitHandler(mux)
+
+	// everything from metrics.HTTP inwards sees the request the mux
+	// records the matched pattern on
+	https := strings.HasPrefix(cfg.Site.URL, "https://")
+	protect := csrf.New([]byte(cfg.CSRF.Secret), https, logger)
+	mws := []middleware.Middleware{
+		middleware.RequestID,
+		middleware.SecurityHeaders(https),
+		tracing.HTTP,
+		protect.HTTP,
+		metrics.HTTP,
+		middleware.AccessLog(logger),
+	}
+	if cfg.RateLimit.Enabled {
+		limiter, err := newRateLimiter(ctx, cfg.RateLimit, client, adminAuth, logger)
+		if err != nil {
+			logger.Error("unable to init rate limiting", "err", err)
+			return
+		}
+		mws = append(mws, limiter.HTTP)
+	}
+	mws = append(mws, middleware.Recover(logger))
+	handler := middleware.Chain(mux, mws...)
 
 	srv := httpserver.New(
-		mux,
+		handler,
 		httpserver.Port(cfg.Server.Port),
+		httpserver.OnShutdown(events.Close),
 	)
 
 	logger.Info("starting http server", "port", cfg.Server.Port)
@@ -69,7 +241,9 @@ func Run(cfg config.Config) {
 
 	select {
 	case sig := <-sigCh:
-		logger.Info("shutdown signal received", "signal", sig.String())
+		logger.Info("shutdown signal received", "signal", sig.String(), "drain_delay", cfg.Server.DrainDelay)
+		probes.Drain()
+		time.Sleep(cfg.Server.DrainDelay)
 	case err := <-srv.Notify():
 		logger.Error("HTTP server error", "err", err)
 	}
@@ -79,4 +253,92 @@ func Run(cfg config.Config) {
 	} else {
 		logger.Info("server stopped gracefully")
 	}
+
+	// views tracked by the last requests are still buffered
+	flushCtx, flushCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer flushCancel()
+	if err := analyticsSvc.Close(flushCtx); err != nil {
+		logger.Error("analytics flush error", "err", err)
+	}
+	if err := trendingSvc.Close(flushCtx); err != nil {
+		logger.Error("trending shutdown error", "err", err)
+	}
+	if err := eventSrc.Close(flushCtx); err != nil {
+		logger.Error("event source shutdown error", "err", err)
+	}
+	if err := outboxSvc.Close(flushCtx); err != nil {
+		logger.Error("outbox shutdown error", "err", err)
+	}
+	if err := webhookSvc.Close(flushCtx); err != nil {
+		logger.Error("webhook shutdown error", "err", err)
+	}
+}
+
+func newBlobStore(cfg config.Media, client *mongo.Mongo) (blob.Store, error) {
+	switch cfg.Backend {
+	case "local":
+		return blob.NewLocal(cfg.Dir)
+	case "gridfs":
+		return blob.NewGridFS(client.Instance(), "media"), nil
+	default:
+		return nil, fmt.Errorf("unknown media backend %q", cfg.Backend)
+	}
+}
+
+func newRateLimiter(ctx context.Context, cfg config.RateLimit, client *mongo.Mongo, admin auth.Basic, logger *slog.Logger) (*ratelimit.Limiter, error) {
+	var store ratelimit.Store
+	switch cfg.Store {
+	case "memory":
+		store = ratelimit.NewMemory()
+	case "mongo":
+		s := ratelimit.NewMongo(client.Instance(), "rate_limits")
+		if err := s.EnsureIndexes(ctx); err != nil {
+			return nil, err
+		}
+		store = s
+	default:
+		return nil, fmt.Errorf("unknown rate limit store %q", cfg.Store)
+	}
+
+	trusted, err := ratelimit.ParsePrefixes(cfg.TrustedProxies)
+	if err != nil {
+		return nil, fmt.Errorf("trusted proxies: %w", err)
+	}
+	clientIP := ratelimit.ClientIP(trusted)
+	key := func(r *http.Request) string {
+		if user, ok := admin.Check(r); ok {
+			return "user:" + user
+		}
+		return "ip:" + clientIP(r)
+	}
+
+	limits := map[string]ratelimit.Limit{
+		"read":   {PerMinute: cfg.ReadPerMinute, Burst: cfg.ReadBurst},
+		"search": {PerMinute: cfg.SearchPerMinute, Burst: cfg.SearchBurst},
+		"write":  {PerMinute: cfg.WritePerMinute, Burst: cfg.WriteBurst},
+	}
+	return ratelimit.New(store, limits, rateLimitGroup, key, logger), nil
+}
+
+// rateLimitGroup sorts requests into the groups limited separately.
+// Probes and metrics are left alone, as are the live event stream and
+// media, which a single page loads many of.
+func rateLimitGroup(r *http.Request) string {
+	switch p := r.URL.Path; {
+	case p == "/ping", p == "/healthz", p == "/readyz", p == "/metrics", p == "/events",
+		strings.HasPrefix(p, "/media/"), strings.HasPrefix(p, "/static/"):
+		return ""
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		if r.URL.Query().Get("q") != "" {
+			return "search"
+		}
+		return "read"
+	case http.MethodOptions:
+		return ""
+	default:
+		return "write"
+	}
 }
@@ -0,0 +1,276 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"news-svc/config"
+	"news-svc/internal/entity/post"
+	svccomment "news-svc/internal/service/comment"
+	svcmedia "news-svc/internal/service/media"
+	svcpost "news-svc/internal/service/post"
+	svcrelated "news-svc/internal/service/related"
+	repocomment "news-svc/internal/storage/mongo/comment"
+	repomedia "news-svc/internal/storage/mongo/media"
+	repopost "news-svc/internal/storage/mongo/post"
+	"news-svc/pkg/metrics"
+	"news-svc/pkg/mongo"
+	"news-svc/pkg/tracing"
+)
+
+// RunCommand executes a one-off CLI subcommand instead of the HTTP server.
+func RunCommand(cfg config.Config, name string, args []string) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	switch name {
+	case "export":
+		return runExport(ctx, cfg, args)
+	case "import":
+		return runImport(ctx, cfg, name, args)
+	case "import-wxr":
+		return runImport(ctx, cfg, name, args)
+	case "media-variants":
+		return runMediaVariants(ctx, cfg)
+	default:
+		return fmt.Errorf("unknown command %q (available: export, import, import-wxr, media-variants)", name)
+	}
+}
+
+func runExport(ctx context.Context, cfg config.Config, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	from := fs.String("from", "", "only posts created on or after this date (YYYY-MM-DD or RFC 3339)")
+	to := fs.String("to", "", "only posts created on or before this date (YYYY-MM-DD or RFC 3339)")
+	status := fs.String("status", "", "only posts with this status (draft, published)")
+	out := fs.String("o", "", "output file (default stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	f, err := post.NewFilter(*from, *to, *status)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+
+	client, err := connectMongo(ctx, cfg, slog.Default())
+	if err != nil {
+		return err
+	}
+	defer client.Close(context.Background())
+
+	svc := svcpost.New(repopost.New(client.Instance()), nil, nil)
+	return svc.Export(ctx, f, w)
+}
+
+// runImport serves both the JSONL "import" and the WordPress "import-wxr"
+// commands, which differ only in how the input is parsed.
+func runImport(ctx context.Context, cfg config.Config, name string, args []string) error {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	in := fs.String("i", "", "input file (default stdin)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var r io.ReadSeeker = os.Stdin
+	if *in != "" {
+		file, err := os.Open(*in)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		r = file
+	} else if name == "import-wxr" {
+		// WXR is read twice, for posts and then for their comments
+		tmp, err := spool(os.Stdin)
+		if err != nil {
+			return err
+		}
+		defer os.Remove(tmp.Name())
+		defer tmp.Close()
+		r = tmp
+	}
+
+	client, err := connectMongo(ctx, cfg, slog.Default())
+	if err != nil {
+		return err
+	}
+	defer client.Close(context.Background())
+
+	postRepo := repopost.New(client.Instance())
+	if err := postRepo.EnsureIndexes(ctx); err != nil {
+		return err
+	}
+
+	// imports write posts in bulk, so related posts are computed for
+	// all of them at the end rather than one by one
+	svc := svcpost.New(postRepo, nil, nil)
+	relatedSvc := svcrelated.New(postRepo, slog.Default())
+	if name == "import" {
+		report, err := svc.Import(ctx, r)
+		if err == nil {
+			err = relatedSvc.RelateAll(ctx)
+		}
+		return printReport(report, report.Failed, report.Written, err)
+	}
+
+	report, err := svc.ImportWXR(ctx, r)
+	if err == nil {
+		err = relatedSvc.RelateAll(ctx)
+	}
+	if err != nil {
+		return printReport(report, report.Failed, report.Written, err)
+	}
+
+	commentRepo := repocomment.New(client.Instance())
+	if err := commentRepo.EnsureIndexes(ctx); err != nil {
+		return err
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	comments, err := svccomment.New(commentRepo, postRepo, cfg.Comments, nil).ImportWXR(ctx, r)
+	combined := struct {
+		Posts    svcpost.ImportReport    `json:"posts"`
+		Comments svccomment.ImportReport `json:"comments"`
+	}{report, comments}
+
+	return printReport(combined, report.Failed+comments.Failed, report.Written+comments.Written, err)
+}
+
+// printReport writes an import report to stderr. The report is useful
+// even when the import was cut short.
+func printReport(report any, failed, written int, err error) error {
+	enc := json.NewEncoder(os.Stderr)
+	enc.SetIndent("", "  ")
+	if encErr := enc.Encode(report); encErr != nil && err == nil {
+		err = encErr
+	}
+	if err == nil && failed > 0 {
+		err = fmt.Errorf("%d of %d records failed", failed, failed+written)
+	}
+
+	return err
+}
+
+// spool copies r into a temporary file so that it can be re-read.
+func spool(r io.Reader) (*os.File, error) {
+	tmp, err := os.CreateTemp("", "news-svc-import-*")
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return nil, err
+	}
+
+	return tmp, nil
+}
+
+// runMediaVariants regenerates the resized variants of every uploaded
+// image and strips their metadata. It is safe to re-run.
+func runMediaVariants(ctx context.Context, cfg config.Config) error {
+	client, err := connectMongo(ctx, cfg, slog.Default())
+	if err != nil {
+		return err
+	}
+	defer client.Close(context.Background())
+
+	blobs, err := newBlobStore(cfg.Media, client)
+	if err != nil {
+		return err
+	}
+	svc := svcmedia.New(repomedia.New(client.Instance()), blobs, cfg.Media.MaxSize)
+
+	const pageSize = 100
+	var done, failed int
+	for page := int64(1); ; page++ {
+		items, _, err := svc.GetAll(ctx, page, pageSize)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range items {
+			if err := svc.Reprocess(ctx, m.ID); err != nil {
+				fmt.Fprintf(os.Stderr, "%s (%s): %v\n", m.ID, m.Filename, err)
+				failed++
+				continue
+			}
+			done++
+		}
+
+		if len(items) < pageSize {
+			break
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "processed %d images, %d failed\n", done, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d images failed", failed)
+	}
+	return nil
+}
+
+func connectMongo(ctx context.Context, cfg config.Config, logger *slog.Logger) (*mongo.Mongo, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.Mongo.StartupTimeout)
+	defer cancel()
+
+	m := cfg.Mongo
+	uri := m.URI
+	if uri == "" {
+		uri = mongo.URI(m.User, m.Password, m.Host, m.Port)
+	}
+
+	opts := []mongo.Option{
+		mongo.Logger(logger),
+		mongo.PoolMonitor(metrics.PoolMonitor()),
+		mongo.CommandMonitor(tracing.CommandMonitor()),
+		mongo.PoolSize(m.MinPoolSize, m.MaxPoolSize),
+		mongo.Timeouts(m.ConnectTimeout, m.ServerSelectionTimeout),
+	}
+	if m.ReplicaSet != "" {
+		opts = append(opts, mongo.ReplicaSet(m.ReplicaSet))
+	}
+	if m.TLS || m.TLSCAFile != "" {
+		tlsCfg, err := mongo.TLSConfig(m.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("mongo TLS: %w", err)
+		}
+		opts = append(opts, mongo.TLS(tlsCfg))
+	}
+	if m.ReadPreference != "" {
+		rp, err := mongo.ParseReadPreference(m.ReadPreference)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, mongo.ReadPreference(rp))
+	}
+	if m.WriteConcern != "" {
+		wc, err := mongo.ParseWriteConcern(m.WriteConcern)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, mongo.WriteConcern(wc))
+	}
+
+	return mongo.New(ctx, uri, m.Name, opts...)
+}
@@ -0,0 +1,65 @@
+package admin
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"news-svc/internal/entity/post"
+	"news-svc/internal/entity/reaction"
+)
+
+// postsPageSize is the number of posts per admin list page.
+const postsPageSize = 50
+
+func (h handler) Posts(w http.ResponseWriter, r *http.Request) {
+	sort := r.URL.Query().Get("sort")
+	if sort != post.SortReactions {
+		sort = post.SortNewest
+	}
+	page, _ := strconv.ParseInt(r.URL.Query().Get("page"), 10, 64)
+	if page < 1 {
+		page = 1
+	}
+
+	posts, total, err := h.svc.GetAllSorted(r.Context(), sort, page, postsPageSize)
+	if err != nil {
+		h.l.ErrorContext(r.Context(), "Admin posts error", "err", err)
+		http.Error(w, "server error", http.StatusInternalServerError)
+		return
+	}
+
+	h.tmpl.Render(w, "posts", PostsPageData{
+		Posts:      posts,
+		Kinds:      reaction.Kinds,
+		Sort:       sort,
+		Page:       page,
+		TotalPages: max(int64(math.Ceil(float64(total)/postsPageSize)), 1),
+	})
+}
+
+func (h handler) Export(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	f, err := post.NewFilter(q.Get("from"), q.Get("to"), q.Get("status"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// exports can outlive the server's write timeout
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		h.l.DebugContext(r.Context(), "Export write deadline", "err", err)
+	}
+
+	filename := fmt.Sprintf("posts-%s.jsonl", time.Now().UTC().Format("20060102-150405"))
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	// headers are already sent once streaming starts, so a failure
+	// midway can only be logged and leaves a truncated file behind
+	if err := h.svc.Export(r.Context(), f, w); err != nil {
+		h.l.ErrorContext(r.Context(), "Export error", "err", err)
+	}
+}
@@ -0,0 +1,135 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"news-svc/internal/entity/post"
+	"news-svc/pkg/auth"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockService struct {
+	exportFn func(ctx context.Context, f post.Filter, w io.Writer) error
+	sortedFn func(ctx context.Context, sort string, page, limit int64) ([]*post.Post, int64, error)
+}
+
+func (m *mockService) Export(ctx context.Context, f post.Filter, w io.Writer) error {
+	return m.exportFn(ctx, f, w)
+}
+func (m *mockService) GetAllSorted(ctx context.Context, sort string, page, limit int64) ([]*post.Post, int64, error) {
+	return m.sortedFn(ctx, sort, page, limit)
+}
+
+func newMux(ms *mockService) *http.ServeMux {
+	mux := http.NewServeMux()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	InitHandler(mux, ms, auth.NewBasic("admin", "secret"), logger)
+	return mux
+}
+
+func TestExportRequiresAuth(t *testing.T) {
+	mux := newMux(&mockService{})
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/admin/export", nil)
+	mux.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.NotEmpty(t, rr.Header().Get("WWW-Authenticate"))
+
+	rr = httptest.NewRecorder()
+	req.SetBasicAuth("admin", "wrong")
+	mux.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
+
+func TestExportStreamsJSONL(t *testing.T) {
+	mux := newMux(&mockService{
+		exportFn: func(ctx context.Context, f post.Filter, w io.Writer) error {
+			assert.Equal(t, post.StatusDraft, f.Status)
+			assert.False(t, f.From.IsZero())
+			_, err := w.Write([]byte("{}\n"))
+			return err
+		},
+	})
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/admin/export?from=2024-01-01&status=draft", nil)
+	req.SetBasicAuth("admin", "secret")
+	mux.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/x-ndjson", rr.Header().Get("Content-Type"))
+	assert.Contains(t, rr.Header().Get("Content-Disposition"), "attachment")
+	assert.Equal(t, "{}\n", rr.Body.String())
+}
+
+func TestExportBadFilter(t *testing.T) {
+	mux := newMux(&mockService{
+		exportFn: func(ctx context.Context, f post.Filter, w io.Writer) error {
+			return errors.New("must not be called")
+		},
+	})
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/admin/export?status=archived", nil)
+	req.SetBasicAuth("admin", "secret")
+	mux.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestPostsSortedByReactions(t *testing.T) {
+	mux := newMux(&mockService{
+		sortedFn: func(ctx context.Context, sort string, page, limit int64) ([]*post.Post, int64, error) {
+			assert.Equal(t, post.SortReactions, sort)
+			assert.Equal(t, int64(2), page)
+			return []*post.Post{{
+				ID:            "p1",
+				Title:         "Popular",
+				Status:        post.StatusPublished,
+				Reactions:     map[string]int64{"like": 5, "sad": 2},
+				ReactionCount: 7,
+			}}, 120, nil
+		},
+	})
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/admin/posts?sort=reactions&page=2", nil)
+	req.SetBasicAuth("admin", "secret")
+	mux.ServeHTTP(rr, req)
+	body := rr.Body.String()
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, body, `<a href="/posts/p1">Popular</a>`)
+	assert.Contains(t, body, "Reactions ▼")
+	assert.Contains(t, body, `<a href="/admin/posts?sort=newest">Created</a>`)
+	assert.Contains(t, body, `<td class="num">5</td>`)
+	assert.Contains(t, body, `<td class="num">7</td>`)
+	assert.Contains(t, body, "Page 2 of 3")
+	assert.Contains(t, body, `href="/admin/posts?sort=reactions&page=3"`)
+}
+
+func TestPostsDefaultsToNewest(t *testing.T) {
+	mux := newMux(&mockService{
+		sortedFn: func(ctx context.Context, sort string, page, limit int64) ([]*post.Post, int64, error) {
+			assert.Equal(t, post.SortNewest, sort)
+			return nil, 0, errors.New("boom")
+		},
+	})
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/admin/posts?sort=bogus", nil)
+	req.SetBasicAuth("admin", "secret")
+	mux.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+}
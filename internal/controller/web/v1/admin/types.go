@@ -0,0 +1,52 @@
+package admin
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"news-svc/internal/entity/post"
+	"news-svc/internal/entity/reaction"
+)
+
+type (
+	service interface {
+		Export(ctx context.Context, f post.Filter, w io.Writer) error
+		GetAllSorted(ctx context.Context, sort string, page, limit int64) ([]*post.Post, int64, error)
+	}
+
+	templateRenderer interface {
+		Render(wr io.Writer, name string, data any) error
+	}
+
+	authenticator interface {
+		Wrap(next http.HandlerFunc) http.HandlerFunc
+	}
+
+	handler struct {
+		svc  service
+		tmpl templateRenderer
+		l    *slog.Logger
+	}
+)
+
+func InitHandler(
+	mux *http.ServeMux,
+	svc service,
+	auth authenticator,
+	l *slog.Logger,
+) {
+	h := handler{svc, newTemplates(), l}
+
+	mux.HandleFunc("GET /admin/posts", auth.Wrap(h.Posts))
+	mux.HandleFunc("GET /admin/export", auth.Wrap(h.Export))
+}
+
+// PostsPageData is a page of the admin post list.
+type PostsPageData struct {
+	Posts      []*post.Post
+	Kinds      []reaction.Kind
+	Sort       string
+	Page       int64
+	TotalPages int64
+}
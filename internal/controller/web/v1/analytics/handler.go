@@ -0,0 +1,112 @@
+package analytics
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"news-svc/internal/entity/analytics"
+)
+
+const (
+	// topPosts is the length of the most-read list.
+	topPosts = 20
+	// hourlyWindow is how far back the hourly chart of a post goes.
+	hourlyWindow = 48 * time.Hour
+)
+
+// ranges are the selectable dashboard periods in days.
+var ranges = []int{1, 7, 30, 90}
+
+func (h handler) Dashboard(w http.ResponseWriter, r *http.Request) {
+	days := rangeDays(r)
+	from, to := dayRange(time.Now(), days)
+
+	daily, err := h.svc.Series(r.Context(), analytics.Daily, "", from, to)
+	if err != nil {
+		h.l.ErrorContext(r.Context(), "Analytics series error", "err", err)
+		http.Error(w, "server error", http.StatusInternalServerError)
+		return
+	}
+
+	top, err := h.svc.Top(r.Context(), from, to, topPosts)
+	if err != nil {
+		h.l.ErrorContext(r.Context(), "Analytics top error", "err", err)
+		http.Error(w, "server error", http.StatusInternalServerError)
+		return
+	}
+
+	h.tmpl.Render(w, "dashboard", DashboardData{
+		Days:   days,
+		Ranges: ranges,
+		Daily:  chart(daily),
+		Top:    top,
+	})
+}
+
+// Post is the breakdown of one post, loaded into the dashboard.
+func (h handler) Post(w http.ResponseWriter, r *http.Request) {
+	postID := r.PathValue("id")
+	days := rangeDays(r)
+	now := time.Now()
+
+	from, to := dayRange(now, days)
+	daily, err := h.svc.Series(r.Context(), analytics.Daily, postID, from, to)
+	if err != nil {
+		h.l.ErrorContext(r.Context(), "Analytics series error", "err", err)
+		http.Error(w, "server error", http.StatusInternalServerError)
+		return
+	}
+
+	to = analytics.Start(analytics.Hourly, now).Add(time.Hour)
+	hourly, err := h.svc.Series(r.Context(), analytics.Hourly, postID, to.Add(-hourlyWindow), to)
+	if err != nil {
+		h.l.ErrorContext(r.Context(), "Analytics series error", "err", err)
+		http.Error(w, "server error", http.StatusInternalServerError)
+		return
+	}
+
+	h.tmpl.Render(w, "post_stats", PostData{
+		PostID: postID,
+		Days:   days,
+		Hourly: chart(hourly),
+		Daily:  chart(daily),
+	})
+}
+
+// rangeDays reads the selected period, falling back to a week.
+func rangeDays(r *http.Request) int {
+	days, _ := strconv.Atoi(r.URL.Query().Get("days"))
+	if !slices.Contains(ranges, days) {
+		return 7
+	}
+	return days
+}
+
+// dayRange covers the given number of days up to and including today.
+func dayRange(now time.Time, days int) (time.Time, time.Time) {
+	to := analytics.Start(analytics.Daily, now).AddDate(0, 0, 1)
+	return to.AddDate(0, 0, -days), to
+}
+
+func chart(points []analytics.Point) Chart {
+	var (
+		c    Chart
+		peak int64
+	)
+	for _, p := range points {
+		c.Views += p.Views
+		c.Visitors += p.Visitors
+		peak = max(peak, p.Views)
+	}
+
+	c.Bars = make([]Bar, len(points))
+	for i, p := range points {
+		c.Bars[i] = Bar{Point: p}
+		if peak > 0 {
+			c.Bars[i].Height = int(p.Views * 100 / peak)
+		}
+	}
+	return c
+}
@@ -0,0 +1,115 @@
+package analytics
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"news-svc/internal/entity/analytics"
+	"news-svc/pkg/auth"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockService struct {
+	seriesFn func(ctx context.Context, period, postID string, from, to time.Time) ([]analytics.Point, error)
+	topFn    func(ctx context.Context, from, to time.Time, limit int64) ([]analytics.PostStats, error)
+}
+
+func (m *mockService) Series(ctx context.Context, period, postID string, from, to time.Time) ([]analytics.Point, error) {
+	return m.seriesFn(ctx, period, postID, from, to)
+}
+func (m *mockService) Top(ctx context.Context, from, to time.Time, limit int64) ([]analytics.PostStats, error) {
+	return m.topFn(ctx, from, to, limit)
+}
+
+func newMux(ms *mockService) *http.ServeMux {
+	mux := http.NewServeMux()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	InitHandler(mux, ms, auth.NewBasic("admin", "secret"), logger)
+	return mux
+}
+
+func adminGet(path string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.SetBasicAuth("admin", "secret")
+	return req
+}
+
+func TestDashboard(t *testing.T) {
+	mux := newMux(&mockService{
+		seriesFn: func(ctx context.Context, period, postID string, from, to time.Time) ([]analytics.Point, error) {
+			assert.Equal(t, analytics.Daily, period)
+			assert.Empty(t, postID)
+			assert.Equal(t, 30*24*time.Hour, to.Sub(from))
+			assert.True(t, to.After(time.Now()))
+			return []analytics.Point{{Views: 10, Visitors: 4}, {Views: 5, Visitors: 2}}, nil
+		},
+		topFn: func(ctx context.Context, from, to time.Time, limit int64) ([]analytics.PostStats, error) {
+			assert.Equal(t, int64(topPosts), limit)
+			return []analytics.PostStats{
+				{PostID: "p1", Title: "Big story", Views: 12, Visitors: 5},
+				{PostID: "p2", Views: 3, Visitors: 1},
+			}, nil
+		},
+	})
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/admin/analytics", nil))
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, adminGet("/admin/analytics?days=30"))
+	body := rr.Body.String()
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, body, "<strong>15</strong> views")
+	assert.Contains(t, body, "<strong>6</strong> visitors")
+	assert.Contains(t, body, `data-height="100"`)
+	assert.Contains(t, body, `data-height="50"`)
+	assert.Contains(t, body, `<a href="/posts/p1">Big story</a>`)
+	assert.Contains(t, body, "<em>deleted post</em>")
+	assert.Contains(t, body, `hx-get="/admin/analytics/posts/p1?days=30"`)
+	assert.Contains(t, body, "<strong>30d</strong>")
+}
+
+func TestPostStats(t *testing.T) {
+	var periods []string
+	mux := newMux(&mockService{
+		seriesFn: func(ctx context.Context, period, postID string, from, to time.Time) ([]analytics.Point, error) {
+			assert.Equal(t, "p1", postID)
+			periods = append(periods, period)
+			if period == analytics.Hourly {
+				assert.Equal(t, hourlyWindow, to.Sub(from))
+				return []analytics.Point{{Views: 3}}, nil
+			}
+			assert.Equal(t, 7*24*time.Hour, to.Sub(from))
+			return []analytics.Point{{Views: 8}}, nil
+		},
+	})
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, adminGet("/admin/analytics/posts/p1?days=5"))
+	body := rr.Body.String()
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, []string{analytics.Daily, analytics.Hourly}, periods)
+	assert.NotContains(t, body, "<html")
+	assert.Contains(t, body, "<strong>3</strong> views")
+	assert.Contains(t, body, "<strong>8</strong> views")
+	assert.Contains(t, body, "Last 7 day(s)")
+}
+
+func TestChart(t *testing.T) {
+	c := chart([]analytics.Point{{Views: 0}, {Views: 4, Visitors: 1}, {Views: 1}})
+
+	assert.Equal(t, int64(5), c.Views)
+	assert.Equal(t, int64(1), c.Visitors)
+	assert.Equal(t, []int{0, 100, 25}, []int{c.Bars[0].Height, c.Bars[1].Height, c.Bars[2].Height})
+
+	assert.Zero(t, chart([]analytics.Point{{}}).Bars[0].Height)
+}
@@ -0,0 +1,74 @@
+package analytics
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"news-svc/internal/entity/analytics"
+	"time"
+)
+
+type (
+	service interface {
+		Series(ctx context.Context, period, postID string, from, to time.Time) ([]analytics.Point, error)
+		Top(ctx context.Context, from, to time.Time, limit int64) ([]analytics.PostStats, error)
+	}
+
+	templateRenderer interface {
+		Render(wr io.Writer, name string, data any) error
+	}
+
+	authenticator interface {
+		Wrap(next http.HandlerFunc) http.HandlerFunc
+	}
+
+	handler struct {
+		svc  service
+		tmpl templateRenderer
+		l    *slog.Logger
+	}
+)
+
+func InitHandler(
+	mux *http.ServeMux,
+	svc service,
+	auth authenticator,
+	l *slog.Logger,
+) {
+	h := handler{svc, newTemplates(), l}
+
+	mux.HandleFunc("GET /admin/analytics", auth.Wrap(h.Dashboard))
+	mux.HandleFunc("GET /admin/analytics/posts/{id}", auth.Wrap(h.Post))
+}
+
+type (
+	// Bar is a point of a chart with its height relative to the
+	// largest point, in percent.
+	Bar struct {
+		analytics.Point
+		Height int
+	}
+
+	// Chart is a series with its totals. Visitors are unique per day,
+	// so over several days they add up to visits rather than people.
+	Chart struct {
+		Bars     []Bar
+		Views    int64
+		Visitors int64
+	}
+
+	DashboardData struct {
+		Days   int
+		Ranges []int
+		Daily  Chart
+		Top    []analytics.PostStats
+	}
+
+	PostData struct {
+		PostID string
+		Days   int
+		Hourly Chart
+		Daily  Chart
+	}
+)
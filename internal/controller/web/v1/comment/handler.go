@@ -0,0 +1,218 @@
+package comment
+
+import (
+	"cmp"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+
+	"news-svc/config"
+	"news-svc/internal/entity/comment"
+)
+
+// threadsPerPage is the number of top-level comments per page.
+const threadsPerPage = 20
+
+func (h handler) List(w http.ResponseWriter, r *http.Request) {
+	postID := r.PathValue("id")
+	page, _ := strconv.ParseInt(r.URL.Query().Get("page"), 10, 64)
+	if page < 1 {
+		page = 1
+	}
+
+	threads, roots, err := h.svc.Threads(r.Context(), postID, page, threadsPerPage)
+	if err != nil {
+		if errors.Is(err, config.ErrPostNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		h.l.ErrorContext(r.Context(), "List comments error", "err", err)
+		http.Error(w, "server error", http.StatusInternalServerError)
+		return
+	}
+
+	data := CommentsData{
+		PostID:  postID,
+		Threads: threads,
+		Form:    FormData{PostID: postID},
+	}
+	if page*threadsPerPage < roots {
+		data.NextPage = page + 1
+	}
+
+	if page > 1 {
+		h.tmpl.Render(w, "threads", data)
+		return
+	}
+
+	data.Total = h.count(r, postID)
+	h.tmpl.Render(w, "comments", data)
+}
+
+func (h handler) Create(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "bad form", http.StatusBadRequest)
+		return
+	}
+
+	c := &comment.Comment{
+		PostID:   r.PathValue("id"),
+		ParentID: r.Form.Get("parent_id"),
+		Author:   r.Form.Get("author"),
+		Email:    r.Form.Get("email"),
+		Content:  r.Form.Get("content"),
+	}
+
+	if _, err := h.svc.Create(r.Context(), c, clientIP(r)); err != nil {
+		h.formError(w, r, c, err)
+		return
+	}
+
+	if !c.Visible() {
+		h.held(w, c)
+		return
+	}
+
+	data := CreatedData{
+		Total: h.count(r, c.PostID),
+		Form:  FormData{PostID: c.PostID, Author: c.Author, Email: c.Email, OOB: true},
+	}
+	if c.RootID == "" {
+		data.Thread = &comment.Thread{Root: c}
+	} else {
+		data.Reply = c
+	}
+
+	w.Header().Set("HX-Trigger", "commentCreated")
+	h.tmpl.Render(w, "created", data)
+}
+
+func (h handler) Replies(w http.ResponseWriter, r *http.Request) {
+	rootID := r.PathValue("id")
+	page, _ := strconv.ParseInt(r.URL.Query().Get("page"), 10, 64)
+	if page < 2 {
+		// the first page comes with the thread
+		page = 2
+	}
+
+	replies, total, err := h.svc.Replies(r.Context(), rootID, page)
+	if err != nil {
+		if errors.Is(err, config.ErrCommentNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		h.l.ErrorContext(r.Context(), "Replies error", "err", err)
+		http.Error(w, "server error", http.StatusInternalServerError)
+		return
+	}
+
+	data := RepliesData{RootID: rootID, Replies: replies}
+	if page*comment.RepliesPerPage < total {
+		data.NextPage = page + 1
+	}
+
+	h.tmpl.Render(w, "replies", data)
+}
+
+func (h handler) ReplyForm(w http.ResponseWriter, r *http.Request) {
+	parent, err := h.svc.GetByID(r.Context(), r.PathValue("id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	h.tmpl.Render(w, "reply_form", FormData{
+		PostID:   parent.PostID,
+		ParentID: parent.ID,
+		RootID:   cmp.Or(parent.RootID, parent.ID),
+	})
+}
+
+// count returns the number of comments on a post for the heading. It
+// is cosmetic, so failures only hide the number.
+func (h handler) count(r *http.Request, postID string) int64 {
+	counts, err := h.svc.Counts(r.Context(), []string{postID})
+	if err != nil {
+		h.l.ErrorContext(r.Context(), "Count comments error", "err", err)
+		return 0
+	}
+	return counts[postID]
+}
+
+// held replaces the submitted form with a notice instead of adding a
+// comment the reader would not see after a reload.
+func (h handler) held(w http.ResponseWriter, c *comment.Comment) {
+	const notice = "Thanks! Your comment is awaiting moderation."
+
+	if c.ParentID == "" {
+		w.Header().Set("HX-Retarget", "#comment-form")
+		w.Header().Set("HX-Reswap", "outerHTML")
+		h.tmpl.Render(w, "comment_form", FormData{
+			PostID: c.PostID,
+			Author: c.Author,
+			Email:  c.Email,
+			Notice: notice,
+		})
+		return
+	}
+
+	w.Header().Set("HX-Retarget", "#reply-form-"+c.ParentID)
+	w.Header().Set("HX-Reswap", "innerHTML")
+	h.tmpl.Render(w, "notice", notice)
+}
+
+// clientIP is the address of the connection, used for rate limiting.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// formError re-renders the form that was submitted, keeping what the
+// reader typed, in place of the form rather than in the comment list.
+func (h handler) formError(w http.ResponseWriter, r *http.Request, c *comment.Comment, err error) {
+	msg := err.Error()
+	switch {
+	case errors.Is(err, config.ErrEmptyComment),
+		errors.Is(err, config.ErrCommentTooLong),
+		errors.Is(err, config.ErrAuthorTooLong),
+		errors.Is(err, config.ErrInvalidEmail),
+		errors.Is(err, config.ErrPostNotFound),
+		errors.Is(err, config.ErrCommentNotFound):
+	default:
+		h.l.ErrorContext(r.Context(), "Create comment error", "err", err)
+		msg = "could not post the comment, please try again"
+	}
+
+	form := FormData{
+		PostID:   c.PostID,
+		ParentID: c.ParentID,
+		Author:   c.Author,
+		Email:    c.Email,
+		Content:  c.Content,
+		Error:    msg,
+	}
+
+	// the parent ID comes from the client, so it is only used as a
+	// selector once it is known to exist
+	var parent *comment.Comment
+	if c.ParentID != "" {
+		parent, _ = h.svc.GetByID(r.Context(), c.ParentID)
+	}
+
+	if parent == nil {
+		form.ParentID = ""
+		w.Header().Set("HX-Retarget", "#comment-form")
+		w.Header().Set("HX-Reswap", "outerHTML")
+		h.tmpl.Render(w, "comment_form", form)
+		return
+	}
+
+	form.RootID = cmp.Or(parent.RootID, parent.ID)
+	w.Header().Set("HX-Retarget", "#reply-form-"+parent.ID)
+	w.Header().Set("HX-Reswap", "innerHTML")
+	h.tmpl.Render(w, "reply_form", form)
+}
@@ -0,0 +1,374 @@
+package comment
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"news-svc/config"
+	"news-svc/internal/entity/comment"
+	"news-svc/pkg/auth"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockService struct {
+	createFn   func(ctx context.Context, c *comment.Comment, ip string) (string, error)
+	getByIDFn  func(ctx context.Context, id string) (*comment.Comment, error)
+	threadsFn  func(ctx context.Context, postID string, page, limit int64) ([]*comment.Thread, int64, error)
+	repliesFn  func(ctx context.Context, rootID string, page int64) ([]*comment.Comment, int64, error)
+	queueFn    func(ctx context.Context, status string, page, limit int64) ([]*comment.Comment, int64, error)
+	moderateFn func(ctx context.Context, ids []string, status string) (int64, error)
+}
+
+func (m *mockService) Create(ctx context.Context, c *comment.Comment, ip string) (string, error) {
+	return m.createFn(ctx, c, ip)
+}
+func (m *mockService) GetByID(ctx context.Context, id string) (*comment.Comment, error) {
+	if m.getByIDFn == nil {
+		return nil, config.ErrCommentNotFound
+	}
+	return m.getByIDFn(ctx, id)
+}
+func (m *mockService) Threads(ctx context.Context, postID string, page, limit int64) ([]*comment.Thread, int64, error) {
+	return m.threadsFn(ctx, postID, page, limit)
+}
+func (m *mockService) Replies(ctx context.Context, rootID string, page int64) ([]*comment.Comment, int64, error) {
+	return m.repliesFn(ctx, rootID, page)
+}
+func (m *mockService) Counts(ctx context.Context, postIDs []string) (map[string]int64, error) {
+	return map[string]int64{postIDs[0]: 7}, nil
+}
+func (m *mockService) Queue(ctx context.Context, status string, page, limit int64) ([]*comment.Comment, int64, error) {
+	return m.queueFn(ctx, status, page, limit)
+}
+func (m *mockService) Moderate(ctx context.Context, ids []string, status string) (int64, error) {
+	return m.moderateFn(ctx, ids, status)
+}
+
+func newMux(ms *mockService) *http.ServeMux {
+	mux := http.NewServeMux()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	InitHandler(mux, ms, auth.NewBasic("admin", "secret"), logger)
+	return mux
+}
+
+func postForm(path string, form url.Values) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req
+}
+
+func TestListFirstPage(t *testing.T) {
+	mux := newMux(&mockService{
+		threadsFn: func(ctx context.Context, postID string, page, limit int64) ([]*comment.Thread, int64, error) {
+			assert.Equal(t, "p1", postID)
+			assert.Equal(t, int64(1), page)
+			return []*comment.Thread{{
+				Root:       &comment.Comment{ID: "c1", Author: "Ann", Content: "<script>x</script>Hello", CreatedAt: time.Now()},
+				Replies:    []*comment.Comment{{ID: "c2", Depth: 1, Content: "Hi Ann"}},
+				ReplyCount: 15,
+			}}, 45, nil
+		},
+	})
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/posts/p1/comments", nil))
+	body := rr.Body.String()
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, body, `<span id="comment-count">7</span>`)
+	assert.Contains(t, body, `id="comment-form"`)
+	assert.Contains(t, body, `id="comment-c1"`)
+	assert.Contains(t, body, "Hello")
+	assert.NotContains(t, body, "<script>")
+	assert.Contains(t, body, `<li class="depth-1">`)
+	assert.Contains(t, body, "Anonymous")
+	assert.Contains(t, body, `/comments/c1/replies?page=2`)
+	assert.Contains(t, body, `/posts/p1/comments?page=2`)
+}
+
+func TestListLaterPage(t *testing.T) {
+	mux := newMux(&mockService{
+		threadsFn: func(ctx context.Context, postID string, page, limit int64) ([]*comment.Thread, int64, error) {
+			return []*comment.Thread{{Root: &comment.Comment{ID: "c9"}}}, 45, nil
+		},
+	})
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/posts/p1/comments?page=3", nil))
+	body := rr.Body.String()
+
+	assert.Contains(t, body, `id="comment-c9"`)
+	assert.NotContains(t, body, `id="comment-form"`)
+	assert.NotContains(t, body, "Load more comments")
+}
+
+func TestListPostNotFound(t *testing.T) {
+	mux := newMux(&mockService{
+		threadsFn: func(ctx context.Context, postID string, page, limit int64) ([]*comment.Thread, int64, error) {
+			return nil, 0, config.ErrPostNotFound
+		},
+	})
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/posts/nope/comments", nil))
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestCreateTopLevel(t *testing.T) {
+	mux := newMux(&mockService{
+		createFn: func(ctx context.Context, c *comment.Comment, ip string) (string, error) {
+			assert.Equal(t, "p1", c.PostID)
+			assert.Equal(t, "Ann", c.Author)
+			assert.Equal(t, "ann@example.com", c.Email)
+			assert.Equal(t, "192.0.2.1", ip)
+			c.ID = "c1"
+			return c.ID, nil
+		},
+	})
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, postForm("/posts/p1/comments", url.Values{"author": {"Ann"}, "email": {"ann@example.com"}, "content": {"Hi"}}))
+	body := rr.Body.String()
+
+	assert.Equal(t, "commentCreated", rr.Header().Get("HX-Trigger"))
+	assert.Contains(t, body, `<li class="thread">`)
+	assert.Contains(t, body, `id="replies-c1"`)
+	assert.Contains(t, body, `hx-swap-oob="true">`)
+	assert.Contains(t, body, `value="Ann"`)
+	assert.Contains(t, body, `<span id="comment-count" hx-swap-oob="true">7</span>`)
+}
+
+func TestCreateReply(t *testing.T) {
+	mux := newMux(&mockService{
+		createFn: func(ctx context.Context, c *comment.Comment, ip string) (string, error) {
+			assert.Equal(t, "c1", c.ParentID)
+			c.ID, c.RootID, c.Depth = "c2", "c1", 1
+			return c.ID, nil
+		},
+	})
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, postForm("/posts/p1/comments", url.Values{"parent_id": {"c1"}, "content": {"Hi"}}))
+	body := rr.Body.String()
+
+	assert.Contains(t, body, `<li class="depth-1">`)
+	assert.Contains(t, body, `<div id="reply-form-c1" hx-swap-oob="innerHTML"></div>`)
+	assert.NotContains(t, body, `id="comment-form"`)
+}
+
+func TestCreateErrors(t *testing.T) {
+	mux := newMux(&mockService{
+		createFn: func(ctx context.Context, c *comment.Comment, ip string) (string, error) {
+			return "", config.ErrEmptyComment
+		},
+		getByIDFn: func(ctx context.Context, id string) (*comment.Comment, error) {
+			if id == "c2" {
+				return &comment.Comment{ID: "c2", PostID: "p1", RootID: "c1"}, nil
+			}
+			return nil, config.ErrCommentNotFound
+		},
+	})
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, postForm("/posts/p1/comments", url.Values{"author": {"Ann"}}))
+
+	assert.Equal(t, "#comment-form", rr.Header().Get("HX-Retarget"))
+	assert.Equal(t, "outerHTML", rr.Header().Get("HX-Reswap"))
+	assert.Contains(t, rr.Body.String(), config.ErrEmptyComment.Error())
+	assert.Contains(t, rr.Body.String(), `value="Ann"`)
+
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, postForm("/posts/p1/comments", url.Values{"parent_id": {"c2"}}))
+
+	assert.Equal(t, "#reply-form-c2", rr.Header().Get("HX-Retarget"))
+	assert.Contains(t, rr.Body.String(), `hx-target="#replies-c1"`)
+
+	// unknown parents never end up in a selector
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, postForm("/posts/p1/comments", url.Values{"parent_id": {`x"], body`}}))
+
+	assert.Equal(t, "#comment-form", rr.Header().Get("HX-Retarget"))
+}
+
+func TestCreateHeld(t *testing.T) {
+	mux := newMux(&mockService{
+		createFn: func(ctx context.Context, c *comment.Comment, ip string) (string, error) {
+			c.ID, c.Status = "c9", comment.StatusPending
+			return c.ID, nil
+		},
+	})
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, postForm("/posts/p1/comments", url.Values{"author": {"Ann"}, "content": {"Buy now"}}))
+	body := rr.Body.String()
+
+	assert.Empty(t, rr.Header().Get("HX-Trigger"))
+	assert.Equal(t, "#comment-form", rr.Header().Get("HX-Retarget"))
+	assert.Contains(t, body, "awaiting moderation")
+	assert.Contains(t, body, `value="Ann"`)
+	assert.NotContains(t, body, "Buy now")
+	assert.NotContains(t, body, `id="comment-c9"`)
+
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, postForm("/posts/p1/comments", url.Values{"parent_id": {"c1"}, "content": {"Buy now"}}))
+
+	assert.Equal(t, "#reply-form-c1", rr.Header().Get("HX-Retarget"))
+	assert.Equal(t, "innerHTML", rr.Header().Get("HX-Reswap"))
+	assert.Contains(t, rr.Body.String(), "awaiting moderation")
+}
+
+func adminRequest(req *http.Request) *http.Request {
+	req.SetBasicAuth("admin", "secret")
+	return req
+}
+
+func TestQueue(t *testing.T) {
+	mux := newMux(&mockService{
+		queueFn: func(ctx context.Context, status string, page, limit int64) ([]*comment.Comment, int64, error) {
+			assert.Equal(t, comment.StatusPending, status)
+			assert.Equal(t, int64(1), page)
+			return []*comment.Comment{{
+				ID:        "c1",
+				PostID:    "p1",
+				Email:     "bot@example.com",
+				Content:   "<b>cheap</b>",
+				Status:    comment.StatusPending,
+				HeldFor:   []string{"spam score 0.97"},
+				SpamScore: 0.97,
+			}}, 120, nil
+		},
+	})
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/admin/comments", nil))
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, adminRequest(httptest.NewRequest(http.MethodGet, "/admin/comments", nil)))
+	body := rr.Body.String()
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, body, "<title>Comment moderation</title>")
+	assert.Contains(t, body, `name="id" value="c1"`)
+	assert.Contains(t, body, "bot@example.com")
+	assert.Contains(t, body, "&lt;b&gt;cheap&lt;/b&gt;")
+	assert.Contains(t, body, "<li>spam score 0.97</li>")
+	assert.Contains(t, body, `hx-post="/admin/comments/c1/approve"`)
+	assert.Contains(t, body, "Page 1 of 3")
+}
+
+func TestQueueInvalidStatus(t *testing.T) {
+	mux := newMux(&mockService{
+		queueFn: func(ctx context.Context, status string, page, limit int64) ([]*comment.Comment, int64, error) {
+			return nil, 0, config.ErrInvalidModerationStatus
+		},
+	})
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, adminRequest(httptest.NewRequest(http.MethodGet, "/admin/comments?status=spam", nil)))
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestModerate(t *testing.T) {
+	mux := newMux(&mockService{
+		moderateFn: func(ctx context.Context, ids []string, status string) (int64, error) {
+			assert.Equal(t, comment.StatusRejected, status)
+			if ids[0] == "c1" {
+				return 1, nil
+			}
+			return 0, nil
+		},
+	})
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, adminRequest(httptest.NewRequest(http.MethodPost, "/admin/comments/c1/reject", nil)))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, adminRequest(httptest.NewRequest(http.MethodPost, "/admin/comments/nope/reject", nil)))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, adminRequest(httptest.NewRequest(http.MethodPost, "/admin/comments/c1/delete", nil)))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestModerateMany(t *testing.T) {
+	mux := newMux(&mockService{
+		moderateFn: func(ctx context.Context, ids []string, status string) (int64, error) {
+			assert.Equal(t, []string{"c1", "c2"}, ids)
+			assert.Equal(t, comment.StatusApproved, status)
+			return 2, nil
+		},
+		queueFn: func(ctx context.Context, status string, page, limit int64) ([]*comment.Comment, int64, error) {
+			assert.Equal(t, comment.StatusRejected, status)
+			assert.Equal(t, int64(2), page)
+			return nil, 60, nil
+		},
+	})
+
+	form := url.Values{"id": {"c1", "c2"}, "action": {"approve"}, "status": {"rejected"}, "page": {"2"}}
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, adminRequest(postForm("/admin/comments/moderate", form)))
+	body := rr.Body.String()
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, body, `<form id="moderation-queue"`)
+	assert.NotContains(t, body, "<html")
+	assert.Contains(t, body, "2 comment(s) approved.")
+
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, adminRequest(postForm("/admin/comments/moderate", url.Values{"action": {"delete"}})))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestReplies(t *testing.T) {
+	mux := newMux(&mockService{
+		repliesFn: func(ctx context.Context, rootID string, page int64) ([]*comment.Comment, int64, error) {
+			assert.Equal(t, "c1", rootID)
+			assert.Equal(t, int64(2), page)
+			return []*comment.Comment{{ID: "r11", Depth: 2}}, 25, nil
+		},
+	})
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/comments/c1/replies", nil))
+	body := rr.Body.String()
+
+	assert.Contains(t, body, `id="comment-r11"`)
+	assert.Contains(t, body, `/comments/c1/replies?page=3`)
+}
+
+func TestReplyForm(t *testing.T) {
+	mux := newMux(&mockService{
+		getByIDFn: func(ctx context.Context, id string) (*comment.Comment, error) {
+			if id == "c2" {
+				return &comment.Comment{ID: "c2", PostID: "p1", RootID: "c1"}, nil
+			}
+			return nil, config.ErrCommentNotFound
+		},
+	})
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/comments/c2/reply", nil))
+	body := rr.Body.String()
+
+	assert.Contains(t, body, `hx-post="/posts/p1/comments"`)
+	assert.Contains(t, body, `hx-target="#replies-c1"`)
+	assert.Contains(t, body, `name="parent_id" value="c2"`)
+
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/comments/nope/reply", nil))
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
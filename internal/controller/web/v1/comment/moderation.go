@@ -0,0 +1,112 @@
+package comment
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"news-svc/config"
+	"news-svc/internal/entity/comment"
+	"news-svc/pkg/csrf"
+)
+
+// queuePageSize is the number of comments per moderation page.
+const queuePageSize = 50
+
+// actions maps the moderation buttons to the status they set.
+var actions = map[string]string{
+	"approve": comment.StatusApproved,
+	"reject":  comment.StatusRejected,
+}
+
+func (h handler) Queue(w http.ResponseWriter, r *http.Request) {
+	status := cmp.Or(r.URL.Query().Get("status"), comment.StatusPending)
+	page, _ := strconv.ParseInt(r.URL.Query().Get("page"), 10, 64)
+
+	h.renderQueue(w, r, "moderation", status, page, "")
+}
+
+// Moderate approves or rejects a single comment from its queue row. The
+// row is removed by the client, so a success has no body.
+func (h handler) Moderate(w http.ResponseWriter, r *http.Request) {
+	status, ok := actions[r.PathValue("action")]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	n, err := h.svc.Moderate(r.Context(), []string{r.PathValue("id")}, status)
+	if err != nil {
+		h.l.ErrorContext(r.Context(), "Moderate error", "err", err)
+		http.Error(w, "server error", http.StatusInternalServerError)
+		return
+	}
+	if n == 0 {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// ModerateMany applies the bulk action to the checked comments and
+// re-renders the queue page it was submitted from.
+func (h handler) ModerateMany(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "bad form", http.StatusBadRequest)
+		return
+	}
+
+	status := cmp.Or(r.Form.Get("status"), comment.StatusPending)
+	page, _ := strconv.ParseInt(r.Form.Get("page"), 10, 64)
+
+	action := r.Form.Get("action")
+	target, ok := actions[action]
+	if !ok {
+		http.Error(w, "unknown action", http.StatusBadRequest)
+		return
+	}
+
+	notice := "No comments selected."
+	if ids := r.Form["id"]; len(ids) > 0 {
+		n, err := h.svc.Moderate(r.Context(), ids, target)
+		if err != nil {
+			h.l.ErrorContext(r.Context(), "Moderate error", "err", err)
+			http.Error(w, "server error", http.StatusInternalServerError)
+			return
+		}
+		notice = fmt.Sprintf("%d comment(s) %s.", n, target)
+	}
+
+	h.renderQueue(w, r, "queue", status, page, notice)
+}
+
+func (h handler) renderQueue(w http.ResponseWriter, r *http.Request, name, status string, page int64, notice string) {
+	if page < 1 {
+		page = 1
+	}
+
+	comments, total, err := h.svc.Queue(r.Context(), status, page, queuePageSize)
+	if err != nil {
+		if errors.Is(err, config.ErrInvalidModerationStatus) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		h.l.ErrorContext(r.Context(), "Queue error", "err", err)
+		http.Error(w, "server error", http.StatusInternalServerError)
+		return
+	}
+
+	h.tmpl.Render(w, name, QueueData{
+		Status:     status,
+		Statuses:   []string{comment.StatusPending, comment.StatusApproved, comment.StatusRejected},
+		Comments:   comments,
+		Page:       page,
+		TotalPages: max(int64(math.Ceil(float64(total)/queuePageSize)), 1),
+		Notice:     notice,
+		CSRFToken:  csrf.Token(r.Context()),
+	})
+}
@@ -0,0 +1,35 @@
+package comment
+
+import (
+	"embed"
+	"html/template"
+	"io"
+
+	"news-svc/internal/controller/web/v1/static"
+	"news-svc/pkg/sanitize"
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+type templates struct {
+	tmpl *template.Template
+}
+
+func newTemplates() *templates {
+	root := template.New("").Funcs(template.FuncMap{
+		"asset": static.Path,
+
+		// content renders comment bodies with the same rules as posts
+		"content": func(s string) template.HTML { return template.HTML(sanitize.Render(s)) },
+		"add":     func(a, b int64) int64 { return a + b },
+		"sub":     func(a, b int64) int64 { return a - b },
+	})
+	tmpl := template.Must(root.ParseFS(templateFS, "templates/*.html"))
+
+	return &templates{tmpl}
+}
+
+func (t templates) Render(wr io.Writer, name string, data any) error {
+	return t.tmpl.ExecuteTemplate(wr, name, data)
+}
@@ -0,0 +1,107 @@
+package comment
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"news-svc/internal/entity/comment"
+)
+
+type (
+	service interface {
+		Create(ctx context.Context, c *comment.Comment, ip string) (string, error)
+		GetByID(ctx context.Context, id string) (*comment.Comment, error)
+		Threads(ctx context.Context, postID string, page, limit int64) ([]*comment.Thread, int64, error)
+		Replies(ctx context.Context, rootID string, page int64) ([]*comment.Comment, int64, error)
+		Counts(ctx context.Context, postIDs []string) (map[string]int64, error)
+		Queue(ctx context.Context, status string, page, limit int64) ([]*comment.Comment, int64, error)
+		Moderate(ctx context.Context, ids []string, status string) (int64, error)
+	}
+
+	templateRenderer interface {
+		Render(wr io.Writer, name string, data any) error
+	}
+
+	authenticator interface {
+		Wrap(next http.HandlerFunc) http.HandlerFunc
+	}
+
+	handler struct {
+		svc  service
+		tmpl templateRenderer
+		l    *slog.Logger
+	}
+)
+
+func InitHandler(
+	mux *http.ServeMux,
+	svc service,
+	auth authenticator,
+	l *slog.Logger,
+) {
+	h := handler{svc, newTemplates(), l}
+
+	mux.HandleFunc("GET /posts/{id}/comments", h.List)
+	mux.HandleFunc("POST /posts/{id}/comments", h.Create)
+
+	mux.HandleFunc("GET /comments/{id}/replies", h.Replies)
+	mux.HandleFunc("GET /comments/{id}/reply", h.ReplyForm)
+
+	mux.HandleFunc("GET /admin/comments", auth.Wrap(h.Queue))
+	mux.HandleFunc("POST /admin/comments/moderate", auth.Wrap(h.ModerateMany))
+	mux.HandleFunc("POST /admin/comments/{id}/{action}", auth.Wrap(h.Moderate))
+}
+
+type (
+	// CommentsData is a page of threads. Page 1 is rendered with the
+	// heading and form, later pages only append threads.
+	CommentsData struct {
+		PostID   string
+		Threads  []*comment.Thread
+		Total    int64
+		NextPage int64
+		Form     FormData
+	}
+
+	RepliesData struct {
+		RootID   string
+		Replies  []*comment.Comment
+		NextPage int64
+	}
+
+	// FormData is the new-comment form, or the reply form when ParentID
+	// is set. OOB marks a fresh form sent along with a new comment.
+	// Notice tells the reader their comment is awaiting moderation.
+	FormData struct {
+		PostID   string
+		ParentID string
+		RootID   string
+		Author   string
+		Email    string
+		Content  string
+		Error    string
+		Notice   string
+		OOB      bool
+	}
+
+	// CreatedData is the response to a successful post: the new
+	// comment plus a fresh form to swap in out of band.
+	CreatedData struct {
+		Thread *comment.Thread
+		Reply  *comment.Comment
+		Total  int64
+		Form   FormData
+	}
+
+	// QueueData is a page of the moderation queue for one status.
+	QueueData struct {
+		Status     string
+		Statuses   []string
+		Comments   []*comment.Comment
+		Page       int64
+		TotalPages int64
+		Notice     string
+		CSRFToken  string
+	}
+)
@@ -0,0 +1,106 @@
+package feed
+
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"net/http"
+
+	"news-svc/internal/entity/post"
+	"news-svc/pkg/feed"
+	"news-svc/pkg/sanitize"
+)
+
+func (h handler) RSS(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, feed.ContentTypeRSS, feed.WriteRSS)
+}
+
+func (h handler) Atom(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, feed.ContentTypeAtom, feed.WriteAtom)
+}
+
+func (h handler) JSON(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, feed.ContentTypeJSON, feed.WriteJSON)
+}
+
+func (h handler) serve(
+	w http.ResponseWriter,
+	r *http.Request,
+	contentType string,
+	write func(io.Writer, feed.Feed) error,
+) {
+	f := post.Filter{
+		Status: post.StatusPublished,
+		Tag:    r.PathValue("tag"),
+		Author: r.PathValue("author"),
+	}
+
+	posts, err := h.svc.GetRecentBy(r.Context(), f, feedSize)
+	if err != nil {
+		h.l.ErrorContext(r.Context(), "Feed error", "err", err)
+		http.Error(w, "server error", http.StatusInternalServerError)
+		return
+	}
+
+	fd := h.build(r, f, posts)
+
+	var buf bytes.Buffer
+	if err := write(&buf, fd); err != nil {
+		h.l.ErrorContext(r.Context(), "Feed encode error", "err", err)
+		http.Error(w, "server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("ETag", etag(posts))
+	w.Header().Set("Cache-Control", "public, max-age=300")
+
+	// ServeContent answers If-None-Match / If-Modified-Since with 304
+	http.ServeContent(w, r, "", fd.Updated, bytes.NewReader(buf.Bytes()))
+}
+
+func (h handler) build(r *http.Request, f post.Filter, posts []*post.Post) feed.Feed {
+	title := h.site.Title
+	switch {
+	case f.Tag != "":
+		title = fmt.Sprintf("%s: #%s", h.site.Title, f.Tag)
+	case f.Author != "":
+		title = fmt.Sprintf("%s: %s", h.site.Title, f.Author)
+	}
+
+	fd := feed.Feed{
+		Title:       title,
+		Description: h.site.Description,
+		Link:        h.site.URL + "/posts",
+		Self:        h.site.URL + r.URL.EscapedPath(),
+	}
+
+	for _, p := range posts {
+		link := h.site.URL + "/posts/" + p.ID
+		fd.Items = append(fd.Items, feed.Item{
+			ID:         link,
+			Title:      p.Title,
+			Link:       link,
+			Summary:    p.Summary,
+			Content:    sanitize.Render(p.Content),
+			Author:     p.Author,
+			Categories: p.Tags,
+			Published:  p.CreatedAt,
+			Updated:    p.UpdatedAt,
+		})
+	}
+	fd.Updated = feed.Updated(fd.Items)
+
+	return fd
+}
+
+// etag derives a validator from the IDs and update times of the posts,
+// so edits and deletions change it even when the newest post does not.
+func etag(posts []*post.Post) string {
+	hash := fnv.New64a()
+	for _, p := range posts {
+		fmt.Fprintf(hash, "%s:%d;", p.ID, p.UpdatedAt.UnixNano())
+	}
+	return fmt.Sprintf(`"%x"`, hash.Sum64())
+}
@@ -0,0 +1,115 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"news-svc/config"
+	"news-svc/internal/entity/post"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockService struct {
+	getRecentByFn func(ctx context.Context, f post.Filter, limit int64) ([]*post.Post, error)
+}
+
+func (m *mockService) GetRecentBy(ctx context.Context, f post.Filter, limit int64) ([]*post.Post, error) {
+	return m.getRecentByFn(ctx, f, limit)
+}
+
+var updated = time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+func newMux(ms *mockService) *http.ServeMux {
+	mux := http.NewServeMux()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	site := config.Site{URL: "https://news.example", Title: "News"}
+	InitHandler(mux, ms, site, logger)
+	return mux
+}
+
+func samplePosts(ctx context.Context, f post.Filter, limit int64) ([]*post.Post, error) {
+	return []*post.Post{
+		{ID: "1", Title: "One", Content: "C", CreatedAt: updated, UpdatedAt: updated},
+	}, nil
+}
+
+func TestFeedFormats(t *testing.T) {
+	cases := map[string]string{
+		"/feed.xml":  "application/rss+xml; charset=utf-8",
+		"/atom.xml":  "application/atom+xml; charset=utf-8",
+		"/feed.json": "application/feed+json; charset=utf-8",
+	}
+
+	mux := newMux(&mockService{getRecentByFn: samplePosts})
+
+	for path, contentType := range cases {
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+
+		assert.Equal(t, http.StatusOK, rr.Code, path)
+		assert.Equal(t, contentType, rr.Header().Get("Content-Type"), path)
+		assert.Equal(t, updated.Format(http.TimeFormat), rr.Header().Get("Last-Modified"), path)
+		assert.NotEmpty(t, rr.Header().Get("ETag"), path)
+		assert.Contains(t, rr.Body.String(), "https://news.example/posts/1", path)
+	}
+}
+
+func TestFeedFilters(t *testing.T) {
+	var got post.Filter
+	mux := newMux(&mockService{
+		getRecentByFn: func(ctx context.Context, f post.Filter, limit int64) ([]*post.Post, error) {
+			got = f
+			assert.Equal(t, int64(feedSize), limit)
+			return nil, nil
+		},
+	})
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/tags/go%20lang/atom.xml", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, post.Filter{Status: post.StatusPublished, Tag: "go lang"}, got)
+
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/authors/jane/feed.json", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, post.Filter{Status: post.StatusPublished, Author: "jane"}, got)
+}
+
+func TestFeedConditionalGet(t *testing.T) {
+	mux := newMux(&mockService{getRecentByFn: samplePosts})
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/feed.xml", nil))
+	tag := rr.Header().Get("ETag")
+
+	req := httptest.NewRequest(http.MethodGet, "/feed.xml", nil)
+	req.Header.Set("If-None-Match", tag)
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNotModified, rr.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/feed.xml", nil)
+	req.Header.Set("If-Modified-Since", updated.Add(time.Minute).Format(http.TimeFormat))
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNotModified, rr.Code)
+}
+
+func TestFeedError(t *testing.T) {
+	mux := newMux(&mockService{
+		getRecentByFn: func(ctx context.Context, f post.Filter, limit int64) ([]*post.Post, error) {
+			return nil, errors.New("fail")
+		},
+	})
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/feed.xml", nil))
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+}
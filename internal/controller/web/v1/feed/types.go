@@ -0,0 +1,39 @@
+package feed
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"news-svc/config"
+	"news-svc/internal/entity/post"
+)
+
+// feedSize is the number of posts included in every feed.
+const feedSize = 20
+
+type (
+	service interface {
+		GetRecentBy(ctx context.Context, f post.Filter, limit int64) ([]*post.Post, error)
+	}
+
+	handler struct {
+		svc  service
+		site config.Site
+		l    *slog.Logger
+	}
+)
+
+func InitHandler(
+	mux *http.ServeMux,
+	svc service,
+	site config.Site,
+	l *slog.Logger,
+) {
+	h := handler{svc, site, l}
+
+	for _, prefix := range []string{"", "/tags/{tag}", "/authors/{author}"} {
+		mux.HandleFunc("GET "+prefix+"/feed.xml", h.RSS)
+		mux.HandleFunc("GET "+prefix+"/atom.xml", h.Atom)
+		mux.HandleFunc("GET "+prefix+"/feed.json", h.JSON)
+	}
+}
@@ -0,0 +1,138 @@
+package media
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"news-svc/config"
+	"news-svc/internal/entity/media"
+	"news-svc/pkg/csrf"
+)
+
+// libraryPageSize is the number of files per library page and in the picker.
+const libraryPageSize = 24
+
+// Serve streams a file or one of its resized variants. Files never
+// change once uploaded, so they can be cached forever.
+func (h handler) Serve(w http.ResponseWriter, r *http.Request) {
+	variant := r.PathValue("variant")
+
+	m, rc, err := h.svc.Open(r.Context(), r.PathValue("id"), variant)
+	if err != nil {
+		if !errors.Is(err, config.ErrMediaNotFound) {
+			h.l.ErrorContext(r.Context(), "Serve media error", "err", err)
+		}
+		http.NotFound(w, r)
+		return
+	}
+	defer rc.Close()
+
+	file, _ := m.File(variant)
+
+	etag := `"` + m.ID + `"`
+	if file.Name != media.Original {
+		etag = `"` + m.ID + "-" + file.Name + `"`
+	}
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", m.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(file.Size, 10))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": m.Filename}))
+
+	if _, err := io.Copy(w, rc); err != nil {
+		h.l.DebugContext(r.Context(), "Serve media copy", "err", err)
+	}
+}
+
+func (h handler) Library(w http.ResponseWriter, r *http.Request) {
+	page, _ := strconv.ParseInt(r.URL.Query().Get("page"), 10, 64)
+	if page < 1 {
+		page = 1
+	}
+
+	items, total, err := h.svc.GetAll(r.Context(), page, libraryPageSize)
+	if err != nil {
+		h.l.ErrorContext(r.Context(), "Library error", "err", err)
+		http.Error(w, "server error", http.StatusInternalServerError)
+		return
+	}
+
+	h.tmpl.Render(w, "library", LibraryPageData{
+		Items:      items,
+		Page:       page,
+		TotalPages: max(int64(math.Ceil(float64(total)/libraryPageSize)), 1),
+		CSRFToken:  csrf.Token(r.Context()),
+	})
+}
+
+func (h handler) Picker(w http.ResponseWriter, r *http.Request) {
+	items, _, err := h.svc.GetAll(r.Context(), 1, libraryPageSize)
+	if err != nil {
+		h.l.ErrorContext(r.Context(), "Picker error", "err", err)
+		http.Error(w, "server error", http.StatusInternalServerError)
+		return
+	}
+
+	h.tmpl.Render(w, "picker", PickerData{Items: items})
+}
+
+func (h handler) Upload(w http.ResponseWriter, r *http.Request) {
+	// leave headroom for the multipart envelope around the file
+	r.Body = http.MaxBytesReader(w, r.Body, h.maxSize+1<<20)
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			err = config.ErrMediaTooLarge
+		}
+		h.uploadError(w, err)
+		return
+	}
+	defer file.Close()
+
+	m, err := h.svc.Upload(r.Context(), header.Filename, file)
+	if err != nil {
+		h.l.ErrorContext(r.Context(), "Upload error", "err", err)
+		h.uploadError(w, err)
+		return
+	}
+
+	w.Header().Set("HX-Trigger", "mediaUploaded")
+	h.tmpl.Render(w, "media_item", m)
+}
+
+func (h handler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.svc.Delete(r.Context(), r.PathValue("id")); err != nil {
+		h.l.ErrorContext(r.Context(), "Delete media error", "err", err)
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// uploadError redirects the htmx swap from the media grid to the
+// form's error slot.
+func (h handler) uploadError(w http.ResponseWriter, err error) {
+	msg := err.Error()
+	if !errors.Is(err, config.ErrMediaTooLarge) && !errors.Is(err, config.ErrUnsupportedMediaType) {
+		msg = fmt.Sprintf("upload failed: %v", err)
+	}
+
+	w.Header().Set("HX-Retarget", "#upload-error")
+	w.Header().Set("HX-Reswap", "innerHTML")
+	h.tmpl.Render(w, "upload_error", ErrorData{Error: msg})
+}
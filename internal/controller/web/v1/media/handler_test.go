@@ -0,0 +1,172 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"news-svc/config"
+	"news-svc/internal/entity/media"
+	"news-svc/pkg/auth"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockService struct {
+	uploadFn func(ctx context.Context, filename string, r io.Reader) (*media.Media, error)
+	openFn   func(ctx context.Context, id, variant string) (*media.Media, io.ReadCloser, error)
+	getAllFn func(ctx context.Context, page, limit int64) ([]*media.Media, int64, error)
+	deleteFn func(ctx context.Context, id string) error
+}
+
+func (m *mockService) Upload(ctx context.Context, filename string, r io.Reader) (*media.Media, error) {
+	return m.uploadFn(ctx, filename, r)
+}
+
+func (m *mockService) Open(ctx context.Context, id, variant string) (*media.Media, io.ReadCloser, error) {
+	return m.openFn(ctx, id, variant)
+}
+
+func (m *mockService) GetAll(ctx context.Context, page, limit int64) ([]*media.Media, int64, error) {
+	return m.getAllFn(ctx, page, limit)
+}
+
+func (m *mockService) Delete(ctx context.Context, id string) error {
+	return m.deleteFn(ctx, id)
+}
+
+func newMux(ms *mockService, maxSize int64) *http.ServeMux {
+	mux := http.NewServeMux()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	InitHandler(mux, ms, auth.NewBasic("admin", "secret"), maxSize, logger)
+	return mux
+}
+
+func uploadRequest(t *testing.T, filename string, content []byte) *http.Request {
+	t.Helper()
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, err := mw.CreateFormFile("file", filename)
+	assert.NoError(t, err)
+	_, _ = fw.Write(content)
+	assert.NoError(t, mw.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/media", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.SetBasicAuth("admin", "secret")
+	return req
+}
+
+func TestServe(t *testing.T) {
+	m := &media.Media{
+		ID: "abc", Filename: "cat.png", ContentType: "image/png", Size: 4,
+		Variants: []media.Variant{{Name: "thumb", Size: 2}},
+	}
+	mux := newMux(&mockService{
+		openFn: func(ctx context.Context, id, variant string) (*media.Media, io.ReadCloser, error) {
+			if id != m.ID {
+				return nil, nil, config.ErrMediaNotFound
+			}
+			if variant == "thumb" {
+				return m, io.NopCloser(strings.NewReader("th")), nil
+			}
+			return m, io.NopCloser(strings.NewReader("data")), nil
+		},
+	}, 1<<20)
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/media/abc", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "image/png", rr.Header().Get("Content-Type"))
+	assert.Contains(t, rr.Header().Get("Cache-Control"), "immutable")
+	assert.Equal(t, "nosniff", rr.Header().Get("X-Content-Type-Options"))
+	assert.Equal(t, "data", rr.Body.String())
+
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/media/abc/thumb", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `"abc-thumb"`, rr.Header().Get("ETag"))
+	assert.Equal(t, "2", rr.Header().Get("Content-Length"))
+	assert.Contains(t, rr.Header().Get("Cache-Control"), "immutable")
+	assert.Equal(t, "th", rr.Body.String())
+
+	rr = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/media/abc", nil)
+	req.Header.Set("If-None-Match", `"abc"`)
+	mux.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotModified, rr.Code)
+	assert.Empty(t, rr.Body.String())
+
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/media/missing", nil))
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestAdminRoutesRequireAuth(t *testing.T) {
+	mux := newMux(&mockService{}, 1<<20)
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/admin/media", nil),
+		httptest.NewRequest(http.MethodGet, "/admin/media/picker", nil),
+		httptest.NewRequest(http.MethodPost, "/admin/media", nil),
+		httptest.NewRequest(http.MethodDelete, "/admin/media/abc", nil),
+	} {
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code, req.Method+" "+req.URL.Path)
+	}
+}
+
+func TestUploadSuccess(t *testing.T) {
+	mux := newMux(&mockService{
+		uploadFn: func(ctx context.Context, filename string, r io.Reader) (*media.Media, error) {
+			assert.Equal(t, "cat.png", filename)
+			b, _ := io.ReadAll(r)
+			assert.Equal(t, "png", string(b))
+			return &media.Media{ID: "abc", Filename: filename, ContentType: "image/png"}, nil
+		},
+	}, 1<<20)
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, uploadRequest(t, "cat.png", []byte("png")))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "mediaUploaded", rr.Header().Get("HX-Trigger"))
+	assert.Contains(t, rr.Body.String(), "/media/abc")
+}
+
+func TestUploadUnsupported(t *testing.T) {
+	mux := newMux(&mockService{
+		uploadFn: func(ctx context.Context, filename string, r io.Reader) (*media.Media, error) {
+			return nil, config.ErrUnsupportedMediaType
+		},
+	}, 1<<20)
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, uploadRequest(t, "notes.txt", []byte("hello")))
+
+	assert.Equal(t, "#upload-error", rr.Header().Get("HX-Retarget"))
+	assert.Contains(t, rr.Body.String(), config.ErrUnsupportedMediaType.Error())
+}
+
+func TestUploadTooLarge(t *testing.T) {
+	mux := newMux(&mockService{}, 16)
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, uploadRequest(t, "big.png", bytes.Repeat([]byte("x"), 2<<20)))
+
+	assert.Equal(t, "#upload-error", rr.Header().Get("HX-Retarget"))
+	assert.Contains(t, rr.Body.String(), config.ErrMediaTooLarge.Error())
+}
@@ -0,0 +1,68 @@
+package media
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"news-svc/internal/entity/media"
+)
+
+type (
+	service interface {
+		Upload(ctx context.Context, filename string, r io.Reader) (*media.Media, error)
+		Open(ctx context.Context, id, variant string) (*media.Media, io.ReadCloser, error)
+		GetAll(ctx context.Context, page, limit int64) ([]*media.Media, int64, error)
+		Delete(ctx context.Context, id string) error
+	}
+
+	templateRenderer interface {
+		Render(wr io.Writer, name string, data any) error
+	}
+
+	authenticator interface {
+		Wrap(next http.HandlerFunc) http.HandlerFunc
+	}
+
+	handler struct {
+		svc     service
+		tmpl    templateRenderer
+		maxSize int64
+		l       *slog.Logger
+	}
+)
+
+func InitHandler(
+	mux *http.ServeMux,
+	svc service,
+	auth authenticator,
+	maxSize int64,
+	l *slog.Logger,
+) {
+	h := handler{svc, newTemplates(), maxSize, l}
+
+	mux.HandleFunc("GET /media/{id}", h.Serve)
+	mux.HandleFunc("GET /media/{id}/{variant}", h.Serve)
+
+	mux.HandleFunc("GET /admin/media", auth.Wrap(h.Library))
+	mux.HandleFunc("GET /admin/media/picker", auth.Wrap(h.Picker))
+	mux.HandleFunc("POST /admin/media", auth.Wrap(h.Upload))
+	mux.HandleFunc("DELETE /admin/media/{id}", auth.Wrap(h.Delete))
+}
+
+type (
+	LibraryPageData struct {
+		Items      []*media.Media
+		Page       int64
+		TotalPages int64
+		CSRFToken  string
+	}
+
+	PickerData struct {
+		Items []*media.Media
+	}
+
+	ErrorData struct {
+		Error string
+	}
+)
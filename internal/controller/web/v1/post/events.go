@@ -0,0 +1,110 @@
+package post
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"news-svc/internal/entity/event"
+	"news-svc/internal/entity/post"
+)
+
+const (
+	// keepAlive is how often an idle stream sends a comment, so that
+	// proxies do not close it.
+	keepAlive = 30 * time.Second
+	// reconnectDelay tells browsers how long to wait before reconnecting
+	// after the stream ends, in milliseconds.
+	reconnectDelay = 5000
+)
+
+// Events streams post changes as Server-Sent Events. Published posts
+// are sent as rendered list items; deletions and unpublished posts as
+// their ID. The stream ends when the reader leaves, falls too far
+// behind, or the server shuts down; browsers then reconnect on their own.
+func (h handler) Events(w http.ResponseWriter, r *http.Request) {
+	rc := http.NewResponseController(w)
+	// the stream is meant to outlive the server's write timeout
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		h.l.ErrorContext(r.Context(), "Events error", "err", err)
+		return
+	}
+
+	sub := h.events.Subscribe()
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	// nginx buffers responses unless told otherwise
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", reconnectDelay)
+	rc.Flush()
+
+	ping := time.NewTicker(keepAlive)
+	defer ping.Stop()
+
+	for {
+		var err error
+		select {
+		case <-r.Context().Done():
+			return
+		case e, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			err = h.writeEvent(w, r, e)
+		case <-ping.C:
+			_, err = io.WriteString(w, ": ping\n\n")
+		}
+		if err == nil {
+			err = rc.Flush()
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (h handler) writeEvent(w io.Writer, r *http.Request, e event.Event) error {
+	typ := e.Type
+	if e.Post != nil && e.Post.Status != post.StatusPublished {
+		if typ == event.PostCreated {
+			return nil
+		}
+		// readers no longer see it
+		typ = event.PostDeleted
+	}
+
+	if typ == event.PostDeleted {
+		return writeSSE(w, typ, e.PostID)
+	}
+
+	// the event is shared by every stream, so decorate a copy
+	p := *e.Post
+	h.countComments(r.Context(), &p)
+
+	var buf bytes.Buffer
+	if err := h.tmpl.Render(&buf, "item", &p); err != nil {
+		h.l.ErrorContext(r.Context(), "Events render error", "err", err)
+		return nil
+	}
+	return writeSSE(w, typ, buf.String())
+}
+
+// writeSSE writes one event; every line of data needs its own field.
+func writeSSE(w io.Writer, typ, data string) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "event: %s\n", typ)
+	for _, line := range strings.Split(data, "\n") {
+		fmt.Fprintf(&b, "data: %s\n", strings.TrimSuffix(line, "\r"))
+	}
+	b.WriteString("\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
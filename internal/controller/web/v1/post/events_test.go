@@ -0,0 +1,69 @@
+package post
+
+import (
+	"bufio"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"news-svc/internal/entity/event"
+	"news-svc/internal/entity/post"
+	"news-svc/pkg/pubsub"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventsStream(t *testing.T) {
+	hub := pubsub.New[event.Event](8)
+	h := handler{comments: mockCounter{"p1": 2}, events: hub, tmpl: newTemplates(),
+		l: slog.New(slog.NewTextHandler(io.Discard, nil))}
+
+	srv := httptest.NewServer(http.HandlerFunc(h.Events))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	// the handler subscribes before it sends headers
+	require.Eventually(t, func() bool { return hub.Len() == 1 }, time.Second, 10*time.Millisecond)
+
+	published := &post.Post{ID: "p1", Title: "Breaking", Content: "News", Status: post.StatusPublished}
+	hub.Publish(event.Event{Type: event.PostCreated, PostID: "p1", Post: published})
+	hub.Publish(event.Event{Type: event.PostCreated, PostID: "d1", Post: &post.Post{ID: "d1", Status: post.StatusDraft}})
+	hub.Publish(event.Event{Type: event.PostUpdated, PostID: "p2", Post: &post.Post{ID: "p2", Status: post.StatusDraft}})
+	hub.Publish(event.Event{Type: event.PostDeleted, PostID: "p3"})
+	hub.Close()
+
+	var events []string
+	var data strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			events = append(events, strings.TrimPrefix(line, "event: "))
+		case strings.HasPrefix(line, "data: "):
+			data.WriteString(strings.TrimPrefix(line, "data: ") + "\n")
+		}
+	}
+
+	assert.Equal(t, []string{event.PostCreated, event.PostDeleted, event.PostDeleted}, events)
+	assert.Contains(t, data.String(), `<li id="post-p1" class="post-container">`)
+	assert.Contains(t, data.String(), "2 comments")
+	assert.Contains(t, data.String(), "\np2\np3\n")
+	// the shared event is left as published
+	assert.Zero(t, published.CommentCount)
+}
+
+func TestWriteSSE(t *testing.T) {
+	var b strings.Builder
+	require.NoError(t, writeSSE(&b, "post.deleted", "line one\r\nline two"))
+	assert.Equal(t, "event: post.deleted\ndata: line one\ndata: line two\n\n", b.String())
+}
@@ -1,12 +1,16 @@
 package post
 
 import (
+	"cmp"
+	"context"
 	"math"
+	"net"
 	"net/http"
 	"strconv"
 	"strings"
 
 	"news-svc/internal/entity/post"
+	"news-svc/pkg/csrf"
 )
 
 func (h handler) Index(w http.ResponseWriter, r *http.Request) {
@@ -25,44 +29,58 @@ func (h handler) List(w http.ResponseWriter, r *http.Request) {
 		limit = 3
 	}
 
+	// tag and category pages live at /tags/{tag} and /categories/{category};
+	// their htmx pagination comes back through /posts with query params
+	f := post.Filter{
+		Tag:      cmp.Or(r.PathValue("tag"), r.URL.Query().Get("tag")),
+		Category: cmp.Or(r.PathValue("category"), r.URL.Query().Get("category")),
+	}
+
 	var (
 		posts []*post.Post
 		total int64
 		err   error
 	)
 	ctx := r.Context()
-	if strings.TrimSpace(q) != "" {
+	switch {
+	case strings.TrimSpace(q) != "":
 		posts, total, err = h.svc.Search(ctx, q, page, limit)
-	} else {
+	case f.Tag != "" || f.Category != "":
+		posts, total, err = h.svc.GetAllBy(ctx, f, page, limit)
+	default:
 		posts, total, err = h.svc.GetAll(ctx, page, limit)
 	}
 	if err != nil {
-		h.l.Error("List error", "err", err)
+		h.l.ErrorContext(r.Context(), "List error", "err", err)
 		http.Error(w, "server error", http.StatusUnprocessableEntity)
 		return
 	}
 
-	recent, _ := h.svc.GetRecent(ctx, 5)
-
+	h.countComments(ctx, posts...)
+	h.markReactions(ctx, reactorID(r), posts...)
 	totalPages := int64(1)
 	if limit > 0 {
 		totalPages = int64(math.Ceil(float64(total) / float64(limit)))
 	}
 
 	data := ListPageData{
+		CSRFToken:  csrf.Token(r.Context()),
 		Posts:      posts,
-		Recent:     recent,
 		Search:     q,
+		Tag:        f.Tag,
+		Category:   f.Category,
 		Page:       page,
 		Limit:      limit,
 		Total:      total,
 		TotalPages: max(totalPages, 1),
 	}
+	data.Meta = h.listMeta(data)
 
 	if r.Header.Get("HX-Request") == "true" {
 		h.tmpl.Render(w, "list", data)
 		h.tmpl.Render(w, "pagination", data)
 	} else {
+		h.sidebar(ctx, &data)
 		h.tmpl.Render(w, "base", data)
 	}
 }
@@ -74,13 +92,15 @@ func (h handler) Create(w http.ResponseWriter, r *http.Request) {
 	}
 
 	p := &post.Post{
-		Title:   r.Form.Get("title"),
-		Content: r.Form.Get("content"),
+		Title:      r.Form.Get("title"),
+		Content:    r.Form.Get("content"),
+		Summary:    strings.TrimSpace(r.Form.Get("summary")),
+		CoverImage: strings.TrimSpace(r.Form.Get("cover_image")),
 	}
 
 	id, err := h.svc.Create(r.Context(), p)
 	if err != nil {
-		h.l.Error("Create error", "err", err)
+		h.l.ErrorContext(r.Context(), "Create error", "err", err)
 		h.tmpl.Render(w, "form", ErrorData{Error: err.Error()})
 		return
 	}
@@ -104,9 +124,11 @@ func (h handler) EditForm(w http.ResponseWriter, r *http.Request) {
 	}
 
 	data := EditFormData{
-		ID:      p.ID,
-		Title:   p.Title,
-		Content: p.Content,
+		ID:         p.ID,
+		Title:      p.Title,
+		Content:    p.Content,
+		Summary:    p.Summary,
+		CoverImage: p.CoverImage,
 	}
 
 	h.tmpl.Render(w, "edit_form", data)
@@ -119,12 +141,24 @@ func (h handler) Show(w http.ResponseWriter, r *http.Request) {
 		http.NotFound(w, r)
 		return
 	}
+	h.markReactions(r.Context(), reactorID(r), p)
+	if err := h.related.Load(r.Context(), p); err != nil {
+		h.l.ErrorContext(r.Context(), "related posts", "err", err)
+	}
+	h.trackView(r, p)
 
 	if r.Header.Get("HX-Request") == "true" {
 		h.tmpl.Render(w, "show", p)
-	} else {
-		h.tmpl.Render(w, "base", PostsData{Posts: []*post.Post{p}})
+		return
 	}
+
+	data := ListPageData{
+		Meta:      h.showMeta(p),
+		Post:      p,
+		CSRFToken: csrf.Token(r.Context()),
+	}
+	h.sidebar(r.Context(), &data)
+	h.tmpl.Render(w, "base", data)
 }
 
 func (h handler) Update(w http.ResponseWriter, r *http.Request) {
@@ -136,18 +170,24 @@ func (h handler) Update(w http.ResponseWriter, r *http.Request) {
 	}
 
 	p := &post.Post{
-		ID:      id,
-		Title:   r.Form.Get("title"),
-		Content: r.Form.Get("content"),
+		ID:         id,
+		Title:      r.Form.Get("title"),
+		Content:    r.Form.Get("content"),
+		Summary:    strings.TrimSpace(r.Form.Get("summary")),
+		CoverImage: strings.TrimSpace(r.Form.Get("cover_image")),
 	}
 
 	if err := h.svc.Update(r.Context(), p); err != nil {
-		h.l.Error("Update error", "err", err)
+		h.l.ErrorContext(r.Context(), "Update error", "err", err)
 		h.tmpl.Render(w, "edit_form", ErrorData{Error: err.Error()})
 		return
 	}
 
 	updated, _ := h.svc.GetByID(r.Context(), id)
+	if updated != nil {
+		h.countComments(r.Context(), updated)
+		h.markReactions(r.Context(), reactorID(r), updated)
+	}
 	h.tmpl.Render(w, "item", updated)
 }
 
@@ -156,10 +196,55 @@ func (h handler) Delete(w http.ResponseWriter, r *http.Request) {
 
 	err := h.svc.Delete(r.Context(), id)
 	if err != nil {
-		h.l.Error("Delete error", "err", err)
+		h.l.ErrorContext(r.Context(), "Delete error", "err", err)
 		w.WriteHeader(http.StatusUnprocessableEntity)
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
 }
+
+// countComments fills in CommentCount for display. Counts are
+// cosmetic, so failures leave them at zero.
+func (h handler) countComments(ctx context.Context, posts ...*post.Post) {
+	if len(posts) == 0 {
+		return
+	}
+
+	ids := make([]string, len(posts))
+	for i, p := range posts {
+		ids[i] = p.ID
+	}
+
+	counts, err := h.comments.Counts(ctx, ids)
+	if err != nil {
+		h.l.ErrorContext(ctx, "Count comments error", "err", err)
+		return
+	}
+
+	for _, p := range posts {
+		p.CommentCount = counts[p.ID]
+	}
+}
+
+// trackView counts a read of a published post. Speculative loads are
+// skipped since nobody may ever look at them.
+func (h handler) trackView(r *http.Request, p *post.Post) {
+	if p.Status != post.StatusPublished {
+		return
+	}
+	if r.Header.Get("Sec-Purpose") != "" || r.Header.Get("Purpose") == "prefetch" {
+		return
+	}
+
+	h.views.Track(p.ID, clientIP(r), r.UserAgent())
+}
+
+// clientIP is the address of the connection.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -29,6 +29,7 @@ type (
 		getByIDFn   func(ctx context.Context, id string) (*post.Post, error)
 		updateFn    func(ctx context.Context, p *post.Post) error
 		deleteFn    func(ctx context.Context, id string) error
+		getAllByFn  func(ctx context.Context, f post.Filter, page, limit int64) ([]*post.Post, int64, error)
 	}
 )
 
@@ -60,10 +61,63 @@ func (m *mockService) Delete(ctx context.Context, id string) error {
 	return m.deleteFn(ctx, id)
 }
 
+func (m *mockService) GetAllBy(ctx context.Context, f post.Filter, page, limit int64) ([]*post.Post, int64, error) {
+	return m.getAllByFn(ctx, f, page, limit)
+}
+
+type mockCounter map[string]int64
+
+func (m mockCounter) Counts(ctx context.Context, postIDs []string) (map[string]int64, error) {
+	return m, nil
+}
+
+type mockReactions struct {
+	toggleFn func(ctx context.Context, postID, kind, reactor string) (map[string]int64, bool, error)
+	mine     map[string][]string
+	reactors []string
+}
+
+func (m *mockReactions) Toggle(ctx context.Context, postID, kind, reactor string) (map[string]int64, bool, error) {
+	return m.toggleFn(ctx, postID, kind, reactor)
+}
+func (m *mockReactions) Mine(ctx context.Context, reactor string, postIDs []string) (map[string][]string, error) {
+	m.reactors = append(m.reactors, reactor)
+	return m.mine, nil
+}
+
+type mockViews struct {
+	tracked []string
+}
+
+func (m *mockViews) Track(postID, ip, userAgent string) {
+	m.tracked = append(m.tracked, postID+" "+ip+" "+userAgent)
+}
+
+type mockTrending struct {
+	trending, mostRead []*post.Post
+	err                error
+}
+
+func (m *mockTrending) Trending(ctx context.Context, limit int) ([]*post.Post, error) {
+	return m.trending, m.err
+}
+func (m *mockTrending) MostRead(ctx context.Context, limit int) ([]*post.Post, error) {
+	return m.mostRead, m.err
+}
+
+type mockRelated struct {
+	related []*post.Post
+}
+
+func (m *mockRelated) Load(ctx context.Context, p *post.Post) error {
+	p.RelatedPosts = m.related
+	return nil
+}
+
 func newHandler(ms *mockService) (*handler, *mockTemplates) {
 	ft := &mockTemplates{}
 	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
-	h := &handler{svc: ms, tmpl: ft, l: logger}
+	h := &handler{svc: ms, comments: mockCounter{}, reactions: &mockReactions{}, views: &mockViews{}, trending: &mockTrending{}, related: &mockRelated{}, tmpl: ft, l: logger}
 	return h, ft
 }
 
@@ -138,6 +192,39 @@ func TestListSuccessWithQuery(t *testing.T) {
 	assert.Contains(t, ft.rendered, "base")
 }
 
+func TestListByTag(t *testing.T) {
+	calledGetAllBy := false
+	ms := &mockService{
+		getAllByFn: func(ctx context.Context, f post.Filter, page, limit int64) ([]*post.Post, int64, error) {
+			calledGetAllBy = true
+			assert.Equal(t, post.Filter{Tag: "go"}, f)
+			return []*post.Post{}, 0, nil
+		},
+		getRecentFn: func(ctx context.Context, limit int64) ([]*post.Post, error) { return nil, nil },
+	}
+	hs, ft := newHandler(ms)
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/tags/go", nil)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /tags/{tag}", hs.List)
+	mux.ServeHTTP(rr, req)
+
+	assert.True(t, calledGetAllBy)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, ft.rendered, "base")
+
+	// htmx pagination of a tag page
+	calledGetAllBy = false
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/posts?page=2&tag=go", nil)
+	req.Header.Set("HX-Request", "true")
+	hs.List(rr, req)
+
+	assert.True(t, calledGetAllBy)
+	assert.Contains(t, ft.rendered, "pagination")
+}
+
 func TestListError(t *testing.T) {
 	ms := &mockService{
 		getAllFn: func(ctx context.Context, page, limit int64) ([]*post.Post, int64, error) {
@@ -222,6 +309,7 @@ func TestShowSuccess(t *testing.T) {
 			assert.Equal(t, "123", id)
 			return &post.Post{ID: id}, nil
 		},
+		getRecentFn: func(ctx context.Context, limit int64) ([]*post.Post, error) { return nil, nil },
 	}
 	hs, ft := newHandler(ms)
 
@@ -259,6 +347,63 @@ func TestShowHTMXRequest(t *testing.T) {
 	assert.NotContains(t, ft.rendered, "base")
 }
 
+func TestShowRendersRelatedPosts(t *testing.T) {
+	ms := &mockService{
+		getByIDFn: func(ctx context.Context, id string) (*post.Post, error) {
+			return &post.Post{ID: id, Title: "Budget", Content: "C", Status: post.StatusPublished}, nil
+		},
+	}
+	hs, _ := newHandler(ms)
+	hs.tmpl = newTemplates()
+	hs.related = &mockRelated{related: []*post.Post{{ID: "r1", Title: "Budget vote delayed"}}}
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/posts/123", nil)
+	req.Header.Set("HX-Request", "true")
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /posts/{id}", hs.Show)
+	mux.ServeHTTP(rr, req)
+
+	assert.Contains(t, rr.Body.String(), "Read next")
+	assert.Contains(t, rr.Body.String(), `<a href="/posts/r1">Budget vote delayed</a>`)
+}
+
+func TestShowTracksPublishedViews(t *testing.T) {
+	ms := &mockService{
+		getByIDFn: func(ctx context.Context, id string) (*post.Post, error) {
+			status := post.StatusPublished
+			if id == "draft" {
+				status = post.StatusDraft
+			}
+			return &post.Post{ID: id, Status: status}, nil
+		},
+		getRecentFn: func(ctx context.Context, limit int64) ([]*post.Post, error) { return nil, nil },
+	}
+	hs, _ := newHandler(ms)
+	mv := &mockViews{}
+	hs.views = mv
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /posts/{id}", hs.Show)
+
+	show := func(id string, headers ...string) {
+		req := httptest.NewRequest(http.MethodGet, "/posts/"+id, nil)
+		req.RemoteAddr = "192.0.2.7:51234"
+		req.Header.Set("User-Agent", "Firefox")
+		for i := 0; i < len(headers); i += 2 {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+		mux.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	show("p1")
+	show("p1", "HX-Request", "true")
+	show("draft")
+	show("p1", "Sec-Purpose", "prefetch")
+
+	assert.Equal(t, []string{"p1 192.0.2.7 Firefox", "p1 192.0.2.7 Firefox"}, mv.tracked)
+}
+
 func TestShowNotFound(t *testing.T) {
 	ms := &mockService{
 		getByIDFn: func(ctx context.Context, id string) (*post.Post, error) {
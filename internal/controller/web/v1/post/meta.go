@@ -0,0 +1,143 @@
+package post
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"news-svc/internal/entity/post"
+)
+
+// descriptionLen is roughly what search result snippets show.
+const descriptionLen = 160
+
+var (
+	tagRe   = regexp.MustCompile(`<[^>]*>`)
+	spaceRe = regexp.MustCompile(`\s+`)
+)
+
+type (
+	// Meta - per-page metadata rendered into <head> by the "meta" template.
+	Meta struct {
+		Title       string
+		Description string
+		Canonical   string
+		Type        string // og:type, "website" or "article"
+		Image       string
+		SiteName    string
+		Article     *NewsArticle
+	}
+
+	// NewsArticle - schema.org structured data for a post page.
+	NewsArticle struct {
+		Context          string   `json:"@context"`
+		Type             string   `json:"@type"`
+		Headline         string   `json:"headline"`
+		Description      string   `json:"description,omitempty"`
+		Image            []string `json:"image,omitempty"`
+		DatePublished    string   `json:"datePublished"`
+		DateModified     string   `json:"dateModified"`
+		MainEntityOfPage string   `json:"mainEntityOfPage"`
+		Author           []Thing  `json:"author,omitempty"`
+		Publisher        Thing    `json:"publisher"`
+		Keywords         []string `json:"keywords,omitempty"`
+	}
+
+	// Thing - a minimal schema.org Person or Organization.
+	Thing struct {
+		Type string `json:"@type"`
+		Name string `json:"name"`
+	}
+)
+
+func (h handler) listMeta(data ListPageData) Meta {
+	title, path := h.site.Title, "/posts"
+	switch {
+	case data.Search != "":
+		title = fmt.Sprintf("Search: %s – %s", data.Search, h.site.Title)
+	case data.Tag != "":
+		title = fmt.Sprintf("#%s – %s", data.Tag, h.site.Title)
+		path = "/tags/" + data.Tag
+	case data.Category != "":
+		title = fmt.Sprintf("%s – %s", data.Category, h.site.Title)
+		path = "/categories/" + data.Category
+	}
+
+	canonical := h.site.URL + path
+	if data.Page > 1 {
+		title = fmt.Sprintf("%s (page %d)", title, data.Page)
+		canonical = fmt.Sprintf("%s?page=%d", canonical, data.Page)
+	}
+
+	return Meta{
+		Title:       title,
+		Description: h.site.Description,
+		Canonical:   canonical,
+		Type:        "website",
+		SiteName:    h.site.Title,
+	}
+}
+
+func (h handler) showMeta(p *post.Post) Meta {
+	canonical := h.site.URL + "/posts/" + p.ID
+	description := describe(p)
+	image := h.absolute(p.CoverImage)
+
+	article := &NewsArticle{
+		Context:          "https://schema.org",
+		Type:             "NewsArticle",
+		Headline:         p.Title,
+		Description:      description,
+		DatePublished:    p.CreatedAt.UTC().Format(time.RFC3339),
+		DateModified:     p.UpdatedAt.UTC().Format(time.RFC3339),
+		MainEntityOfPage: canonical,
+		Publisher:        Thing{Type: "Organization", Name: h.site.Title},
+		Keywords:         p.Tags,
+	}
+	if image != "" {
+		article.Image = []string{image}
+	}
+	if p.Author != "" {
+		article.Author = []Thing{{Type: "Person", Name: p.Author}}
+	}
+
+	return Meta{
+		Title:       fmt.Sprintf("%s – %s", p.Title, h.site.Title),
+		Description: description,
+		Canonical:   canonical,
+		Type:        "article",
+		Image:       image,
+		SiteName:    h.site.Title,
+		Article:     article,
+	}
+}
+
+func (h handler) absolute(path string) string {
+	if strings.HasPrefix(path, "/") {
+		return h.site.URL + path
+	}
+	return path
+}
+
+// describe returns the post summary, or a plain-text excerpt of the
+// content when there is none.
+func describe(p *post.Post) string {
+	if p.Summary != "" {
+		return p.Summary
+	}
+
+	text := html.UnescapeString(tagRe.ReplaceAllString(p.Content, " "))
+	text = strings.TrimSpace(spaceRe.ReplaceAllString(text, " "))
+	if utf8.RuneCountInString(text) <= descriptionLen {
+		return text
+	}
+
+	r := []rune(text)[:descriptionLen-1]
+	if i := strings.LastIndexByte(string(r), ' '); i > descriptionLen/2 {
+		return string(r)[:i] + "…"
+	}
+	return string(r) + "…"
+}
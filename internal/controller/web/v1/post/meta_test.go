@@ -0,0 +1,94 @@
+package post
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"news-svc/config"
+	"news-svc/internal/entity/post"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testSite = config.Site{URL: "https://news.example", Title: "News", Description: "Latest news"}
+
+func TestListMeta(t *testing.T) {
+	h := handler{site: testSite}
+
+	m := h.listMeta(ListPageData{Page: 1})
+	assert.Equal(t, "News", m.Title)
+	assert.Equal(t, "https://news.example/posts", m.Canonical)
+	assert.Equal(t, "website", m.Type)
+
+	m = h.listMeta(ListPageData{Page: 2, Tag: "go"})
+	assert.Equal(t, "#go – News (page 2)", m.Title)
+	assert.Equal(t, "https://news.example/tags/go?page=2", m.Canonical)
+}
+
+func TestShowMeta(t *testing.T) {
+	h := handler{site: testSite}
+	created := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	p := &post.Post{
+		ID:         "abc",
+		Title:      "Hello",
+		Content:    "<p>Long &amp; winding</p>",
+		CoverImage: "/media/cover.jpg",
+		Author:     "Jane",
+		CreatedAt:  created,
+		UpdatedAt:  created,
+	}
+
+	m := h.showMeta(p)
+	assert.Equal(t, "Hello – News", m.Title)
+	assert.Equal(t, "Long & winding", m.Description)
+	assert.Equal(t, "https://news.example/posts/abc", m.Canonical)
+	assert.Equal(t, "https://news.example/media/cover.jpg", m.Image)
+	require.NotNil(t, m.Article)
+	assert.Equal(t, "NewsArticle", m.Article.Type)
+	assert.Equal(t, "2024-01-01T10:00:00Z", m.Article.DatePublished)
+	assert.Equal(t, []Thing{{Type: "Person", Name: "Jane"}}, m.Article.Author)
+
+	p.Summary = "Short"
+	assert.Equal(t, "Short", h.showMeta(p).Description)
+}
+
+func TestDescribeTruncates(t *testing.T) {
+	d := describe(&post.Post{Content: strings.Repeat("word ", 100)})
+	assert.LessOrEqual(t, len([]rune(d)), descriptionLen)
+	assert.True(t, strings.HasSuffix(d, "word…"))
+}
+
+// TestTemplatesRender executes the real templates, which the handler
+// tests replace with a mock.
+func TestTemplatesRender(t *testing.T) {
+	h := handler{site: testSite}
+	tmpl := newTemplates()
+	p := &post.Post{ID: "abc", Title: "Hello", Content: "World", Tags: []string{"go"}, CoverImage: "/c.jpg"}
+
+	list := ListPageData{Posts: []*post.Post{p}, Page: 1, Limit: 3, TotalPages: 1}
+	list.Meta = h.listMeta(list)
+
+	var buf bytes.Buffer
+	require.NoError(t, tmpl.Render(&buf, "base", list))
+	assert.Contains(t, buf.String(), "<title>News</title>")
+	assert.Contains(t, buf.String(), `href="/tags/go"`)
+
+	buf.Reset()
+	require.NoError(t, tmpl.Render(&buf, "base", ListPageData{Meta: h.showMeta(p), Post: p}))
+	out := buf.String()
+	assert.Contains(t, out, "<title>Hello – News</title>")
+	assert.Contains(t, out, `<link rel="canonical" href="https://news.example/posts/abc">`)
+	assert.Contains(t, out, `<meta property="og:image" content="https://news.example/c.jpg">`)
+	assert.Contains(t, out, `"@type":"NewsArticle"`)
+	assert.NotContains(t, out, `id="create-form"`)
+	assert.Contains(t, out, `<img class="cover" src="/c.jpg" alt="">`)
+
+	p.CoverImage = "/media/0123456789abcdef01234567"
+	buf.Reset()
+	require.NoError(t, tmpl.Render(&buf, "item", p))
+	assert.Contains(t, buf.String(), `srcset="/media/0123456789abcdef01234567/thumb 320w, `)
+}
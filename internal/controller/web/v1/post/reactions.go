@@ -0,0 +1,106 @@
+package post
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+
+	"news-svc/config"
+	"news-svc/internal/entity/post"
+	"news-svc/pkg/auth"
+)
+
+const (
+	// reactorCookie identifies anonymous readers so each can react
+	// once per kind. It carries a random token and nothing else.
+	reactorCookie = "reactor"
+	reactorMaxAge = 365 * 24 * 60 * 60
+)
+
+func (h handler) React(w http.ResponseWriter, r *http.Request) {
+	postID := r.PathValue("id")
+
+	reactor := h.reactor(w, r)
+
+	counts, _, err := h.reactions.Toggle(r.Context(), postID, r.PathValue("kind"), reactor)
+	if err != nil {
+		switch {
+		case errors.Is(err, config.ErrPostNotFound), errors.Is(err, config.ErrInvalidReaction):
+			http.NotFound(w, r)
+		default:
+			h.l.ErrorContext(r.Context(), "React error", "err", err)
+			http.Error(w, "server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	p := &post.Post{ID: postID, Reactions: counts}
+	h.markReactions(r.Context(), reactor, p)
+	h.tmpl.Render(w, "reactions", p)
+}
+
+// reactor identifies the reader: the signed-in user if there is one,
+// otherwise the anonymous cookie, which is issued on first use.
+func (h handler) reactor(w http.ResponseWriter, r *http.Request) string {
+	if id := reactorID(r); id != "" {
+		return id
+	}
+
+	token := make([]byte, 16)
+	_, _ = rand.Read(token)
+	value := hex.EncodeToString(token)
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     reactorCookie,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   reactorMaxAge,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	return "anon:" + value
+}
+
+// reactorID returns the reader's identity without issuing a cookie, or
+// "" for a reader who has never reacted.
+func reactorID(r *http.Request) string {
+	if user := auth.User(r.Context()); user != "" {
+		return "user:" + user
+	}
+	if c, err := r.Cookie(reactorCookie); err == nil && validToken(c.Value) {
+		return "anon:" + c.Value
+	}
+	return ""
+}
+
+func validToken(s string) bool {
+	b, err := hex.DecodeString(s)
+	return err == nil && len(b) == 16
+}
+
+// markReactions fills in MyReactions for display. Like comment counts
+// they are cosmetic, so failures only leave the buttons unpressed.
+func (h handler) markReactions(ctx context.Context, reactor string, posts ...*post.Post) {
+	if reactor == "" || len(posts) == 0 {
+		return
+	}
+
+	ids := make([]string, len(posts))
+	for i, p := range posts {
+		ids[i] = p.ID
+	}
+
+	mine, err := h.reactions.Mine(ctx, reactor, ids)
+	if err != nil {
+		h.l.ErrorContext(ctx, "Load reactions error", "err", err)
+		return
+	}
+
+	for _, p := range posts {
+		p.MyReactions = mine[p.ID]
+	}
+}
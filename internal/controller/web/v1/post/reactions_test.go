@@ -0,0 +1,123 @@
+package post
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"news-svc/config"
+	"news-svc/internal/entity/post"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testToken = "0123456789abcdef0123456789abcdef"
+
+func newReactMux(mr *mockReactions) *http.ServeMux {
+	h := handler{reactions: mr, tmpl: newTemplates(), l: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /posts/{id}/reactions/{kind}", h.React)
+	return mux
+}
+
+func TestReactIssuesCookie(t *testing.T) {
+	var reactor string
+	mr := &mockReactions{
+		toggleFn: func(ctx context.Context, postID, kind, r string) (map[string]int64, bool, error) {
+			assert.Equal(t, "p1", postID)
+			assert.Equal(t, "love", kind)
+			reactor = r
+			return map[string]int64{"love": 3}, true, nil
+		},
+		mine: map[string][]string{"p1": {"love"}},
+	}
+
+	rr := httptest.NewRecorder()
+	newReactMux(mr).ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/posts/p1/reactions/love", nil))
+	body := rr.Body.String()
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	cookies := rr.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.Equal(t, reactorCookie, cookies[0].Name)
+	assert.True(t, cookies[0].HttpOnly)
+	assert.Equal(t, "anon:"+cookies[0].Value, reactor)
+	assert.Equal(t, []string{reactor}, mr.reactors)
+
+	assert.Contains(t, body, `id="reactions-p1"`)
+	assert.Contains(t, body, `hx-post="/posts/p1/reactions/love"`)
+	assert.Contains(t, body, `aria-pressed="true"`)
+	assert.Contains(t, body, "<span>3</span>")
+	assert.Equal(t, 1, strings.Count(body, `aria-pressed="true"`))
+}
+
+func TestReactReusesCookie(t *testing.T) {
+	var reactor string
+	mr := &mockReactions{
+		toggleFn: func(ctx context.Context, postID, kind, r string) (map[string]int64, bool, error) {
+			reactor = r
+			return map[string]int64{}, false, nil
+		},
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/posts/p1/reactions/like", nil)
+	req.AddCookie(&http.Cookie{Name: reactorCookie, Value: testToken})
+	rr := httptest.NewRecorder()
+	newReactMux(mr).ServeHTTP(rr, req)
+
+	assert.Equal(t, "anon:"+testToken, reactor)
+	assert.Empty(t, rr.Result().Cookies())
+
+	// tampered cookies are replaced rather than trusted
+	req = httptest.NewRequest(http.MethodPost, "/posts/p1/reactions/like", nil)
+	req.AddCookie(&http.Cookie{Name: reactorCookie, Value: "user:admin"})
+	rr = httptest.NewRecorder()
+	newReactMux(mr).ServeHTTP(rr, req)
+
+	assert.NotEqual(t, "anon:user:admin", reactor)
+	assert.Len(t, rr.Result().Cookies(), 1)
+}
+
+func TestReactErrors(t *testing.T) {
+	for _, err := range []error{config.ErrPostNotFound, config.ErrInvalidReaction} {
+		mr := &mockReactions{
+			toggleFn: func(ctx context.Context, postID, kind, r string) (map[string]int64, bool, error) {
+				return nil, false, err
+			},
+		}
+
+		rr := httptest.NewRecorder()
+		newReactMux(mr).ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/posts/p1/reactions/x", nil))
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	}
+}
+
+func TestListMarksReactions(t *testing.T) {
+	posts := []*post.Post{{ID: "p1"}, {ID: "p2"}}
+	ms := &mockService{
+		getAllFn: func(ctx context.Context, page, limit int64) ([]*post.Post, int64, error) {
+			return posts, 2, nil
+		},
+		getRecentFn: func(ctx context.Context, limit int64) ([]*post.Post, error) { return nil, nil },
+	}
+	hs, _ := newHandler(ms)
+	mr := &mockReactions{mine: map[string][]string{"p2": {"wow"}}}
+	hs.reactions = mr
+
+	// readers without a cookie are not looked up
+	hs.List(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/posts", nil))
+	assert.Empty(t, mr.reactors)
+
+	req := httptest.NewRequest(http.MethodGet, "/posts", nil)
+	req.AddCookie(&http.Cookie{Name: reactorCookie, Value: testToken})
+	hs.List(httptest.NewRecorder(), req)
+
+	assert.Equal(t, []string{"anon:" + testToken}, mr.reactors)
+	assert.Empty(t, posts[0].MyReactions)
+	assert.True(t, posts[1].Reacted("wow"))
+}
@@ -0,0 +1,21 @@
+package post
+
+import "context"
+
+// sidebarSize is how many posts each sidebar list shows.
+const sidebarSize = 5
+
+// sidebar fills in the sidebar lists of a full page. They are
+// secondary, so failures are logged and leave the list empty.
+func (h handler) sidebar(ctx context.Context, data *ListPageData) {
+	var err error
+	if data.Recent, err = h.svc.GetRecent(ctx, sidebarSize); err != nil {
+		h.l.ErrorContext(ctx, "recent posts", "err", err)
+	}
+	if data.Trending, err = h.trending.Trending(ctx, sidebarSize); err != nil {
+		h.l.ErrorContext(ctx, "trending posts", "err", err)
+	}
+	if data.MostRead, err = h.trending.MostRead(ctx, sidebarSize); err != nil {
+		h.l.ErrorContext(ctx, "most read posts", "err", err)
+	}
+}
@@ -0,0 +1,54 @@
+package post
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"news-svc/internal/entity/post"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListRendersSidebarRankings(t *testing.T) {
+	ms := &mockService{
+		getAllFn: func(ctx context.Context, page, limit int64) ([]*post.Post, int64, error) { return nil, 0, nil },
+		getRecentFn: func(ctx context.Context, limit int64) ([]*post.Post, error) {
+			return []*post.Post{{ID: "r1", Title: "Latest news"}}, nil
+		},
+	}
+	mt := &mockTrending{
+		trending: []*post.Post{{ID: "t1", Title: "Hot take"}},
+		mostRead: []*post.Post{{ID: "m1", Title: "Evergreen"}},
+	}
+	h := handler{svc: ms, comments: mockCounter{}, reactions: &mockReactions{}, trending: mt,
+		tmpl: newTemplates(), l: slog.New(slog.NewTextHandler(io.Discard, nil))}
+
+	rr := httptest.NewRecorder()
+	h.List(rr, httptest.NewRequest(http.MethodGet, "/posts", nil))
+
+	body := rr.Body.String()
+	assert.Contains(t, body, `<a href="/posts/t1">Hot take</a>`)
+	assert.Contains(t, body, "Most read this week")
+	assert.Contains(t, body, `<a href="/posts/m1">Evergreen</a>`)
+	assert.Contains(t, body, "Latest news")
+}
+
+func TestSidebarSurvivesRankingErrors(t *testing.T) {
+	ms := &mockService{
+		getAllFn:    func(ctx context.Context, page, limit int64) ([]*post.Post, int64, error) { return nil, 0, nil },
+		getRecentFn: func(ctx context.Context, limit int64) ([]*post.Post, error) { return nil, nil },
+	}
+	h := handler{svc: ms, comments: mockCounter{}, reactions: &mockReactions{}, trending: &mockTrending{err: errors.New("boom")},
+		tmpl: newTemplates(), l: slog.New(slog.NewTextHandler(io.Discard, nil))}
+
+	rr := httptest.NewRecorder()
+	h.List(rr, httptest.NewRequest(http.MethodGet, "/posts", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Nothing trending yet.")
+}
@@ -4,6 +4,12 @@ import (
 	"embed"
 	"html/template"
 	"io"
+	"net/url"
+
+	"news-svc/internal/controller/web/v1/static"
+	"news-svc/internal/entity/media"
+	"news-svc/internal/entity/reaction"
+	"news-svc/pkg/sanitize"
 )
 
 //go:embed templates/*.html
@@ -15,8 +21,21 @@ type templates struct {
 
 func newTemplates() *templates {
 	root := template.New("").Funcs(template.FuncMap{
+		"asset": static.Path,
+
 		"add": func(a, b int64) int64 { return a + b },
 		"sub": func(a, b int64) int64 { return a - b },
+
+		"pathEscape": url.PathEscape,
+
+		// content renders post bodies, which may contain a restricted
+		// subset of HTML such as inserted images
+		"content": func(s string) template.HTML { return template.HTML(sanitize.Render(s)) },
+
+		// srcset lists the resized variants of an uploaded cover image
+		"srcset": media.SrcSetFor,
+
+		"reactionKinds": func() []reaction.Kind { return reaction.Kinds },
 	})
 	tmpl := template.Must(root.ParseFS(templateFS, "templates/*.html"))
 
@@ -5,7 +5,10 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"news-svc/config"
+	"news-svc/internal/entity/event"
 	"news-svc/internal/entity/post"
+	"news-svc/pkg/pubsub"
 )
 
 type (
@@ -17,6 +20,33 @@ type (
 		Delete(ctx context.Context, id string) error
 		Search(ctx context.Context, query string, page, limit int64) ([]*post.Post, int64, error)
 		GetRecent(ctx context.Context, limit int64) ([]*post.Post, error)
+		GetAllBy(ctx context.Context, f post.Filter, page, limit int64) ([]*post.Post, int64, error)
+	}
+
+	commentCounter interface {
+		Counts(ctx context.Context, postIDs []string) (map[string]int64, error)
+	}
+
+	reactionService interface {
+		Toggle(ctx context.Context, postID, kind, reactor string) (map[string]int64, bool, error)
+		Mine(ctx context.Context, reactor string, postIDs []string) (map[string][]string, error)
+	}
+
+	viewTracker interface {
+		Track(postID, ip, userAgent string)
+	}
+
+	trendingService interface {
+		Trending(ctx context.Context, limit int) ([]*post.Post, error)
+		MostRead(ctx context.Context, limit int) ([]*post.Post, error)
+	}
+
+	relatedLoader interface {
+		Load(ctx context.Context, p *post.Post) error
+	}
+
+	eventSource interface {
+		Subscribe() *pubsub.Subscription[event.Event]
 	}
 
 	templateRenderer interface {
@@ -24,18 +54,32 @@ type (
 	}
 
 	handler struct {
-		svc  service
-		tmpl templateRenderer
-		l    *slog.Logger
+		svc       service
+		comments  commentCounter
+		reactions reactionService
+		views     viewTracker
+		trending  trendingService
+		related   relatedLoader
+		events    eventSource
+		tmpl      templateRenderer
+		site      config.Site
+		l         *slog.Logger
 	}
 )
 
 func InitHandler(
 	mux *http.ServeMux,
 	svc service,
+	comments commentCounter,
+	reactions reactionService,
+	views viewTracker,
+	trending trendingService,
+	related relatedLoader,
+	events eventSource,
+	site config.Site,
 	l *slog.Logger,
 ) {
-	h := handler{svc, newTemplates(), l}
+	h := handler{svc, comments, reactions, views, trending, related, events, newTemplates(), site, l}
 
 	mux.HandleFunc("/", h.Index)
 
@@ -43,46 +87,65 @@ func InitHandler(
 	mux.HandleFunc("POST /posts", h.Create)
 	mux.HandleFunc("GET /posts/create", h.CreateForm)
 
+	mux.HandleFunc("GET /tags/{tag}", h.List)
+	mux.HandleFunc("GET /categories/{category}", h.List)
+
 	mux.HandleFunc("GET /posts/{id}", h.Show)
 	mux.HandleFunc("GET /posts/{id}/edit", h.EditForm)
 	mux.HandleFunc("PATCH /posts/{id}", h.Update)
 	mux.HandleFunc("DELETE /posts/{id}", h.Delete)
 
+	mux.HandleFunc("POST /posts/{id}/reactions/{kind}", h.React)
+
+	mux.HandleFunc("GET /events", h.Events)
+
 	return
 }
 
 type (
+	// ListPageData is what the "base" layout renders. Post is set on
+	// a single post page and replaces the list. CSRFToken is sent with
+	// every htmx request made from the page.
 	ListPageData struct {
+		Meta       Meta
+		Post       *post.Post
 		Posts      []*post.Post
 		Recent     []*post.Post
+		Trending   []*post.Post
+		MostRead   []*post.Post
 		Search     string
+		Tag        string
+		Category   string
 		Page       int64
 		Limit      int64
 		Total      int64
 		TotalPages int64
 		Title      string
 		Content    string
+		Summary    string
+		CoverImage string
 		Error      string
+		CSRFToken  string
 	}
 
 	CreateFormData struct {
-		Title   string
-		Content string
-		Error   string
+		Title      string
+		Content    string
+		Summary    string
+		CoverImage string
+		Error      string
 	}
 
 	EditFormData struct {
-		ID      string
-		Title   string
-		Content string
-		Error   string
+		ID         string
+		Title      string
+		Content    string
+		Summary    string
+		CoverImage string
+		Error      string
 	}
 
 	ErrorData struct {
 		Error string
 	}
-
-	PostsData struct {
-		Posts []*post.Post
-	}
 )
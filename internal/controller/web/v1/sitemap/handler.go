@@ -0,0 +1,181 @@
+package sitemap
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"news-svc/internal/entity/post"
+	"news-svc/pkg/sitemap"
+)
+
+const (
+	sectionPosts = "posts"
+	sectionTerms = "terms"
+)
+
+func (h handler) Robots(w http.ResponseWriter, r *http.Request) {
+	var b strings.Builder
+
+	b.WriteString("User-agent: *\n")
+	if h.robots.DenyAll {
+		b.WriteString("Disallow: /\n")
+	} else {
+		for _, path := range h.robots.Disallow {
+			fmt.Fprintf(&b, "Disallow: %s\n", path)
+		}
+		if len(h.robots.Disallow) == 0 {
+			b.WriteString("Disallow:\n")
+		}
+	}
+	fmt.Fprintf(&b, "\nSitemap: %s/sitemap.xml\n", h.site.URL)
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	w.Write([]byte(b.String()))
+}
+
+// Sitemap serves a single sitemap while everything fits into one,
+// and a sitemap index pointing at the per-section pages otherwise.
+func (h handler) Sitemap(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	postCount, err := h.svc.CountPublished(ctx)
+	if err != nil {
+		h.error(w, r, err)
+		return
+	}
+
+	terms, err := h.termURLs(r)
+	if err != nil {
+		h.error(w, r, err)
+		return
+	}
+
+	if postCount+int64(len(terms)) <= sitemap.MaxURLs {
+		posts, err := h.postURLs(r, 0, postCount)
+		if err != nil {
+			h.error(w, r, err)
+			return
+		}
+		h.write(w, r, func(buf *bytes.Buffer) error {
+			return sitemap.WriteURLSet(buf, append(terms, posts...))
+		})
+		return
+	}
+
+	var index []sitemap.URL
+	for n := range sitemap.Pages(postCount) {
+		index = append(index, sitemap.URL{Loc: h.sectionURL(sectionPosts, n+1)})
+	}
+	for n := range sitemap.Pages(int64(len(terms))) {
+		index = append(index, sitemap.URL{Loc: h.sectionURL(sectionTerms, n+1)})
+	}
+
+	h.write(w, r, func(buf *bytes.Buffer) error {
+		return sitemap.WriteIndex(buf, index)
+	})
+}
+
+// Section serves page {file} ("1.xml", "2.xml", ...) of a sitemap section.
+func (h handler) Section(w http.ResponseWriter, r *http.Request) {
+	page, err := strconv.ParseInt(strings.TrimSuffix(r.PathValue("file"), ".xml"), 10, 64)
+	if err != nil || page < 1 {
+		http.NotFound(w, r)
+		return
+	}
+	skip := (page - 1) * sitemap.MaxURLs
+
+	var urls []sitemap.URL
+	switch r.PathValue("section") {
+	case sectionPosts:
+		urls, err = h.postURLs(r, skip, sitemap.MaxURLs)
+	case sectionTerms:
+		urls, err = h.termURLs(r)
+		urls = urls[min(skip, int64(len(urls))):min(skip+sitemap.MaxURLs, int64(len(urls)))]
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		h.error(w, r, err)
+		return
+	}
+	if len(urls) == 0 {
+		http.NotFound(w, r)
+		return
+	}
+
+	h.write(w, r, func(buf *bytes.Buffer) error {
+		return sitemap.WriteURLSet(buf, urls)
+	})
+}
+
+func (h handler) postURLs(r *http.Request, skip, limit int64) ([]sitemap.URL, error) {
+	posts, err := h.svc.PublishedSummaries(r.Context(), skip, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	urls := make([]sitemap.URL, 0, len(posts))
+	for _, p := range posts {
+		urls = append(urls, sitemap.URL{
+			Loc:     h.site.URL + "/posts/" + p.ID,
+			LastMod: p.UpdatedAt,
+		})
+	}
+	return urls, nil
+}
+
+// termURLs lists the front page followed by every tag and category page.
+func (h handler) termURLs(r *http.Request) ([]sitemap.URL, error) {
+	tags, err := h.svc.Tags(r.Context())
+	if err != nil {
+		return nil, err
+	}
+	categories, err := h.svc.Categories(r.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	urls := make([]sitemap.URL, 0, 1+len(tags)+len(categories))
+	urls = append(urls, sitemap.URL{Loc: h.site.URL + "/posts"})
+	urls = append(urls, h.pageURLs("/tags/", tags)...)
+	urls = append(urls, h.pageURLs("/categories/", categories)...)
+	return urls, nil
+}
+
+func (h handler) pageURLs(prefix string, terms []post.Term) []sitemap.URL {
+	urls := make([]sitemap.URL, 0, len(terms))
+	for _, t := range terms {
+		urls = append(urls, sitemap.URL{
+			Loc:     h.site.URL + prefix + url.PathEscape(t.Name),
+			LastMod: t.UpdatedAt,
+		})
+	}
+	return urls
+}
+
+func (h handler) sectionURL(section string, page int64) string {
+	return fmt.Sprintf("%s/sitemaps/%s/%d.xml", h.site.URL, section, page)
+}
+
+func (h handler) write(w http.ResponseWriter, r *http.Request, encode func(*bytes.Buffer) error) {
+	var buf bytes.Buffer
+	if err := encode(&buf); err != nil {
+		h.error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", sitemap.ContentType)
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	w.Write(buf.Bytes())
+}
+
+func (h handler) error(w http.ResponseWriter, r *http.Request, err error) {
+	h.l.ErrorContext(r.Context(), "Sitemap error", "err", err)
+	http.Error(w, "server error", http.StatusInternalServerError)
+}
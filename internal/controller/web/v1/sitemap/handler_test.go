@@ -0,0 +1,142 @@
+package sitemap
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"news-svc/config"
+	"news-svc/internal/entity/post"
+	"news-svc/pkg/sitemap"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockService struct {
+	countFn      func(ctx context.Context) (int64, error)
+	summariesFn  func(ctx context.Context, skip, limit int64) ([]*post.Post, error)
+	tagsFn       func(ctx context.Context) ([]post.Term, error)
+	categoriesFn func(ctx context.Context) ([]post.Term, error)
+}
+
+func (m *mockService) CountPublished(ctx context.Context) (int64, error) {
+	return m.countFn(ctx)
+}
+func (m *mockService) PublishedSummaries(ctx context.Context, skip, limit int64) ([]*post.Post, error) {
+	return m.summariesFn(ctx, skip, limit)
+}
+func (m *mockService) Tags(ctx context.Context) ([]post.Term, error) {
+	return m.tagsFn(ctx)
+}
+func (m *mockService) Categories(ctx context.Context) ([]post.Term, error) {
+	return m.categoriesFn(ctx)
+}
+
+var updated = time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+func newMux(ms *mockService, robots config.Robots) *http.ServeMux {
+	mux := http.NewServeMux()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	InitHandler(mux, ms, config.Site{URL: "https://news.example"}, robots, logger)
+	return mux
+}
+
+func newService(count int64) *mockService {
+	return &mockService{
+		countFn: func(ctx context.Context) (int64, error) { return count, nil },
+		summariesFn: func(ctx context.Context, skip, limit int64) ([]*post.Post, error) {
+			return []*post.Post{{ID: "p1", UpdatedAt: updated}}, nil
+		},
+		tagsFn: func(ctx context.Context) ([]post.Term, error) {
+			return []post.Term{{Name: "go lang", UpdatedAt: updated}}, nil
+		},
+		categoriesFn: func(ctx context.Context) ([]post.Term, error) {
+			return []post.Term{{Name: "politics"}}, nil
+		},
+	}
+}
+
+func TestSitemapSingle(t *testing.T) {
+	mux := newMux(newService(1), config.Robots{})
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/sitemap.xml", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, sitemap.ContentType, rr.Header().Get("Content-Type"))
+	body := rr.Body.String()
+	assert.Contains(t, body, "<urlset")
+	assert.Contains(t, body, "<loc>https://news.example/posts/p1</loc>")
+	assert.Contains(t, body, "<lastmod>2024-01-01T10:00:00Z</lastmod>")
+	assert.Contains(t, body, "<loc>https://news.example/tags/go%20lang</loc>")
+	assert.Contains(t, body, "<loc>https://news.example/categories/politics</loc>")
+}
+
+func TestSitemapIndex(t *testing.T) {
+	ms := newService(2*sitemap.MaxURLs + 1)
+	mux := newMux(ms, config.Robots{})
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/sitemap.xml", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	body := rr.Body.String()
+	assert.Contains(t, body, "<sitemapindex")
+	assert.Contains(t, body, "https://news.example/sitemaps/posts/3.xml")
+	assert.NotContains(t, body, "https://news.example/sitemaps/posts/4.xml")
+	assert.Contains(t, body, "https://news.example/sitemaps/terms/1.xml")
+
+	var gotSkip int64
+	ms.summariesFn = func(ctx context.Context, skip, limit int64) ([]*post.Post, error) {
+		gotSkip = skip
+		assert.Equal(t, int64(sitemap.MaxURLs), limit)
+		return []*post.Post{{ID: "p2"}}, nil
+	}
+
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/sitemaps/posts/2.xml", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, int64(sitemap.MaxURLs), gotSkip)
+	assert.Contains(t, rr.Body.String(), "https://news.example/posts/p2")
+
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/sitemaps/terms/1.xml", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "https://news.example/categories/politics")
+
+	for _, path := range []string{"/sitemaps/terms/2.xml", "/sitemaps/other/1.xml", "/sitemaps/posts/x.xml"} {
+		rr = httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, http.StatusNotFound, rr.Code, path)
+	}
+}
+
+func TestSitemapError(t *testing.T) {
+	ms := newService(1)
+	ms.countFn = func(ctx context.Context) (int64, error) { return 0, errors.New("fail") }
+	mux := newMux(ms, config.Robots{})
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/sitemap.xml", nil))
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+}
+
+func TestRobots(t *testing.T) {
+	mux := newMux(nil, config.Robots{Disallow: []string{"/admin/"}})
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/robots.txt", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "User-agent: *\nDisallow: /admin/\n\nSitemap: https://news.example/sitemap.xml\n", rr.Body.String())
+
+	mux = newMux(nil, config.Robots{DenyAll: true})
+
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/robots.txt", nil))
+	assert.Contains(t, rr.Body.String(), "Disallow: /\n")
+}
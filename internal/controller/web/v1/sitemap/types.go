@@ -0,0 +1,39 @@
+package sitemap
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"news-svc/config"
+	"news-svc/internal/entity/post"
+)
+
+type (
+	service interface {
+		CountPublished(ctx context.Context) (int64, error)
+		PublishedSummaries(ctx context.Context, skip, limit int64) ([]*post.Post, error)
+		Tags(ctx context.Context) ([]post.Term, error)
+		Categories(ctx context.Context) ([]post.Term, error)
+	}
+
+	handler struct {
+		svc    service
+		site   config.Site
+		robots config.Robots
+		l      *slog.Logger
+	}
+)
+
+func InitHandler(
+	mux *http.ServeMux,
+	svc service,
+	site config.Site,
+	robots config.Robots,
+	l *slog.Logger,
+) {
+	h := handler{svc, site, robots, l}
+
+	mux.HandleFunc("GET /robots.txt", h.Robots)
+	mux.HandleFunc("GET /sitemap.xml", h.Sitemap)
+	mux.HandleFunc("GET /sitemaps/{section}/{file}", h.Section)
+}
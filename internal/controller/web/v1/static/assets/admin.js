@@ -0,0 +1,14 @@
+// clear forms marked with data-reset-on-success once they were submitted
+document.addEventListener('htmx:afterRequest', function (e) {
+  var form = e.detail.elt;
+  if (e.detail.successful && form.matches && form.matches('form[data-reset-on-success]')) {
+    form.reset();
+  }
+});
+
+// size chart bars from data-height, since inline styles are not allowed
+htmx.onLoad(function (elt) {
+  elt.querySelectorAll('[data-height]').forEach(function (bar) {
+    bar.style.height = bar.dataset.height + '%';
+  });
+});
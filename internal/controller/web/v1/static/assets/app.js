@@ -0,0 +1,64 @@
+// media picker: insert an uploaded image into the post being edited
+document.addEventListener('click', function (e) {
+  var btn = e.target.closest('[data-insert-image], [data-cover-image]');
+  if (!btn) return;
+  var form = btn.closest('form');
+
+  if (btn.dataset.coverImage) {
+    form.elements['cover_image'].value = btn.dataset.coverImage;
+    return;
+  }
+
+  var img = document.createElement('img');
+  img.src = btn.dataset.insertImage;
+  img.alt = btn.dataset.alt || '';
+  if (btn.dataset.srcset) {
+    img.srcset = btn.dataset.srcset;
+    img.sizes = '(max-width: 800px) 100vw, 800px';
+  }
+  img.loading = 'lazy';
+  var content = form.elements['content'];
+  var at = content.selectionStart || content.value.length;
+  content.value = content.value.slice(0, at) + img.outerHTML + content.value.slice(at);
+});
+
+// live updates: keep the post list in sync with posts written elsewhere
+(function () {
+  var list = document.getElementById('posts-list');
+  if (!list || !window.EventSource) return;
+
+  function fragment(html) {
+    var t = document.createElement('template');
+    t.innerHTML = html.trim();
+    return t.content.firstElementChild;
+  }
+
+  // a post created from this page arrives both as the form response
+  // and as an event, in either order; keep the newest copy
+  htmx.onLoad(function (elt) {
+    if (!elt.id || elt.id.indexOf('post-') !== 0) return;
+    document.querySelectorAll('[id="' + elt.id + '"]').forEach(function (el) {
+      if (el !== elt) el.remove();
+    });
+  });
+
+  var source = new EventSource('/events');
+  source.addEventListener('post.created', function (e) {
+    var item = fragment(e.data);
+    if (!list.hasAttribute('data-live-prepend') || document.getElementById(item.id)) return;
+    list.querySelector('ul').prepend(item);
+    htmx.process(item);
+  });
+  source.addEventListener('post.updated', function (e) {
+    var item = fragment(e.data);
+    var old = document.getElementById(item.id);
+    // a post opened in place is left alone
+    if (!old || !old.classList.contains('post-container')) return;
+    old.replaceWith(item);
+    htmx.process(item);
+  });
+  source.addEventListener('post.deleted', function (e) {
+    var old = document.getElementById('post-' + e.data);
+    if (old) old.remove();
+  });
+})();
@@ -0,0 +1,8 @@
+// show the notice for requests rejected by the CSRF check, which htmx
+// would otherwise drop like any error response
+document.addEventListener('htmx:beforeSwap', function (e) {
+  if (e.detail.xhr.status === 403 && e.detail.xhr.getResponseHeader('X-CSRF-Failure')) {
+    e.detail.shouldSwap = true;
+    e.detail.isError = false;
+  }
+});
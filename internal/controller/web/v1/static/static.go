@@ -0,0 +1,81 @@
+// Package static serves the stylesheets and scripts the pages load.
+// Each file is served under a name containing a hash of its content,
+// so it can be cached forever and a new build still gets picked up.
+package static
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// prefix is where the assets are served.
+const prefix = "/static/"
+
+//go:embed assets
+var assetFS embed.FS
+
+type asset struct {
+	content     []byte
+	contentType string
+}
+
+var (
+	// paths maps file names to the paths they are served at
+	paths = map[string]string{}
+	// assets maps the served file names to the files
+	assets = map[string]asset{}
+)
+
+func init() {
+	entries, err := fs.ReadDir(assetFS, "assets")
+	if err != nil {
+		panic(err)
+	}
+
+	for _, e := range entries {
+		name := e.Name()
+		content, err := fs.ReadFile(assetFS, "assets/"+name)
+		if err != nil {
+			panic(err)
+		}
+
+		sum := sha256.Sum256(content)
+		ext := path.Ext(name)
+		hashed := strings.TrimSuffix(name, ext) + "." + hex.EncodeToString(sum[:6]) + ext
+
+		paths[name] = prefix + hashed
+		assets[hashed] = asset{content, mime.TypeByExtension(ext)}
+	}
+}
+
+// Path - returns the path the named asset is served at, such as
+// "/static/app.1a2b3c4d5e6f.css", for use in templates.
+func Path(name string) string {
+	if p, ok := paths[name]; ok {
+		return p
+	}
+	// a missing asset shows up as a 404 rather than a broken page
+	return prefix + name
+}
+
+func InitHandler(mux *http.ServeMux) {
+	mux.HandleFunc("GET "+prefix+"{file}", serve)
+}
+
+func serve(w http.ResponseWriter, r *http.Request) {
+	a, ok := assets[r.PathValue("file")]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", a.contentType)
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	w.Write(a.content)
+}
@@ -0,0 +1,33 @@
+package static
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPath(t *testing.T) {
+	assert.Regexp(t, regexp.MustCompile(`^/static/app\.[0-9a-f]{12}\.css$`), Path("app.css"))
+	assert.Equal(t, "/static/missing.js", Path("missing.js"))
+}
+
+func TestServe(t *testing.T) {
+	mux := http.NewServeMux()
+	InitHandler(mux)
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, Path("app.css"), nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Header().Get("Content-Type"), "text/css")
+	assert.Equal(t, "public, max-age=31536000, immutable", rr.Header().Get("Cache-Control"))
+	assert.Contains(t, rr.Body.String(), ".layout")
+
+	// only hashed names are served, so a stale name cannot be cached
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/static/app.css", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
@@ -0,0 +1,155 @@
+package webhook
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"news-svc/config"
+	"news-svc/internal/entity/webhook"
+	"news-svc/pkg/csrf"
+)
+
+// deliveriesPageSize is the number of deliveries per log page.
+const deliveriesPageSize = 50
+
+// actions maps the row buttons to the active flag they set.
+var actions = map[string]bool{
+	"enable":  true,
+	"disable": false,
+}
+
+func (h handler) List(w http.ResponseWriter, r *http.Request) {
+	h.renderList(w, r, "webhooks", FormData{}, "")
+}
+
+// Create registers a webhook and re-renders the list with it, or the
+// form with the reason it was rejected.
+func (h handler) Create(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "bad form", http.StatusBadRequest)
+		return
+	}
+
+	hook := &webhook.Webhook{
+		URL:    r.Form.Get("url"),
+		Events: r.Form["event"],
+	}
+	if _, err := h.svc.Create(r.Context(), hook); err != nil {
+		form := FormData{URL: hook.URL, Events: hook.Events, Error: err.Error()}
+		switch {
+		case errors.Is(err, config.ErrInvalidWebhookURL),
+			errors.Is(err, config.ErrNoWebhookEvents),
+			errors.Is(err, config.ErrUnknownWebhookEvent):
+		default:
+			h.l.ErrorContext(r.Context(), "Create webhook error", "err", err)
+			form.Error = "could not save the webhook, please try again"
+		}
+		h.renderList(w, r, "webhooks_panel", form, "")
+		return
+	}
+
+	h.renderList(w, r, "webhooks_panel", FormData{}, "Webhook added for "+hook.URL+".")
+}
+
+// SetActive enables or disables a webhook and re-renders its row.
+func (h handler) SetActive(w http.ResponseWriter, r *http.Request) {
+	active, ok := actions[r.PathValue("action")]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	id := r.PathValue("id")
+	if err := h.svc.SetActive(r.Context(), id, active); err != nil {
+		h.error(w, r, "SetActive webhook error", err)
+		return
+	}
+
+	hook, err := h.svc.GetByID(r.Context(), id)
+	if err != nil {
+		h.error(w, r, "GetByID webhook error", err)
+		return
+	}
+
+	h.tmpl.Render(w, "webhook_row", hook)
+}
+
+// Delete removes a webhook and its delivery log. The row is removed by
+// the client, so a success has no body.
+func (h handler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.svc.Delete(r.Context(), r.PathValue("id")); err != nil {
+		h.error(w, r, "Delete webhook error", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h handler) Deliveries(w http.ResponseWriter, r *http.Request) {
+	page, _ := strconv.ParseInt(r.URL.Query().Get("page"), 10, 64)
+	if page < 1 {
+		page = 1
+	}
+
+	hook, err := h.svc.GetByID(r.Context(), r.PathValue("id"))
+	if err != nil {
+		h.error(w, r, "GetByID webhook error", err)
+		return
+	}
+
+	deliveries, total, err := h.svc.Deliveries(r.Context(), hook.ID, page, deliveriesPageSize)
+	if err != nil {
+		h.error(w, r, "Deliveries error", err)
+		return
+	}
+
+	h.tmpl.Render(w, "deliveries", DeliveriesData{
+		Webhook:    hook,
+		Deliveries: deliveries,
+		Page:       page,
+		TotalPages: max(int64(math.Ceil(float64(total)/deliveriesPageSize)), 1),
+		CSRFToken:  csrf.Token(r.Context()),
+	})
+}
+
+// Replay queues a delivery again and renders the new delivery's row for
+// the top of the log.
+func (h handler) Replay(w http.ResponseWriter, r *http.Request) {
+	d, err := h.svc.Replay(r.Context(), r.PathValue("id"))
+	if err != nil {
+		h.error(w, r, "Replay error", err)
+		return
+	}
+
+	h.tmpl.Render(w, "delivery_row", d)
+}
+
+func (h handler) renderList(w http.ResponseWriter, r *http.Request, name string, form FormData, notice string) {
+	hooks, err := h.svc.GetAll(r.Context())
+	if err != nil {
+		h.l.ErrorContext(r.Context(), "List webhooks error", "err", err)
+		http.Error(w, "server error", http.StatusInternalServerError)
+		return
+	}
+
+	h.tmpl.Render(w, name, WebhooksData{
+		Webhooks:  hooks,
+		Events:    webhook.Events,
+		Form:      form,
+		Notice:    notice,
+		CSRFToken: csrf.Token(r.Context()),
+	})
+}
+
+// error answers 404 for unknown webhooks and deliveries and 500 for
+// anything else.
+func (h handler) error(w http.ResponseWriter, r *http.Request, msg string, err error) {
+	if errors.Is(err, config.ErrWebhookNotFound) || errors.Is(err, config.ErrDeliveryNotFound) {
+		http.NotFound(w, r)
+		return
+	}
+	h.l.ErrorContext(r.Context(), msg, "err", err)
+	http.Error(w, "server error", http.StatusInternalServerError)
+}
@@ -0,0 +1,254 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"news-svc/config"
+	"news-svc/internal/entity/webhook"
+	"news-svc/pkg/auth"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockService struct {
+	hooks      []*webhook.Webhook
+	createFn   func(ctx context.Context, w *webhook.Webhook) (string, error)
+	setActive  map[string]bool
+	deleted    []string
+	deliveries []*webhook.Delivery
+	replayFn   func(ctx context.Context, id string) (*webhook.Delivery, error)
+}
+
+func (m *mockService) Create(ctx context.Context, w *webhook.Webhook) (string, error) {
+	return m.createFn(ctx, w)
+}
+func (m *mockService) GetByID(ctx context.Context, id string) (*webhook.Webhook, error) {
+	for _, w := range m.hooks {
+		if w.ID == id {
+			return w, nil
+		}
+	}
+	return nil, config.ErrWebhookNotFound
+}
+func (m *mockService) GetAll(ctx context.Context) ([]*webhook.Webhook, error) {
+	return m.hooks, nil
+}
+func (m *mockService) SetActive(ctx context.Context, id string, active bool) error {
+	w, err := m.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	w.Active = active
+	return nil
+}
+func (m *mockService) Delete(ctx context.Context, id string) error {
+	m.deleted = append(m.deleted, id)
+	return nil
+}
+func (m *mockService) Deliveries(ctx context.Context, webhookID string, page, limit int64) ([]*webhook.Delivery, int64, error) {
+	return m.deliveries, int64(len(m.deliveries)), nil
+}
+func (m *mockService) Replay(ctx context.Context, id string) (*webhook.Delivery, error) {
+	return m.replayFn(ctx, id)
+}
+
+func newMux(ms *mockService) *http.ServeMux {
+	mux := http.NewServeMux()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	InitHandler(mux, ms, auth.NewBasic("admin", "secret"), logger)
+	return mux
+}
+
+func adminRequest(req *http.Request) *http.Request {
+	req.SetBasicAuth("admin", "secret")
+	return req
+}
+
+func postForm(path string, form url.Values) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return adminRequest(req)
+}
+
+func testHook() *webhook.Webhook {
+	return &webhook.Webhook{
+		ID:        "w1",
+		URL:       "https://example.com/hook",
+		Secret:    "s3cret",
+		Events:    []string{webhook.EventPublished, webhook.EventDeleted},
+		Active:    true,
+		CreatedAt: time.Now(),
+	}
+}
+
+func TestRequiresAuth(t *testing.T) {
+	mux := newMux(&mockService{})
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/admin/webhooks", nil))
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
+
+func TestList(t *testing.T) {
+	mux := newMux(&mockService{hooks: []*webhook.Webhook{testHook()}})
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, adminRequest(httptest.NewRequest(http.MethodGet, "/admin/webhooks", nil)))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	body := rr.Body.String()
+	assert.Contains(t, body, "<title>Webhooks</title>")
+	assert.Contains(t, body, `id="webhook-w1"`)
+	assert.Contains(t, body, "https://example.com/hook")
+	assert.Contains(t, body, "post.published, post.deleted")
+	assert.Contains(t, body, `hx-post="/admin/webhooks/w1/disable"`)
+	assert.Contains(t, body, `href="/admin/webhooks/w1/deliveries"`)
+	// every event is offered, and checked, on a fresh form
+	assert.Equal(t, len(webhook.Events), strings.Count(body, `name="event"`))
+	assert.Equal(t, len(webhook.Events), strings.Count(body, "checked"))
+}
+
+func TestCreate(t *testing.T) {
+	ms := &mockService{}
+	ms.createFn = func(ctx context.Context, w *webhook.Webhook) (string, error) {
+		assert.Equal(t, "https://example.com/hook", w.URL)
+		assert.Equal(t, []string{webhook.EventUpdated}, w.Events)
+		w.ID = "w2"
+		ms.hooks = append(ms.hooks, w)
+		return w.ID, nil
+	}
+	mux := newMux(ms)
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, postForm("/admin/webhooks", url.Values{"url": {"https://example.com/hook"}, "event": {webhook.EventUpdated}}))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	body := rr.Body.String()
+	assert.NotContains(t, body, "<html")
+	assert.Contains(t, body, `id="webhooks"`)
+	assert.Contains(t, body, `id="webhook-w2"`)
+	assert.Contains(t, body, "Webhook added")
+}
+
+func TestCreateErrors(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{config.ErrInvalidWebhookURL, config.ErrInvalidWebhookURL.Error()},
+		{config.ErrNoWebhookEvents, config.ErrNoWebhookEvents.Error()},
+		{errors.New("boom"), "could not save the webhook"},
+	}
+	for _, tt := range tests {
+		mux := newMux(&mockService{
+			createFn: func(ctx context.Context, w *webhook.Webhook) (string, error) { return "", tt.err },
+		})
+
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, postForm("/admin/webhooks", url.Values{"url": {"ftp://x"}, "event": {webhook.EventDeleted}}))
+
+		body := rr.Body.String()
+		assert.Contains(t, body, tt.want)
+		assert.NotContains(t, body, "boom")
+		// what was submitted is kept
+		assert.Contains(t, body, `value="ftp://x"`)
+		assert.Equal(t, 1, strings.Count(body, "checked"))
+	}
+}
+
+func TestSetActive(t *testing.T) {
+	ms := &mockService{hooks: []*webhook.Webhook{testHook()}}
+	mux := newMux(ms)
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, postForm("/admin/webhooks/w1/disable", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.False(t, ms.hooks[0].Active)
+	assert.Contains(t, rr.Body.String(), `hx-post="/admin/webhooks/w1/enable"`)
+
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, postForm("/admin/webhooks/w1/pause", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, postForm("/admin/webhooks/missing/enable", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestDelete(t *testing.T) {
+	ms := &mockService{}
+	mux := newMux(ms)
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, adminRequest(httptest.NewRequest(http.MethodDelete, "/admin/webhooks/w1", nil)))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, []string{"w1"}, ms.deleted)
+}
+
+func TestDeliveries(t *testing.T) {
+	now := time.Now()
+	mux := newMux(&mockService{
+		hooks: []*webhook.Webhook{testHook()},
+		deliveries: []*webhook.Delivery{{
+			ID:            "d1",
+			WebhookID:     "w1",
+			Event:         webhook.EventPublished,
+			Payload:       `{"id":"e1","event":"post.published"}`,
+			Status:        webhook.DeliveryPending,
+			Attempts:      []webhook.Attempt{{At: now, StatusCode: 503, Error: "unexpected status 503", Duration: 120 * time.Millisecond}},
+			NextAttemptAt: now.Add(time.Minute),
+			CreatedAt:     now,
+		}},
+	})
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, adminRequest(httptest.NewRequest(http.MethodGet, "/admin/webhooks/w1/deliveries", nil)))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	body := rr.Body.String()
+	assert.Contains(t, body, `id="delivery-d1"`)
+	assert.Contains(t, body, "503")
+	assert.Contains(t, body, "next try")
+	assert.Contains(t, body, "120ms")
+	assert.Contains(t, body, "{&#34;id&#34;:&#34;e1&#34;")
+	assert.Contains(t, body, `hx-post="/admin/webhooks/deliveries/d1/replay"`)
+
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, adminRequest(httptest.NewRequest(http.MethodGet, "/admin/webhooks/missing/deliveries", nil)))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestReplay(t *testing.T) {
+	mux := newMux(&mockService{
+		replayFn: func(ctx context.Context, id string) (*webhook.Delivery, error) {
+			if id != "d1" {
+				return nil, config.ErrDeliveryNotFound
+			}
+			return &webhook.Delivery{ID: "d2", ReplayOf: "d1", Event: webhook.EventPublished, Status: webhook.DeliveryPending}, nil
+		},
+	})
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, postForm("/admin/webhooks/deliveries/d1/replay", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	body := rr.Body.String()
+	assert.Contains(t, body, `id="delivery-d2"`)
+	assert.Contains(t, body, "replay of")
+
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, postForm("/admin/webhooks/deliveries/nope/replay", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
@@ -0,0 +1,78 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"news-svc/internal/entity/webhook"
+)
+
+type (
+	service interface {
+		Create(ctx context.Context, w *webhook.Webhook) (string, error)
+		GetByID(ctx context.Context, id string) (*webhook.Webhook, error)
+		GetAll(ctx context.Context) ([]*webhook.Webhook, error)
+		SetActive(ctx context.Context, id string, active bool) error
+		Delete(ctx context.Context, id string) error
+		Deliveries(ctx context.Context, webhookID string, page, limit int64) ([]*webhook.Delivery, int64, error)
+		Replay(ctx context.Context, deliveryID string) (*webhook.Delivery, error)
+	}
+
+	templateRenderer interface {
+		Render(wr io.Writer, name string, data any) error
+	}
+
+	authenticator interface {
+		Wrap(next http.HandlerFunc) http.HandlerFunc
+	}
+
+	handler struct {
+		svc  service
+		tmpl templateRenderer
+		l    *slog.Logger
+	}
+)
+
+func InitHandler(
+	mux *http.ServeMux,
+	svc service,
+	auth authenticator,
+	l *slog.Logger,
+) {
+	h := handler{svc, newTemplates(), l}
+
+	mux.HandleFunc("GET /admin/webhooks", auth.Wrap(h.List))
+	mux.HandleFunc("POST /admin/webhooks", auth.Wrap(h.Create))
+	mux.HandleFunc("POST /admin/webhooks/{id}/{action}", auth.Wrap(h.SetActive))
+	mux.HandleFunc("DELETE /admin/webhooks/{id}", auth.Wrap(h.Delete))
+	mux.HandleFunc("GET /admin/webhooks/{id}/deliveries", auth.Wrap(h.Deliveries))
+	mux.HandleFunc("POST /admin/webhooks/deliveries/{id}/replay", auth.Wrap(h.Replay))
+}
+
+type (
+	// WebhooksData is the list of webhooks with the registration form.
+	// Form keeps what was submitted when it was rejected.
+	WebhooksData struct {
+		Webhooks  []*webhook.Webhook
+		Events    []string
+		Form      FormData
+		Notice    string
+		CSRFToken string
+	}
+
+	FormData struct {
+		URL    string
+		Events []string
+		Error  string
+	}
+
+	// DeliveriesData is a page of one webhook's delivery log.
+	DeliveriesData struct {
+		Webhook    *webhook.Webhook
+		Deliveries []*webhook.Delivery
+		Page       int64
+		TotalPages int64
+		CSRFToken  string
+	}
+)
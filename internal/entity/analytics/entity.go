@@ -0,0 +1,122 @@
+package analytics
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const (
+	// CollectionName holds the view counters, one document per post
+	// and bucket.
+	CollectionName = "post_views"
+	// VisitorsCollectionName remembers which visitors have been counted
+	// on a post today, so each is counted once per day.
+	VisitorsCollectionName = "post_visitors"
+	// SaltsCollectionName holds the random salt of each day. Salts and
+	// visitor hashes expire after VisitorTTL, after which the hashes
+	// that were counted cannot be recomputed from an address.
+	SaltsCollectionName = "analytics_salts"
+
+	VisitorTTL = 48 * time.Hour
+
+	Hourly = "hour"
+	Daily  = "day"
+)
+
+type (
+	// Bucket counts the views of a post within one hour or day (UTC).
+	// Visitors is the number of distinct visitors and is only kept for
+	// daily buckets.
+	Bucket struct {
+		PostID   string    `bson:"post_id" json:"post_id"`
+		Period   string    `bson:"period" json:"period"`
+		Start    time.Time `bson:"start" json:"start"`
+		Views    int64     `bson:"views" json:"views"`
+		Visitors int64     `bson:"visitors" json:"visitors"`
+	}
+
+	mongoBucket struct {
+		PostID   bson.ObjectID `bson:"post_id"`
+		Period   string        `bson:"period"`
+		Start    time.Time     `bson:"start"`
+		Views    int64         `bson:"views"`
+		Visitors int64         `bson:"visitors"`
+	}
+
+	// Visit is a visitor seen on a post on a day. Visitor is a salted
+	// hash of the client address and User-Agent, never the address.
+	Visit struct {
+		PostID  string
+		Day     time.Time
+		Visitor string
+	}
+
+	// Point is one step of a time series.
+	Point struct {
+		Start    time.Time
+		Views    int64
+		Visitors int64
+	}
+
+	// PostStats are the totals of a post over a range of days.
+	PostStats struct {
+		PostID   string
+		Title    string
+		Views    int64
+		Visitors int64
+	}
+)
+
+// Start returns the beginning of the hour or day that contains t, in UTC.
+func Start(period string, t time.Time) time.Time {
+	t = t.UTC()
+	if period == Daily {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	return t.Truncate(time.Hour)
+}
+
+// Step is the length of a bucket.
+func Step(period string) time.Duration {
+	if period == Daily {
+		return 24 * time.Hour
+	}
+	return time.Hour
+}
+
+func (b *Bucket) MarshalBSON() ([]byte, error) {
+	doc := mongoBucket{
+		Period:   b.Period,
+		Start:    b.Start,
+		Views:    b.Views,
+		Visitors: b.Visitors,
+	}
+
+	postID, err := bson.ObjectIDFromHex(b.PostID)
+	if err != nil {
+		return nil, err
+	}
+	doc.PostID = postID
+
+	return bson.Marshal(doc)
+}
+
+func (b *Bucket) UnmarshalBSON(data []byte) error {
+	var tmp mongoBucket
+	if err := bson.Unmarshal(data, &tmp); err != nil {
+		return err
+	}
+
+	*b = Bucket{
+		Period:   tmp.Period,
+		Start:    tmp.Start.UTC(),
+		Views:    tmp.Views,
+		Visitors: tmp.Visitors,
+	}
+	if !tmp.PostID.IsZero() {
+		b.PostID = tmp.PostID.Hex()
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package analytics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestStart(t *testing.T) {
+	at := time.Date(2024, 3, 10, 23, 45, 12, 0, time.FixedZone("CET", 3600))
+
+	assert.Equal(t, time.Date(2024, 3, 10, 22, 0, 0, 0, time.UTC), Start(Hourly, at))
+	assert.Equal(t, time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC), Start(Daily, at))
+	assert.Equal(t, 24*time.Hour, Step(Daily))
+	assert.Equal(t, time.Hour, Step(Hourly))
+}
+
+func TestMarshalUnmarshalBSON(t *testing.T) {
+	orig := &Bucket{
+		PostID:   bson.NewObjectID().Hex(),
+		Period:   Daily,
+		Start:    Start(Daily, time.Now()),
+		Views:    12,
+		Visitors: 4,
+	}
+
+	data, err := orig.MarshalBSON()
+	assert.NoError(t, err)
+
+	var doc bson.M
+	assert.NoError(t, bson.Unmarshal(data, &doc))
+	assert.IsType(t, bson.ObjectID{}, doc["post_id"])
+
+	var round Bucket
+	assert.NoError(t, round.UnmarshalBSON(data))
+	assert.Equal(t, *orig, round)
+
+	_, err = (&Bucket{PostID: "nope"}).MarshalBSON()
+	assert.Error(t, err)
+}
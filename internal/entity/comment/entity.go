@@ -0,0 +1,208 @@
+package comment
+
+import (
+	"news-svc/config"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const (
+	CollectionName = "comments"
+
+	// Only approved comments are shown. Comments written before
+	// moderation existed have no status and count as approved.
+	StatusPending  = "pending"
+	StatusApproved = "approved"
+	StatusRejected = "rejected"
+
+	ContentMaxLen = 5000
+	AuthorMaxLen  = 80
+
+	// MaxDepth caps reply nesting. Replies to a comment at MaxDepth are
+	// shown at the same depth rather than indented further.
+	MaxDepth = 4
+
+	// RepliesPerPage is how many replies are loaded with a thread and on
+	// every "more replies" request after that.
+	RepliesPerPage = 10
+)
+
+type (
+	// Comment is a reader's response to a post. Top-level comments have
+	// no RootID; replies point at their direct parent and at the
+	// top-level comment of their thread, which is what threads are
+	// fetched and paginated by.
+	Comment struct {
+		ID        string    `bson:"_id,omitempty" json:"id"`
+		PostID    string    `bson:"post_id" json:"post_id"`
+		RootID    string    `bson:"root_id,omitempty" json:"root_id,omitempty"`
+		ParentID  string    `bson:"parent_id,omitempty" json:"parent_id,omitempty"`
+		Depth     int       `bson:"depth" json:"depth"`
+		Author    string    `bson:"author,omitempty" json:"author,omitempty"`
+		Email     string    `bson:"email,omitempty" json:"-"`
+		Content   string    `bson:"content" json:"content"`
+		ImportID  string    `bson:"import_id,omitempty" json:"-"`
+		CreatedAt time.Time `bson:"created_at" json:"created_at"`
+
+		// Moderation. Identity ties together comments by the same person
+		// (email, or name when there is none); IPHash stands in for the
+		// client address, which is never stored.
+		Status      string    `bson:"status,omitempty" json:"status,omitempty"`
+		HeldFor     []string  `bson:"held_for,omitempty" json:"-"`
+		SpamScore   float64   `bson:"spam_score,omitempty" json:"-"`
+		Identity    string    `bson:"identity,omitempty" json:"-"`
+		IPHash      string    `bson:"ip_hash,omitempty" json:"-"`
+		ModeratedAt time.Time `bson:"moderated_at,omitempty" json:"-"`
+	}
+
+	mongoComment struct {
+		ID        bson.ObjectID `bson:"_id,omitempty"`
+		PostID    bson.ObjectID `bson:"post_id"`
+		RootID    bson.ObjectID `bson:"root_id,omitempty"`
+		ParentID  bson.ObjectID `bson:"parent_id,omitempty"`
+		Depth     int           `bson:"depth"`
+		Author    string        `bson:"author,omitempty"`
+		Email     string        `bson:"email,omitempty"`
+		Content   string        `bson:"content"`
+		ImportID  string        `bson:"import_id,omitempty"`
+		CreatedAt time.Time     `bson:"created_at"`
+
+		Status      string    `bson:"status,omitempty"`
+		HeldFor     []string  `bson:"held_for,omitempty"`
+		SpamScore   float64   `bson:"spam_score,omitempty"`
+		Identity    string    `bson:"identity,omitempty"`
+		IPHash      string    `bson:"ip_hash,omitempty"`
+		ModeratedAt time.Time `bson:"moderated_at,omitempty"`
+	}
+
+	// Thread is a top-level comment with the first page of its replies.
+	Thread struct {
+		Root       *Comment
+		Replies    []*Comment
+		ReplyCount int64
+	}
+)
+
+// Validate applies the same rules as posts: content is required and
+// stored as written, to be sanitised when rendered.
+func (c Comment) Validate() error {
+	if c.Content == "" {
+		return config.ErrEmptyComment
+	}
+	if utf8.RuneCountInString(c.Content) > ContentMaxLen {
+		return config.ErrCommentTooLong
+	}
+	if utf8.RuneCountInString(c.Author) > AuthorMaxLen {
+		return config.ErrAuthorTooLong
+	}
+	if c.Email != "" && !ValidEmail(c.Email) {
+		return config.ErrInvalidEmail
+	}
+	if c.Status != "" && !ValidStatus(c.Status) {
+		return config.ErrInvalidModerationStatus
+	}
+	return nil
+}
+
+// ValidEmail is deliberately loose; the address is never mailed, only
+// used to recognise returning commenters.
+func ValidEmail(s string) bool {
+	at := strings.LastIndexByte(s, '@')
+	return len(s) <= 254 && at > 0 && at < len(s)-1 && !strings.ContainsAny(s, " \t\r\n")
+}
+
+// ValidStatus reports whether s is a known moderation status.
+func ValidStatus(s string) bool {
+	return s == StatusPending || s == StatusApproved || s == StatusRejected
+}
+
+// Visible reports whether the comment is shown on the site.
+func (c Comment) Visible() bool {
+	return c.Status == "" || c.Status == StatusApproved
+}
+
+// More reports whether the thread has replies beyond those loaded.
+func (t Thread) More() bool {
+	return int64(len(t.Replies)) < t.ReplyCount
+}
+
+func (c *Comment) MarshalBSON() ([]byte, error) {
+	doc := mongoComment{
+		Depth:       c.Depth,
+		Author:      c.Author,
+		Email:       c.Email,
+		Content:     c.Content,
+		ImportID:    c.ImportID,
+		CreatedAt:   c.CreatedAt,
+		Status:      c.Status,
+		HeldFor:     c.HeldFor,
+		SpamScore:   c.SpamScore,
+		Identity:    c.Identity,
+		IPHash:      c.IPHash,
+		ModeratedAt: c.ModeratedAt,
+	}
+
+	ids := []struct {
+		hex string
+		dst *bson.ObjectID
+	}{
+		{c.ID, &doc.ID},
+		{c.PostID, &doc.PostID},
+		{c.RootID, &doc.RootID},
+		{c.ParentID, &doc.ParentID},
+	}
+	for _, id := range ids {
+		if id.hex == "" {
+			continue
+		}
+		objectID, err := bson.ObjectIDFromHex(id.hex)
+		if err != nil {
+			return nil, err
+		}
+		*id.dst = objectID
+	}
+
+	return bson.Marshal(doc)
+}
+
+func (c *Comment) UnmarshalBSON(data []byte) error {
+	var tmp mongoComment
+	if err := bson.Unmarshal(data, &tmp); err != nil {
+		return err
+	}
+
+	if tmp.Status == "" {
+		tmp.Status = StatusApproved
+	}
+
+	*c = Comment{
+		ID:          tmp.ID.Hex(),
+		PostID:      hexOrEmpty(tmp.PostID),
+		RootID:      hexOrEmpty(tmp.RootID),
+		ParentID:    hexOrEmpty(tmp.ParentID),
+		Depth:       tmp.Depth,
+		Author:      tmp.Author,
+		Email:       tmp.Email,
+		Content:     tmp.Content,
+		ImportID:    tmp.ImportID,
+		CreatedAt:   tmp.CreatedAt,
+		Status:      tmp.Status,
+		HeldFor:     tmp.HeldFor,
+		SpamScore:   tmp.SpamScore,
+		Identity:    tmp.Identity,
+		IPHash:      tmp.IPHash,
+		ModeratedAt: tmp.ModeratedAt,
+	}
+
+	return nil
+}
+
+func hexOrEmpty(id bson.ObjectID) string {
+	if id.IsZero() {
+		return ""
+	}
+	return id.Hex()
+}
@@ -0,0 +1,76 @@
+package comment
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"news-svc/config"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestMarshalUnmarshalBSON(t *testing.T) {
+	orig := &Comment{
+		ID:        bson.NewObjectID().Hex(),
+		PostID:    bson.NewObjectID().Hex(),
+		Author:    "Ann",
+		Content:   "First!",
+		CreatedAt: time.Now().UTC().Truncate(time.Millisecond),
+		Status:    StatusApproved,
+	}
+
+	data, err := orig.MarshalBSON()
+	assert.NoError(t, err)
+
+	var doc bson.M
+	assert.NoError(t, bson.Unmarshal(data, &doc))
+	assert.NotContains(t, doc, "root_id")
+	assert.NotContains(t, doc, "parent_id")
+	assert.NotContains(t, doc, "import_id")
+	assert.NotContains(t, doc, "moderated_at")
+
+	var round Comment
+	assert.NoError(t, round.UnmarshalBSON(data))
+	assert.Equal(t, *orig, round)
+
+	orig.RootID = bson.NewObjectID().Hex()
+	orig.ParentID = orig.RootID
+	orig.Depth = 1
+	data, err = orig.MarshalBSON()
+	assert.NoError(t, err)
+	assert.NoError(t, round.UnmarshalBSON(data))
+	assert.Equal(t, *orig, round)
+
+	orig.Status = StatusPending
+	orig.HeldFor = []string{"first comment"}
+	orig.SpamScore = 0.25
+	orig.Identity = "ann@example.com"
+	orig.IPHash = "abc"
+	orig.ModeratedAt = orig.CreatedAt
+	data, err = orig.MarshalBSON()
+	assert.NoError(t, err)
+	assert.NoError(t, round.UnmarshalBSON(data))
+	assert.Equal(t, *orig, round)
+
+	// written before moderation existed
+	data, err = bson.Marshal(bson.M{"content": "old"})
+	assert.NoError(t, err)
+	assert.NoError(t, round.UnmarshalBSON(data))
+	assert.Equal(t, StatusApproved, round.Status)
+
+	_, err = (&Comment{PostID: "invalid"}).MarshalBSON()
+	assert.Error(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Comment{Content: "hi"}.Validate())
+	assert.ErrorIs(t, Comment{}.Validate(), config.ErrEmptyComment)
+	assert.ErrorIs(t, Comment{Content: strings.Repeat("é", ContentMaxLen+1)}.Validate(), config.ErrCommentTooLong)
+	assert.ErrorIs(t, Comment{Content: "hi", Author: strings.Repeat("a", AuthorMaxLen+1)}.Validate(), config.ErrAuthorTooLong)
+	assert.NoError(t, Comment{Content: "hi", Email: "ann@example.com"}.Validate())
+	assert.ErrorIs(t, Comment{Content: "hi", Email: "ann"}.Validate(), config.ErrInvalidEmail)
+	assert.ErrorIs(t, Comment{Content: "hi", Email: "ann @example.com"}.Validate(), config.ErrInvalidEmail)
+	assert.ErrorIs(t, Comment{Content: "hi", Status: "spam"}.Validate(), config.ErrInvalidModerationStatus)
+}
@@ -0,0 +1,53 @@
+package event
+
+import (
+	"time"
+
+	"news-svc/internal/entity/post"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const (
+	// CursorsCollectionName stores how far each instance has read changes.
+	CursorsCollectionName = "event_cursors"
+	// OutboxCollectionName holds events written together with the post
+	// changes they describe, until they have been relayed.
+	OutboxCollectionName = "event_outbox"
+)
+
+// Types of post events.
+const (
+	PostCreated = "post.created"
+	PostUpdated = "post.updated"
+	PostDeleted = "post.deleted"
+)
+
+// Event tells listeners that a post changed. Post is the post as
+// stored after the change; it is nil for deletions. Published is set
+// when the change made the post visible to readers, as far as the
+// source can tell. ID is the same for a change however many instances
+// observe it, so consumers can tell repeats apart.
+type Event struct {
+	ID        string
+	Type      string
+	PostID    string
+	Post      *post.Post
+	Published bool
+	At        time.Time
+}
+
+// NewID returns a random event ID for changes observed only once.
+func NewID() string {
+	return bson.NewObjectID().Hex()
+}
+
+// Cursor is how far a consumer has read post changes from MongoDB.
+// Change streams resume from Token; polling from At, skipping the posts
+// in Seen that were already read at exactly that time.
+type Cursor struct {
+	Consumer string    `bson:"_id"`
+	Token    bson.Raw  `bson:"token,omitempty"`
+	At       time.Time `bson:"at,omitempty"`
+	Seen     []string  `bson:"seen,omitempty"`
+}
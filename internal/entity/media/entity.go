@@ -0,0 +1,187 @@
+package media
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const (
+	CollectionName = "media"
+
+	// Original names the uploaded file itself, as opposed to a variant.
+	Original = "original"
+)
+
+// Sizes are the resized variants generated for every upload, narrowest
+// first. Images are never scaled up, so small uploads get fewer variants.
+var Sizes = []Size{
+	{Name: "thumb", Width: 320},
+	{Name: "medium", Width: 768},
+	{Name: "large", Width: 1600},
+}
+
+type (
+	// Media is an uploaded file. The bytes live in a blob store under Key;
+	// this document only holds the metadata.
+	Media struct {
+		ID          string    `bson:"_id,omitempty" json:"id"`
+		Filename    string    `bson:"filename" json:"filename"`
+		ContentType string    `bson:"content_type" json:"content_type"`
+		Size        int64     `bson:"size" json:"size"`
+		Width       int       `bson:"width" json:"width"`
+		Height      int       `bson:"height" json:"height"`
+		Key         string    `bson:"key" json:"-"`
+		Variants    []Variant `bson:"variants,omitempty" json:"variants,omitempty"`
+		CreatedAt   time.Time `bson:"created_at" json:"created_at"`
+	}
+
+	// Variant is a resized copy of an image, stored next to the original.
+	Variant struct {
+		Name   string `bson:"name" json:"name"`
+		Width  int    `bson:"width" json:"width"`
+		Height int    `bson:"height" json:"height"`
+		Size   int64  `bson:"size" json:"size"`
+		Key    string `bson:"key" json:"-"`
+	}
+
+	Size struct {
+		Name  string
+		Width int
+	}
+
+	mongoMedia struct {
+		ID          bson.ObjectID `bson:"_id,omitempty"`
+		Filename    string        `bson:"filename"`
+		ContentType string        `bson:"content_type"`
+		Size        int64         `bson:"size"`
+		Width       int           `bson:"width"`
+		Height      int           `bson:"height"`
+		Key         string        `bson:"key"`
+		Variants    []Variant     `bson:"variants,omitempty"`
+		CreatedAt   time.Time     `bson:"created_at"`
+	}
+)
+
+// URL returns the public path the original file is served from.
+func (m Media) URL() string {
+	return "/media/" + m.ID
+}
+
+// URLFor returns the path of the named variant, or of the original when
+// the image is too small to have that variant.
+func (m Media) URLFor(name string) string {
+	if _, ok := m.variant(name); ok {
+		return m.URL() + "/" + name
+	}
+	return m.URL()
+}
+
+// SrcSet lists the variants and the original for an img srcset attribute.
+func (m Media) SrcSet() string {
+	var b strings.Builder
+	for _, v := range m.Variants {
+		fmt.Fprintf(&b, "%s/%s %dw, ", m.URL(), v.Name, v.Width)
+	}
+	fmt.Fprintf(&b, "%s %dw", m.URL(), m.Width)
+	return b.String()
+}
+
+// File describes the stored object for a variant name. A known size
+// that was not generated falls back to the original; unknown names
+// report false.
+func (m Media) File(name string) (Variant, bool) {
+	if v, ok := m.variant(name); ok {
+		return v, true
+	}
+	if name != "" && name != Original && !KnownSize(name) {
+		return Variant{}, false
+	}
+
+	return Variant{Name: Original, Width: m.Width, Height: m.Height, Size: m.Size, Key: m.Key}, true
+}
+
+func (m Media) variant(name string) (Variant, bool) {
+	for _, v := range m.Variants {
+		if v.Name == name {
+			return v, true
+		}
+	}
+	return Variant{}, false
+}
+
+// KnownSize reports whether name is one of Sizes.
+func KnownSize(name string) bool {
+	for _, s := range Sizes {
+		if s.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// SrcSetFor builds a srcset from a bare media URL such as a post's cover
+// image, where the variants actually generated are unknown. Missing
+// variants are served as the original, so the result is always usable.
+// URLs outside /media/ yield an empty string.
+func SrcSetFor(url string) string {
+	id, ok := strings.CutPrefix(url, "/media/")
+	if !ok {
+		return ""
+	}
+	if _, err := bson.ObjectIDFromHex(id); err != nil {
+		return ""
+	}
+
+	parts := make([]string, len(Sizes))
+	for i, s := range Sizes {
+		parts[i] = fmt.Sprintf("%s/%s %dw", url, s.Name, s.Width)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (m *Media) MarshalBSON() ([]byte, error) {
+	doc := mongoMedia{
+		Filename:    m.Filename,
+		ContentType: m.ContentType,
+		Size:        m.Size,
+		Width:       m.Width,
+		Height:      m.Height,
+		Key:         m.Key,
+		Variants:    m.Variants,
+		CreatedAt:   m.CreatedAt,
+	}
+
+	if m.ID != "" {
+		objectID, err := bson.ObjectIDFromHex(m.ID)
+		if err != nil {
+			return nil, err
+		}
+		doc.ID = objectID
+	}
+
+	return bson.Marshal(doc)
+}
+
+func (m *Media) UnmarshalBSON(data []byte) error {
+	var tmp mongoMedia
+	if err := bson.Unmarshal(data, &tmp); err != nil {
+		return err
+	}
+
+	*m = Media{
+		ID:          tmp.ID.Hex(),
+		Filename:    tmp.Filename,
+		ContentType: tmp.ContentType,
+		Size:        tmp.Size,
+		Width:       tmp.Width,
+		Height:      tmp.Height,
+		Key:         tmp.Key,
+		Variants:    tmp.Variants,
+		CreatedAt:   tmp.CreatedAt,
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package media
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestMarshalUnmarshalBSON(t *testing.T) {
+	orig := &Media{
+		Filename:    "cat.jpg",
+		ContentType: "image/jpeg",
+		Size:        1234,
+		Width:       640,
+		Height:      480,
+		Key:         "ab/original.jpg",
+		Variants:    []Variant{{Name: "thumb", Width: 320, Height: 240, Size: 99, Key: "ab/thumb.jpg"}},
+		CreatedAt:   time.Now().UTC().Truncate(time.Millisecond),
+	}
+
+	data, err := orig.MarshalBSON()
+	assert.NoError(t, err)
+
+	var doc bson.M
+	assert.NoError(t, bson.Unmarshal(data, &doc))
+	assert.NotContains(t, doc, "_id")
+
+	orig.ID = bson.NewObjectID().Hex()
+	data, err = orig.MarshalBSON()
+	assert.NoError(t, err)
+
+	var round Media
+	assert.NoError(t, round.UnmarshalBSON(data))
+	assert.Equal(t, *orig, round)
+	assert.Equal(t, "/media/"+orig.ID, round.URL())
+
+	_, err = (&Media{ID: "invalid"}).MarshalBSON()
+	assert.Error(t, err)
+}
+
+func TestVariants(t *testing.T) {
+	m := Media{
+		ID:     "abc",
+		Width:  1000,
+		Height: 500,
+		Size:   9000,
+		Key:    "k/original.jpg",
+		Variants: []Variant{
+			{Name: "thumb", Width: 320, Height: 160, Key: "k/thumb.jpg"},
+			{Name: "medium", Width: 768, Height: 384, Key: "k/medium.jpg"},
+		},
+	}
+
+	assert.Equal(t, "/media/abc/thumb", m.URLFor("thumb"))
+	assert.Equal(t, "/media/abc", m.URLFor("large"))
+	assert.Equal(t, "/media/abc/thumb 320w, /media/abc/medium 768w, /media/abc 1000w", m.SrcSet())
+
+	v, ok := m.File("medium")
+	assert.True(t, ok)
+	assert.Equal(t, "k/medium.jpg", v.Key)
+
+	// too small for "large", so the original stands in
+	v, ok = m.File("large")
+	assert.True(t, ok)
+	assert.Equal(t, Variant{Name: Original, Width: 1000, Height: 500, Size: 9000, Key: "k/original.jpg"}, v)
+
+	v, ok = m.File("")
+	assert.True(t, ok)
+	assert.Equal(t, "k/original.jpg", v.Key)
+
+	_, ok = m.File("huge")
+	assert.False(t, ok)
+}
+
+func TestSrcSetFor(t *testing.T) {
+	id := bson.NewObjectID().Hex()
+
+	assert.Equal(t,
+		"/media/"+id+"/thumb 320w, /media/"+id+"/medium 768w, /media/"+id+"/large 1600w",
+		SrcSetFor("/media/"+id))
+	assert.Empty(t, SrcSetFor("https://example.com/cat.jpg"))
+	assert.Empty(t, SrcSetFor("/media/"+id+"/thumb"))
+}
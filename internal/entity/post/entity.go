@@ -1,31 +1,106 @@
 package post
 
 import (
+	"net/url"
 	"news-svc/config"
+	"slices"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"go.mongodb.org/mongo-driver/v2/bson"
 )
 
 const (
 	CollectionName = "posts"
+	// TombstonesCollectionName records recent deletions for readers
+	// that cannot watch the posts collection.
+	TombstonesCollectionName = "post_tombstones"
+	// TombstoneTTL is how long deletions stay visible there.
+	TombstoneTTL = 24 * time.Hour
+
+	StatusDraft     = "draft"
+	StatusPublished = "published"
+
+	// Orders for admin listings.
+	SortNewest    = "newest"
+	SortReactions = "reactions"
+
+	// SummaryMaxLen keeps summaries within what search engines and
+	// link previews display.
+	SummaryMaxLen = 300
 )
 
 type (
 	Post struct {
-		ID        string    `bson:"_id,omitempty" json:"id"`
-		Title     string    `bson:"title" json:"title"`
-		Content   string    `bson:"content" json:"content"`
-		CreatedAt time.Time `bson:"created_at" json:"created_at"`
-		UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+		ID         string    `bson:"_id,omitempty" json:"id"`
+		Slug       string    `bson:"slug,omitempty" json:"slug,omitempty"`
+		Title      string    `bson:"title" json:"title"`
+		Content    string    `bson:"content" json:"content"`
+		Summary    string    `bson:"summary,omitempty" json:"summary,omitempty"`
+		CoverImage string    `bson:"cover_image,omitempty" json:"cover_image,omitempty"`
+		Status     string    `bson:"status" json:"status"`
+		Author     string    `bson:"author,omitempty" json:"author,omitempty"`
+		Tags       []string  `bson:"tags,omitempty" json:"tags,omitempty"`
+		Categories []string  `bson:"categories,omitempty" json:"categories,omitempty"`
+		CreatedAt  time.Time `bson:"created_at" json:"created_at"`
+		UpdatedAt  time.Time `bson:"updated_at" json:"updated_at"`
+
+		// Reactions counts reactions by kind name. ReactionCount is
+		// their sum, stored alongside so posts can be sorted by it.
+		Reactions     map[string]int64 `bson:"reactions,omitempty" json:"reactions,omitempty"`
+		ReactionCount int64            `bson:"reaction_count,omitempty" json:"-"`
+
+		// Related lists the IDs of the posts to read next, best match
+		// first. It is recomputed whenever the post is saved.
+		Related []string `bson:"related,omitempty" json:"-"`
+
+		// CommentCount is filled in for display; comments are counted
+		// from their own collection and never stored on the post.
+		CommentCount int64 `bson:"-" json:"-"`
+		// MyReactions is filled in for display with the kinds the
+		// current reader has reacted with.
+		MyReactions []string `bson:"-" json:"-"`
+		// RelatedPosts is filled in for display from Related.
+		RelatedPosts []*Post `bson:"-" json:"-"`
 	}
 
 	mongoPost struct {
-		ID        bson.ObjectID `bson:"_id,omitempty"`
-		Title     string        `bson:"title"`
-		Content   string        `bson:"content"`
-		CreatedAt time.Time     `bson:"created_at"`
-		UpdatedAt time.Time     `bson:"updated_at"`
+		ID         bson.ObjectID `bson:"_id,omitempty"`
+		Slug       string        `bson:"slug,omitempty"`
+		Title      string        `bson:"title"`
+		Content    string        `bson:"content"`
+		Summary    string        `bson:"summary,omitempty"`
+		CoverImage string        `bson:"cover_image,omitempty"`
+		Status     string        `bson:"status"`
+		Author     string        `bson:"author,omitempty"`
+		Tags       []string      `bson:"tags,omitempty"`
+		Categories []string      `bson:"categories,omitempty"`
+		CreatedAt  time.Time     `bson:"created_at"`
+		UpdatedAt  time.Time     `bson:"updated_at"`
+
+		Reactions     map[string]int64 `bson:"reactions,omitempty"`
+		ReactionCount int64            `bson:"reaction_count,omitempty"`
+
+		Related []bson.ObjectID `bson:"related,omitempty"`
+	}
+
+	// Filter narrows down bulk reads such as exports and feeds.
+	// Zero values mean "no restriction".
+	Filter struct {
+		From     time.Time
+		To       time.Time
+		Status   string
+		Tag      string
+		Category string
+		Author   string
+	}
+
+	// Term is a tag or category together with usage statistics.
+	Term struct {
+		Name      string    `bson:"_id"`
+		Count     int64     `bson:"count"`
+		UpdatedAt time.Time `bson:"updated_at"`
 	}
 )
 
@@ -36,31 +111,99 @@ func (p Post) Validate() error {
 	if p.Content == "" {
 		return config.ErrEmptyContent
 	}
+	if p.Status != "" && !ValidStatus(p.Status) {
+		return config.ErrInvalidStatus
+	}
+	if utf8.RuneCountInString(p.Summary) > SummaryMaxLen {
+		return config.ErrSummaryTooLong
+	}
+	if p.CoverImage != "" && !validImageURL(p.CoverImage) {
+		return config.ErrInvalidCoverImage
+	}
 	return nil
 }
 
+// validImageURL accepts absolute http(s) URLs and site-relative paths.
+func validImageURL(s string) bool {
+	u, err := url.Parse(s)
+	if err != nil {
+		return false
+	}
+	if u.Scheme == "" && u.Host == "" {
+		return strings.HasPrefix(u.Path, "/")
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// ValidStatus reports whether s is a known post status.
+func ValidStatus(s string) bool {
+	return s == StatusDraft || s == StatusPublished
+}
+
 func (p *Post) MarshalBSON() ([]byte, error) {
-	if p.ID == "" {
-		return bson.Marshal(bson.D{
-			{Key: "title", Value: p.Title},
-			{Key: "content", Value: p.Content},
-			{Key: "created_at", Value: p.CreatedAt},
-			{Key: "updated_at", Value: p.UpdatedAt},
-		})
+	doc := bson.D{}
+
+	if p.ID != "" {
+		objectID, err := bson.ObjectIDFromHex(p.ID)
+		if err != nil {
+			return nil, err
+		}
+		doc = append(doc, bson.E{Key: "_id", Value: objectID})
 	}
 
-	objectID, err := bson.ObjectIDFromHex(p.ID)
-	if err != nil {
-		return nil, err
+	// slug has a unique sparse index, so an empty one must not be stored
+	if p.Slug != "" {
+		doc = append(doc, bson.E{Key: "slug", Value: p.Slug})
+	}
+
+	doc = append(doc,
+		bson.E{Key: "title", Value: p.Title},
+		bson.E{Key: "content", Value: p.Content},
+		bson.E{Key: "status", Value: p.Status},
+	)
+
+	if p.Summary != "" {
+		doc = append(doc, bson.E{Key: "summary", Value: p.Summary})
+	}
+	if p.CoverImage != "" {
+		doc = append(doc, bson.E{Key: "cover_image", Value: p.CoverImage})
+	}
+	if p.Author != "" {
+		doc = append(doc, bson.E{Key: "author", Value: p.Author})
+	}
+	if len(p.Tags) > 0 {
+		doc = append(doc, bson.E{Key: "tags", Value: p.Tags})
+	}
+	if len(p.Categories) > 0 {
+		doc = append(doc, bson.E{Key: "categories", Value: p.Categories})
+	}
+
+	doc = append(doc,
+		bson.E{Key: "created_at", Value: p.CreatedAt},
+		bson.E{Key: "updated_at", Value: p.UpdatedAt},
+	)
+
+	// the total is derived so an imported post cannot disagree with itself
+	if len(p.Reactions) > 0 {
+		var total int64
+		for _, n := range p.Reactions {
+			total += n
+		}
+		doc = append(doc,
+			bson.E{Key: "reactions", Value: p.Reactions},
+			bson.E{Key: "reaction_count", Value: total},
+		)
 	}
 
-	return bson.Marshal(bson.D{
-		{Key: "_id", Value: objectID},
-		{Key: "title", Value: p.Title},
-		{Key: "content", Value: p.Content},
-		{Key: "created_at", Value: p.CreatedAt},
-		{Key: "updated_at", Value: p.UpdatedAt},
-	})
+	if len(p.Related) > 0 {
+		related, err := ObjectIDs(p.Related)
+		if err != nil {
+			return nil, err
+		}
+		doc = append(doc, bson.E{Key: "related", Value: related})
+	}
+
+	return bson.Marshal(doc)
 }
 
 func (p *Post) UnmarshalBSON(data []byte) error {
@@ -70,10 +213,92 @@ func (p *Post) UnmarshalBSON(data []byte) error {
 	}
 
 	p.ID = tmp.ID.Hex()
+	p.Slug = tmp.Slug
 	p.Title = tmp.Title
 	p.Content = tmp.Content
+	p.Summary = tmp.Summary
+	p.CoverImage = tmp.CoverImage
+	p.Status = tmp.Status
+	p.Author = tmp.Author
+	p.Tags = tmp.Tags
+	p.Categories = tmp.Categories
 	p.CreatedAt = tmp.CreatedAt
 	p.UpdatedAt = tmp.UpdatedAt
+	p.Reactions = tmp.Reactions
+	p.ReactionCount = tmp.ReactionCount
+
+	p.Related = nil
+	for _, id := range tmp.Related {
+		p.Related = append(p.Related, id.Hex())
+	}
+
+	// posts written before statuses existed are treated as published
+	if p.Status == "" {
+		p.Status = StatusPublished
+	}
 
 	return nil
 }
+
+// ObjectIDs converts hex post IDs for use in queries.
+func ObjectIDs(ids []string) ([]bson.ObjectID, error) {
+	objIDs := make([]bson.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		objID, err := bson.ObjectIDFromHex(id)
+		if err != nil {
+			return nil, err
+		}
+		objIDs = append(objIDs, objID)
+	}
+	return objIDs, nil
+}
+
+// Reacted reports whether the current reader reacted with kind.
+func (p Post) Reacted(kind string) bool {
+	return slices.Contains(p.MyReactions, kind)
+}
+
+// NewFilter builds a Filter from raw user input. Dates are accepted
+// either as "2006-01-02" or RFC 3339; "to" dates without a time part
+// include the whole day.
+func NewFilter(from, to, status string) (Filter, error) {
+	var (
+		f   Filter
+		err error
+	)
+
+	if from != "" {
+		if f.From, _, err = parseDate(from); err != nil {
+			return f, err
+		}
+	}
+
+	if to != "" {
+		var dateOnly bool
+		if f.To, dateOnly, err = parseDate(to); err != nil {
+			return f, err
+		}
+		if dateOnly {
+			f.To = f.To.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		}
+	}
+
+	if status != "" && !ValidStatus(status) {
+		return f, config.ErrInvalidStatus
+	}
+	f.Status = status
+
+	return f, nil
+}
+
+func parseDate(s string) (time.Time, bool, error) {
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, true, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, false, config.ErrInvalidDate
+	}
+	return t, false, nil
+}
@@ -2,6 +2,7 @@ package post
 
 import (
 	"news-svc/config"
+	"strings"
 	"testing"
 	"time"
 
@@ -21,6 +22,30 @@ func TestValidate(t *testing.T) {
 	p.Content = "Content"
 	err = p.Validate()
 	assert.NoError(t, err)
+
+	p.Status = "archived"
+	err = p.Validate()
+	assert.ErrorIs(t, err, config.ErrInvalidStatus)
+
+	p.Status = StatusDraft
+	err = p.Validate()
+	assert.NoError(t, err)
+
+	p.Summary = strings.Repeat("é", SummaryMaxLen+1)
+	err = p.Validate()
+	assert.ErrorIs(t, err, config.ErrSummaryTooLong)
+	p.Summary = strings.Repeat("é", SummaryMaxLen)
+
+	for _, bad := range []string{"javascript:alert(1)", "cover.jpg", "ftp://example.com/a.jpg", "https://"} {
+		p.CoverImage = bad
+		err = p.Validate()
+		assert.ErrorIs(t, err, config.ErrInvalidCoverImage, bad)
+	}
+	for _, good := range []string{"/media/a.jpg", "https://cdn.example.com/a.jpg"} {
+		p.CoverImage = good
+		err = p.Validate()
+		assert.NoError(t, err, good)
+	}
 }
 
 func TestMarshalUnmarshalBSON(t *testing.T) {
@@ -63,3 +88,65 @@ func TestMarshalBSONInvalidHex(t *testing.T) {
 	_, err := p.MarshalBSON()
 	assert.Error(t, err)
 }
+
+func TestMarshalBSONReactions(t *testing.T) {
+	p := &Post{Title: "T", Content: "C", Reactions: map[string]int64{"like": 3, "sad": 1}, ReactionCount: 99}
+
+	data, err := p.MarshalBSON()
+	assert.NoError(t, err)
+
+	var round Post
+	assert.NoError(t, round.UnmarshalBSON(data))
+	assert.Equal(t, p.Reactions, round.Reactions)
+	assert.Equal(t, int64(4), round.ReactionCount)
+
+	data, err = (&Post{Title: "T", Content: "C"}).MarshalBSON()
+	assert.NoError(t, err)
+
+	var doc bson.M
+	assert.NoError(t, bson.Unmarshal(data, &doc))
+	assert.NotContains(t, doc, "reactions")
+	assert.NotContains(t, doc, "reaction_count")
+}
+
+func TestMarshalBSONRelated(t *testing.T) {
+	related := []string{"65a000000000000000000001", "65a000000000000000000002"}
+	data, err := (&Post{Title: "T", Content: "C", Related: related}).MarshalBSON()
+	assert.NoError(t, err)
+
+	var round Post
+	assert.NoError(t, round.UnmarshalBSON(data))
+	assert.Equal(t, related, round.Related)
+
+	_, err = (&Post{Title: "T", Content: "C", Related: []string{"nope"}}).MarshalBSON()
+	assert.Error(t, err)
+}
+
+func TestUnmarshalBSONDefaultsStatus(t *testing.T) {
+	data, err := bson.Marshal(bson.D{{Key: "title", Value: "T"}})
+	assert.NoError(t, err)
+
+	var p Post
+	err = p.UnmarshalBSON(data)
+	assert.NoError(t, err)
+	assert.Equal(t, StatusPublished, p.Status)
+}
+
+func TestNewFilter(t *testing.T) {
+	f, err := NewFilter("2024-01-02", "2024-01-31", StatusPublished)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), f.From)
+	assert.Equal(t, time.Date(2024, 1, 31, 23, 59, 59, 999999999, time.UTC), f.To)
+	assert.Equal(t, StatusPublished, f.Status)
+
+	f, err = NewFilter("", "2024-01-31T12:00:00Z", "")
+	assert.NoError(t, err)
+	assert.True(t, f.From.IsZero())
+	assert.Equal(t, time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC), f.To)
+
+	_, err = NewFilter("yesterday", "", "")
+	assert.ErrorIs(t, err, config.ErrInvalidDate)
+
+	_, err = NewFilter("", "", "archived")
+	assert.ErrorIs(t, err, config.ErrInvalidStatus)
+}
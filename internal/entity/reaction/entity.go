@@ -0,0 +1,99 @@
+package reaction
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const CollectionName = "reactions"
+
+type (
+	// Kind is one of the fixed reactions readers can leave on a post.
+	// Name is what is stored and used in URLs.
+	Kind struct {
+		Name  string
+		Emoji string
+		Label string
+	}
+
+	// Reaction records that a reader reacted to a post, so the same
+	// reader cannot count twice. The counts themselves live on the post.
+	// Reactor is "user:<name>" for signed-in users and "anon:<token>"
+	// for everyone else.
+	Reaction struct {
+		ID        string    `bson:"_id,omitempty" json:"id"`
+		PostID    string    `bson:"post_id" json:"post_id"`
+		Reactor   string    `bson:"reactor" json:"-"`
+		Kind      string    `bson:"kind" json:"kind"`
+		CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	}
+
+	mongoReaction struct {
+		ID        bson.ObjectID `bson:"_id,omitempty"`
+		PostID    bson.ObjectID `bson:"post_id"`
+		Reactor   string        `bson:"reactor"`
+		Kind      string        `bson:"kind"`
+		CreatedAt time.Time     `bson:"created_at"`
+	}
+)
+
+// Kinds is the set of reactions, in display order.
+var Kinds = []Kind{
+	{Name: "like", Emoji: "👍", Label: "Like"},
+	{Name: "love", Emoji: "❤️", Label: "Love"},
+	{Name: "laugh", Emoji: "😂", Label: "Funny"},
+	{Name: "wow", Emoji: "😮", Label: "Surprising"},
+	{Name: "sad", Emoji: "😢", Label: "Sad"},
+}
+
+// ValidKind reports whether name is one of Kinds.
+func ValidKind(name string) bool {
+	for _, k := range Kinds {
+		if k.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Reaction) MarshalBSON() ([]byte, error) {
+	doc := mongoReaction{
+		Reactor:   r.Reactor,
+		Kind:      r.Kind,
+		CreatedAt: r.CreatedAt,
+	}
+
+	if r.ID != "" {
+		objectID, err := bson.ObjectIDFromHex(r.ID)
+		if err != nil {
+			return nil, err
+		}
+		doc.ID = objectID
+	}
+
+	postID, err := bson.ObjectIDFromHex(r.PostID)
+	if err != nil {
+		return nil, err
+	}
+	doc.PostID = postID
+
+	return bson.Marshal(doc)
+}
+
+func (r *Reaction) UnmarshalBSON(data []byte) error {
+	var tmp mongoReaction
+	if err := bson.Unmarshal(data, &tmp); err != nil {
+		return err
+	}
+
+	*r = Reaction{
+		ID:        tmp.ID.Hex(),
+		PostID:    tmp.PostID.Hex(),
+		Reactor:   tmp.Reactor,
+		Kind:      tmp.Kind,
+		CreatedAt: tmp.CreatedAt,
+	}
+
+	return nil
+}
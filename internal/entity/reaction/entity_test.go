@@ -0,0 +1,43 @@
+package reaction
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestMarshalUnmarshalBSON(t *testing.T) {
+	orig := &Reaction{
+		PostID:    bson.NewObjectID().Hex(),
+		Reactor:   "anon:abc",
+		Kind:      "like",
+		CreatedAt: time.Now().UTC().Truncate(time.Millisecond),
+	}
+
+	data, err := orig.MarshalBSON()
+	assert.NoError(t, err)
+
+	var doc bson.M
+	assert.NoError(t, bson.Unmarshal(data, &doc))
+	assert.NotContains(t, doc, "_id")
+	assert.IsType(t, bson.ObjectID{}, doc["post_id"])
+
+	var round Reaction
+	assert.NoError(t, round.UnmarshalBSON(data))
+	assert.Equal(t, orig.PostID, round.PostID)
+	assert.Equal(t, orig.Reactor, round.Reactor)
+	assert.Equal(t, orig.CreatedAt, round.CreatedAt)
+
+	_, err = (&Reaction{PostID: "nope"}).MarshalBSON()
+	assert.Error(t, err)
+}
+
+func TestValidKind(t *testing.T) {
+	for _, k := range Kinds {
+		assert.True(t, ValidKind(k.Name))
+	}
+	assert.False(t, ValidKind(""))
+	assert.False(t, ValidKind("👍"))
+}
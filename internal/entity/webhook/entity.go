@@ -0,0 +1,261 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"news-svc/config"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const (
+	CollectionName           = "webhooks"
+	DeliveriesCollectionName = "webhook_deliveries"
+
+	// Events webhooks can subscribe to.
+	EventPublished = "post.published"
+	EventUpdated   = "post.updated"
+	EventDeleted   = "post.deleted"
+
+	// A delivery is pending until it succeeds or runs out of attempts.
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+
+	// Request headers. The signature is "sha256=" and the hex HMAC-SHA256
+	// of the request body, keyed with the webhook's secret.
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+	SignatureHeader = "X-Webhook-Signature-256"
+
+	// DeliveryRetention is how long deliveries stay in the log.
+	DeliveryRetention = 30 * 24 * time.Hour
+)
+
+// Events lists every event in the order they are offered to admins.
+var Events = []string{EventPublished, EventUpdated, EventDeleted}
+
+type (
+	// Webhook is an endpoint that is sent a POST request for each of
+	// the subscribed events.
+	Webhook struct {
+		ID        string    `bson:"_id,omitempty" json:"id"`
+		URL       string    `bson:"url" json:"url"`
+		Secret    string    `bson:"secret" json:"-"`
+		Events    []string  `bson:"events" json:"events"`
+		Active    bool      `bson:"active" json:"active"`
+		CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	}
+
+	mongoWebhook struct {
+		ID        bson.ObjectID `bson:"_id,omitempty"`
+		URL       string        `bson:"url"`
+		Secret    string        `bson:"secret"`
+		Events    []string      `bson:"events"`
+		Active    bool          `bson:"active"`
+		CreatedAt time.Time     `bson:"created_at"`
+	}
+
+	// Delivery is one event queued for, and then sent to, one webhook.
+	// EventID is unique per webhook so an event observed by several
+	// instances is delivered once. Replays are new deliveries of the
+	// same payload pointing back at the original.
+	Delivery struct {
+		ID            string
+		WebhookID     string
+		EventID       string
+		Event         string
+		Payload       string
+		Status        string
+		Attempts      []Attempt
+		NextAttemptAt time.Time
+		ReplayOf      string
+		CreatedAt     time.Time
+		UpdatedAt     time.Time
+	}
+
+	mongoDelivery struct {
+		ID            bson.ObjectID `bson:"_id,omitempty"`
+		WebhookID     bson.ObjectID `bson:"webhook_id"`
+		EventID       string        `bson:"event_id"`
+		Event         string        `bson:"event"`
+		Payload       string        `bson:"payload"`
+		Status        string        `bson:"status"`
+		Attempts      []Attempt     `bson:"attempts,omitempty"`
+		NextAttemptAt time.Time     `bson:"next_attempt_at"`
+		ReplayOf      bson.ObjectID `bson:"replay_of,omitempty"`
+		CreatedAt     time.Time     `bson:"created_at"`
+		UpdatedAt     time.Time     `bson:"updated_at"`
+	}
+
+	// Attempt records one request of a delivery. StatusCode is 0 when
+	// no response arrived, in which case Error says why.
+	Attempt struct {
+		At         time.Time     `bson:"at"`
+		StatusCode int           `bson:"status_code,omitempty"`
+		Error      string        `bson:"error,omitempty"`
+		Duration   time.Duration `bson:"duration"`
+	}
+
+	// Payload is the JSON body of every request.
+	Payload struct {
+		ID        string      `json:"id"`
+		Event     string      `json:"event"`
+		CreatedAt time.Time   `json:"created_at"`
+		Post      PostPayload `json:"post"`
+	}
+
+	// PostPayload describes the post an event is about. Deletions only
+	// carry the ID.
+	PostPayload struct {
+		ID         string    `json:"id"`
+		URL        string    `json:"url,omitempty"`
+		Slug       string    `json:"slug,omitempty"`
+		Title      string    `json:"title,omitempty"`
+		Summary    string    `json:"summary,omitempty"`
+		Status     string    `json:"status,omitempty"`
+		Author     string    `json:"author,omitempty"`
+		Tags       []string  `json:"tags,omitempty"`
+		Categories []string  `json:"categories,omitempty"`
+		CreatedAt  time.Time `json:"created_at,omitzero"`
+		UpdatedAt  time.Time `json:"updated_at,omitzero"`
+	}
+)
+
+// Validate checks the endpoint and the subscribed events.
+func (w Webhook) Validate() error {
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return config.ErrInvalidWebhookURL
+	}
+	if len(w.Events) == 0 {
+		return config.ErrNoWebhookEvents
+	}
+	for _, e := range w.Events {
+		if !slices.Contains(Events, e) {
+			return config.ErrUnknownWebhookEvent
+		}
+	}
+	return nil
+}
+
+// Subscribed reports whether the webhook wants event.
+func (w Webhook) Subscribed(event string) bool {
+	return slices.Contains(w.Events, event)
+}
+
+// Last returns the latest attempt, or nil before the first one.
+func (d Delivery) Last() *Attempt {
+	if len(d.Attempts) == 0 {
+		return nil
+	}
+	return &d.Attempts[len(d.Attempts)-1]
+}
+
+// Signature returns the value of SignatureHeader for body.
+func Signature(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (w *Webhook) MarshalBSON() ([]byte, error) {
+	doc := mongoWebhook{
+		URL:       w.URL,
+		Secret:    w.Secret,
+		Events:    w.Events,
+		Active:    w.Active,
+		CreatedAt: w.CreatedAt,
+	}
+
+	if w.ID != "" {
+		objectID, err := bson.ObjectIDFromHex(w.ID)
+		if err != nil {
+			return nil, err
+		}
+		doc.ID = objectID
+	}
+
+	return bson.Marshal(doc)
+}
+
+func (w *Webhook) UnmarshalBSON(data []byte) error {
+	var tmp mongoWebhook
+	if err := bson.Unmarshal(data, &tmp); err != nil {
+		return err
+	}
+
+	*w = Webhook{
+		ID:        tmp.ID.Hex(),
+		URL:       tmp.URL,
+		Secret:    tmp.Secret,
+		Events:    tmp.Events,
+		Active:    tmp.Active,
+		CreatedAt: tmp.CreatedAt,
+	}
+
+	return nil
+}
+
+func (d *Delivery) MarshalBSON() ([]byte, error) {
+	doc := mongoDelivery{
+		EventID:       d.EventID,
+		Event:         d.Event,
+		Payload:       d.Payload,
+		Status:        d.Status,
+		Attempts:      d.Attempts,
+		NextAttemptAt: d.NextAttemptAt,
+		CreatedAt:     d.CreatedAt,
+		UpdatedAt:     d.UpdatedAt,
+	}
+
+	ids := []struct {
+		hex string
+		dst *bson.ObjectID
+	}{
+		{d.ID, &doc.ID},
+		{d.WebhookID, &doc.WebhookID},
+		{d.ReplayOf, &doc.ReplayOf},
+	}
+	for _, id := range ids {
+		if id.hex == "" {
+			continue
+		}
+		objectID, err := bson.ObjectIDFromHex(id.hex)
+		if err != nil {
+			return nil, err
+		}
+		*id.dst = objectID
+	}
+
+	return bson.Marshal(doc)
+}
+
+func (d *Delivery) UnmarshalBSON(data []byte) error {
+	var tmp mongoDelivery
+	if err := bson.Unmarshal(data, &tmp); err != nil {
+		return err
+	}
+
+	*d = Delivery{
+		ID:            tmp.ID.Hex(),
+		WebhookID:     tmp.WebhookID.Hex(),
+		EventID:       tmp.EventID,
+		Event:         tmp.Event,
+		Payload:       tmp.Payload,
+		Status:        tmp.Status,
+		Attempts:      tmp.Attempts,
+		NextAttemptAt: tmp.NextAttemptAt,
+		CreatedAt:     tmp.CreatedAt,
+		UpdatedAt:     tmp.UpdatedAt,
+	}
+	if !tmp.ReplayOf.IsZero() {
+		d.ReplayOf = tmp.ReplayOf.Hex()
+	}
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package webhook
+
+import (
+	"testing"
+	"time"
+
+	"news-svc/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestValidate(t *testing.T) {
+	w := Webhook{URL: "https://cdn.example.com/purge", Events: []string{EventPublished, EventDeleted}}
+	assert.NoError(t, w.Validate())
+
+	for _, u := range []string{"", "cdn.example.com/purge", "ftp://example.com", "https://"} {
+		assert.ErrorIs(t, Webhook{URL: u, Events: Events}.Validate(), config.ErrInvalidWebhookURL, u)
+	}
+	assert.ErrorIs(t, Webhook{URL: w.URL}.Validate(), config.ErrNoWebhookEvents)
+	assert.ErrorIs(t, Webhook{URL: w.URL, Events: []string{"post.read"}}.Validate(), config.ErrUnknownWebhookEvent)
+}
+
+func TestSignature(t *testing.T) {
+	// printf '{"id":"1"}' | openssl dgst -sha256 -hmac secret
+	assert.Equal(t,
+		"sha256=6146142a2ce0159e84c0767881e4ec80bc397da62526e7d19f70795eb79460c0",
+		Signature("secret", []byte(`{"id":"1"}`)))
+	assert.NotEqual(t, Signature("secret", []byte("a")), Signature("other", []byte("a")))
+}
+
+func TestDeliveryMarshalBSON(t *testing.T) {
+	d := &Delivery{
+		ID:            bson.NewObjectID().Hex(),
+		WebhookID:     bson.NewObjectID().Hex(),
+		EventID:       "e1",
+		Event:         EventPublished,
+		Payload:       `{}`,
+		Status:        DeliveryPending,
+		Attempts:      []Attempt{{At: time.Now().UTC().Truncate(time.Millisecond), StatusCode: 500, Duration: time.Second}},
+		NextAttemptAt: time.Now().UTC().Truncate(time.Millisecond),
+	}
+
+	data, err := d.MarshalBSON()
+	require.NoError(t, err)
+
+	var round Delivery
+	require.NoError(t, round.UnmarshalBSON(data))
+	assert.Equal(t, d.ID, round.ID)
+	assert.Equal(t, d.WebhookID, round.WebhookID)
+	assert.Equal(t, d.Attempts, round.Attempts)
+	assert.Empty(t, round.ReplayOf)
+
+	last := round.Last()
+	require.NotNil(t, last)
+	assert.Equal(t, 500, last.StatusCode)
+	assert.Nil(t, Delivery{}.Last())
+}
@@ -0,0 +1,84 @@
+package analytics
+
+import (
+	"context"
+	"time"
+
+	"news-svc/internal/entity/analytics"
+	"news-svc/pkg/tracing"
+)
+
+// maxPoints caps a series so a bad range cannot build a huge chart.
+const maxPoints = 24 * 90
+
+// Series returns views per hour or day in [from, to) for one post, or
+// for the whole site when postID is empty. Buckets without views are
+// included as zeros so the series can be charted as is.
+func (s *service) Series(ctx context.Context, period, postID string, from, to time.Time) ([]analytics.Point, error) {
+	ctx, span := tracing.Start(ctx, "service.analytics.Series")
+	defer span.End()
+
+	if period != analytics.Daily {
+		period = analytics.Hourly
+	}
+	step := analytics.Step(period)
+	from = analytics.Start(period, from)
+	if to.Sub(from) > maxPoints*step {
+		from = to.Add(-maxPoints * step)
+	}
+
+	stored, err := s.repo.Series(ctx, period, postID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	byStart := make(map[time.Time]analytics.Point, len(stored))
+	for _, p := range stored {
+		byStart[p.Start] = p
+	}
+
+	var points []analytics.Point
+	for t := from; t.Before(to); t = t.Add(step) {
+		p, ok := byStart[t]
+		if !ok {
+			p = analytics.Point{Start: t}
+		}
+		points = append(points, p)
+	}
+	return points, nil
+}
+
+// Top returns the most viewed posts over the days in [from, to) with
+// their current titles. Posts deleted since keep an empty title.
+func (s *service) Top(ctx context.Context, from, to time.Time, limit int64) ([]analytics.PostStats, error) {
+	ctx, span := tracing.Start(ctx, "service.analytics.Top")
+	defer span.End()
+
+	if limit <= 0 {
+		limit = 20
+	}
+
+	stats, err := s.repo.Top(ctx, analytics.Start(analytics.Daily, from), to, limit)
+	if err != nil || len(stats) == 0 {
+		return stats, err
+	}
+
+	ids := make([]string, len(stats))
+	for i, st := range stats {
+		ids[i] = st.PostID
+	}
+
+	posts, err := s.posts.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	titles := make(map[string]string, len(posts))
+	for _, p := range posts {
+		titles[p.ID] = p.Title
+	}
+	for i := range stats {
+		stats[i].Title = titles[stats[i].PostID]
+	}
+	return stats, nil
+}
@@ -0,0 +1,196 @@
+package analytics
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"news-svc/config"
+	"news-svc/internal/entity/analytics"
+	"news-svc/pkg/useragent"
+)
+
+// Start launches the writer. It is a no-op when tracking is disabled
+// or the writer is already running.
+func (s *service) Start() {
+	if !s.cfg.Enabled {
+		return
+	}
+	s.startOnce.Do(func() {
+		s.started.Store(true)
+		go s.run()
+	})
+}
+
+// Track counts a view of a post. It never blocks: bots are ignored and
+// views are dropped when the buffer is full.
+func (s *service) Track(postID, ip, userAgent string) {
+	if !s.cfg.Enabled || useragent.IsBot(userAgent) {
+		return
+	}
+
+	select {
+	case s.hits <- hit{postID, ip, userAgent, time.Now()}:
+	default:
+		s.dropped.Add(1)
+	}
+}
+
+// Check reports an error once the writer has stopped. Nothing is
+// checked before Start.
+func (s *service) Check(ctx context.Context) error {
+	if !s.started.Load() {
+		return nil
+	}
+
+	select {
+	case <-s.done:
+		return config.ErrWorkerStopped
+	default:
+		return nil
+	}
+}
+
+// Close stops the writer after it has written the buffered views, or
+// gives up when ctx is done.
+func (s *service) Close(ctx context.Context) error {
+	s.stopOnce.Do(func() { close(s.stop) })
+	if !s.started.Load() {
+		return nil
+	}
+
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// batch collects views between flushes.
+type batch struct {
+	buckets map[bucketKey]*analytics.Bucket
+	visits  map[analytics.Visit]struct{}
+}
+
+type bucketKey struct {
+	postID string
+	period string
+	start  time.Time
+}
+
+func newBatch() *batch {
+	return &batch{
+		buckets: make(map[bucketKey]*analytics.Bucket),
+		visits:  make(map[analytics.Visit]struct{}),
+	}
+}
+
+func (b *batch) bucket(postID, period string, at time.Time) *analytics.Bucket {
+	key := bucketKey{postID, period, analytics.Start(period, at)}
+	bk, ok := b.buckets[key]
+	if !ok {
+		bk = &analytics.Bucket{PostID: postID, Period: period, Start: key.start}
+		b.buckets[key] = bk
+	}
+	return bk
+}
+
+func (s *service) run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	b := newBatch()
+	for {
+		select {
+		case h := <-s.hits:
+			s.add(b, h)
+		case <-ticker.C:
+			s.flush(b)
+			b = newBatch()
+		case <-s.stop:
+			for {
+				select {
+				case h := <-s.hits:
+					s.add(b, h)
+				default:
+					s.flush(b)
+					return
+				}
+			}
+		}
+	}
+}
+
+func (s *service) add(b *batch, h hit) {
+	b.bucket(h.postID, analytics.Hourly, h.at).Views++
+	b.bucket(h.postID, analytics.Daily, h.at).Views++
+
+	day := analytics.Start(analytics.Daily, h.at)
+	visitor, err := s.visitor(day, h)
+	if err != nil {
+		// the view still counts, only the visitor cannot be told apart
+		s.l.Error("Analytics salt error", "err", err)
+		return
+	}
+	b.visits[analytics.Visit{PostID: h.postID, Day: day, Visitor: visitor}] = struct{}{}
+}
+
+// visitor hashes the address and User-Agent with the salt of the day.
+// Hashes of different days cannot be linked, and once the salt has
+// expired a hash cannot be traced back to an address.
+func (s *service) visitor(day time.Time, h hit) (string, error) {
+	if !day.Equal(s.saltDay) {
+		ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+		salt, err := s.repo.Salt(ctx, day)
+		cancel()
+		if err != nil {
+			return "", err
+		}
+		s.saltDay, s.salt = day, salt
+	}
+
+	mac := hmac.New(sha256.New, s.salt)
+	mac.Write([]byte(h.ip))
+	mac.Write([]byte{0})
+	mac.Write([]byte(h.userAgent))
+	return hex.EncodeToString(mac.Sum(nil)[:16]), nil
+}
+
+func (s *service) flush(b *batch) {
+	if n := s.dropped.Swap(0); n > 0 {
+		s.l.Warn("Analytics buffer full, views dropped", "dropped", n)
+	}
+	if len(b.buckets) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+	defer cancel()
+
+	visits := make([]analytics.Visit, 0, len(b.visits))
+	for v := range b.visits {
+		visits = append(visits, v)
+	}
+
+	added, err := s.repo.AddVisits(ctx, visits)
+	if err != nil {
+		s.l.Error("Analytics visitors error", "err", err)
+	}
+	for _, v := range added {
+		b.bucket(v.PostID, analytics.Daily, v.Day).Visitors++
+	}
+
+	buckets := make([]analytics.Bucket, 0, len(b.buckets))
+	for _, bk := range b.buckets {
+		buckets = append(buckets, *bk)
+	}
+
+	if err := s.repo.Increment(ctx, buckets); err != nil {
+		s.l.Error("Analytics write error", "err", err, "buckets", len(buckets))
+	}
+}
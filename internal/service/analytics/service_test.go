@@ -0,0 +1,203 @@
+package analytics
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"news-svc/config"
+	"news-svc/internal/entity/analytics"
+	"news-svc/internal/entity/post"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const browser = "Mozilla/5.0 (X11; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0"
+
+type (
+	// memRepo stores buckets and visitors the way the Mongo repo does.
+	memRepo struct {
+		mu      sync.Mutex
+		salts   map[time.Time][]byte
+		visits  map[analytics.Visit]bool
+		buckets map[[3]string]*analytics.Bucket
+		flushes int
+
+		series []analytics.Point
+		top    []analytics.PostStats
+	}
+
+	mockPosts struct{}
+)
+
+func newMemRepo() *memRepo {
+	return &memRepo{
+		salts:   map[time.Time][]byte{},
+		visits:  map[analytics.Visit]bool{},
+		buckets: map[[3]string]*analytics.Bucket{},
+	}
+}
+
+func (m *memRepo) Salt(ctx context.Context, day time.Time) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.salts[day]; !ok {
+		m.salts[day] = []byte(day.String())
+	}
+	return m.salts[day], nil
+}
+func (m *memRepo) AddVisits(ctx context.Context, visits []analytics.Visit) ([]analytics.Visit, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var added []analytics.Visit
+	for _, v := range visits {
+		if !m.visits[v] {
+			m.visits[v] = true
+			added = append(added, v)
+		}
+	}
+	return added, nil
+}
+func (m *memRepo) Increment(ctx context.Context, buckets []analytics.Bucket) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.flushes++
+	for _, b := range buckets {
+		key := [3]string{b.PostID, b.Period, b.Start.String()}
+		stored, ok := m.buckets[key]
+		if !ok {
+			stored = &analytics.Bucket{PostID: b.PostID, Period: b.Period, Start: b.Start}
+			m.buckets[key] = stored
+		}
+		stored.Views += b.Views
+		stored.Visitors += b.Visitors
+	}
+	return nil
+}
+func (m *memRepo) Series(ctx context.Context, period, postID string, from, to time.Time) ([]analytics.Point, error) {
+	return m.series, nil
+}
+func (m *memRepo) Top(ctx context.Context, from, to time.Time, limit int64) ([]analytics.PostStats, error) {
+	return m.top, nil
+}
+
+func (m *memRepo) bucket(postID, period string) analytics.Bucket {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := [3]string{postID, period, analytics.Start(period, time.Now()).String()}
+	if b, ok := m.buckets[key]; ok {
+		return *b
+	}
+	return analytics.Bucket{}
+}
+
+func (mockPosts) GetByIDs(ctx context.Context, ids []string) ([]*post.Post, error) {
+	return []*post.Post{{ID: "p1", Title: "First"}}, nil
+}
+
+func newService(repo *memRepo, cfg config.Analytics) *service {
+	return New(repo, mockPosts{}, cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+var testConfig = config.Analytics{Enabled: true, BufferSize: 100, FlushInterval: time.Hour}
+
+func TestTrackAggregates(t *testing.T) {
+	repo := newMemRepo()
+	svc := newService(repo, testConfig)
+	svc.Start()
+
+	svc.Track("p1", "10.0.0.1", browser)
+	svc.Track("p1", "10.0.0.1", browser)
+	svc.Track("p1", "10.0.0.2", browser)
+	svc.Track("p2", "10.0.0.1", browser)
+	svc.Track("p1", "10.0.0.3", "Googlebot/2.1")
+	svc.Track("p1", "10.0.0.3", "")
+
+	require.NoError(t, svc.Close(context.Background()))
+
+	daily := repo.bucket("p1", analytics.Daily)
+	assert.Equal(t, int64(3), daily.Views)
+	assert.Equal(t, int64(2), daily.Visitors)
+
+	hourly := repo.bucket("p1", analytics.Hourly)
+	assert.Equal(t, int64(3), hourly.Views)
+	assert.Zero(t, hourly.Visitors)
+
+	assert.Equal(t, int64(1), repo.bucket("p2", analytics.Daily).Visitors)
+	assert.Equal(t, 1, repo.flushes)
+
+	for v := range repo.visits {
+		assert.NotContains(t, v.Visitor, "10.0.0")
+		assert.Len(t, v.Visitor, 32)
+	}
+}
+
+func TestVisitorsCountOncePerDay(t *testing.T) {
+	repo := newMemRepo()
+
+	for range 2 {
+		svc := newService(repo, testConfig)
+		svc.Start()
+		svc.Track("p1", "10.0.0.1", browser)
+		require.NoError(t, svc.Close(context.Background()))
+	}
+
+	daily := repo.bucket("p1", analytics.Daily)
+	assert.Equal(t, int64(2), daily.Views)
+	assert.Equal(t, int64(1), daily.Visitors)
+}
+
+func TestTrackDropsWhenFull(t *testing.T) {
+	svc := newService(newMemRepo(), config.Analytics{Enabled: true, BufferSize: 1, FlushInterval: time.Hour})
+
+	// not started, so nothing drains the buffer
+	for range 3 {
+		svc.Track("p1", "10.0.0.1", browser)
+	}
+
+	assert.Equal(t, int64(2), svc.dropped.Load())
+}
+
+func TestTrackDisabled(t *testing.T) {
+	repo := newMemRepo()
+	svc := newService(repo, config.Analytics{BufferSize: 10, FlushInterval: time.Hour})
+	svc.Start()
+
+	svc.Track("p1", "10.0.0.1", browser)
+
+	require.NoError(t, svc.Close(context.Background()))
+	assert.Empty(t, svc.hits)
+	assert.Zero(t, repo.flushes)
+}
+
+func TestSeriesFillsGaps(t *testing.T) {
+	day := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+	repo := newMemRepo()
+	repo.series = []analytics.Point{{Start: day.Add(24 * time.Hour), Views: 5, Visitors: 3}}
+	svc := newService(repo, testConfig)
+
+	points, err := svc.Series(context.Background(), analytics.Daily, "", day.Add(time.Hour), day.Add(72*time.Hour))
+	require.NoError(t, err)
+
+	require.Len(t, points, 3)
+	assert.Equal(t, day, points[0].Start)
+	assert.Zero(t, points[0].Views)
+	assert.Equal(t, int64(5), points[1].Views)
+	assert.Zero(t, points[2].Views)
+}
+
+func TestTopAddsTitles(t *testing.T) {
+	repo := newMemRepo()
+	repo.top = []analytics.PostStats{{PostID: "p1", Views: 9}, {PostID: "gone", Views: 2}}
+	svc := newService(repo, testConfig)
+
+	stats, err := svc.Top(context.Background(), time.Now().Add(-time.Hour), time.Now(), 0)
+	require.NoError(t, err)
+
+	assert.Equal(t, "First", stats[0].Title)
+	assert.Empty(t, stats[1].Title)
+}
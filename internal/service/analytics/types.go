@@ -0,0 +1,73 @@
+package analytics
+
+import (
+	"context"
+	"log/slog"
+	"news-svc/config"
+	"news-svc/internal/entity/analytics"
+	"news-svc/internal/entity/post"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// flushTimeout bounds a single write of buffered views.
+const flushTimeout = 30 * time.Second
+
+type (
+	repository interface {
+		Salt(ctx context.Context, day time.Time) ([]byte, error)
+		AddVisits(ctx context.Context, visits []analytics.Visit) ([]analytics.Visit, error)
+		Increment(ctx context.Context, buckets []analytics.Bucket) error
+		Series(ctx context.Context, period, postID string, from, to time.Time) ([]analytics.Point, error)
+		Top(ctx context.Context, from, to time.Time, limit int64) ([]analytics.PostStats, error)
+	}
+
+	// posts is the part of the post repository analytics depend on.
+	posts interface {
+		GetByIDs(ctx context.Context, ids []string) ([]*post.Post, error)
+	}
+
+	// service tracks views through a buffered channel drained by a single
+	// writer goroutine, so pages never wait for Mongo. Start launches the
+	// writer and Close flushes what is left.
+	service struct {
+		repo  repository
+		posts posts
+		cfg   config.Analytics
+		l     *slog.Logger
+
+		hits      chan hit
+		stop      chan struct{}
+		done      chan struct{}
+		startOnce sync.Once
+		stopOnce  sync.Once
+		started   atomic.Bool
+		dropped   atomic.Int64
+
+		// owned by the writer goroutine
+		saltDay time.Time
+		salt    []byte
+	}
+
+	// hit is a view waiting to be counted. The address only lives here,
+	// in memory, until the writer has hashed it.
+	hit struct {
+		postID    string
+		ip        string
+		userAgent string
+		at        time.Time
+	}
+)
+
+func New(repo repository, posts posts, cfg config.Analytics, l *slog.Logger) *service {
+	return &service{
+		repo:  repo,
+		posts: posts,
+		cfg:   cfg,
+		l:     l,
+		hits:  make(chan hit, max(cfg.BufferSize, 1)),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+}
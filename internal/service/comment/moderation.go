@@ -0,0 +1,207 @@
+package comment
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"news-svc/config"
+	"news-svc/internal/entity/comment"
+	"news-svc/pkg/spam"
+	"news-svc/pkg/tracing"
+)
+
+const (
+	// trainingLimit bounds how many past decisions Train replays.
+	trainingLimit = 10_000
+)
+
+var linkPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)`)
+
+// screen applies the moderation rules to a new comment and returns the
+// reasons to hold it, if any, together with its spam score.
+func (s service) screen(ctx context.Context, c *comment.Comment) ([]string, float64, error) {
+	var reasons []string
+
+	if limit := s.rules.MaxLinks; limit > 0 {
+		if n := len(linkPattern.FindAllStringIndex(c.Content, -1)); n > limit {
+			reasons = append(reasons, fmt.Sprintf("%d links", n))
+		}
+	}
+
+	if word, ok := bannedWord(c.Author+"\n"+c.Content, s.rules.BannedWords); ok {
+		reasons = append(reasons, fmt.Sprintf("banned word %q", word))
+	}
+
+	if s.rules.HoldFirstTime {
+		known := false
+		if c.Identity != "" {
+			var err error
+			if known, err = s.repo.HasApproved(ctx, c.Identity); err != nil {
+				return nil, 0, err
+			}
+		}
+		if !known {
+			reasons = append(reasons, "first comment")
+		}
+	}
+
+	if limit := s.rules.RateLimit; limit > 0 && c.IPHash != "" {
+		n, err := s.repo.CountByIPSince(ctx, c.IPHash, time.Now().Add(-s.rules.RateWindow))
+		if err != nil {
+			return nil, 0, err
+		}
+		if n >= int64(limit) {
+			reasons = append(reasons, fmt.Sprintf("over %d comments in %s from one address", limit, s.rules.RateWindow))
+		}
+	}
+
+	var score float64
+	if s.classifier != nil && s.rules.SpamThreshold > 0 {
+		var err error
+		if score, err = s.classifier.Score(ctx, classifierText(c)); err != nil {
+			return nil, 0, err
+		}
+		if score >= s.rules.SpamThreshold {
+			reasons = append(reasons, fmt.Sprintf("spam score %.2f", score))
+		}
+	}
+
+	return reasons, score, nil
+}
+
+// Queue returns a page of comments with the given moderation status,
+// newest first.
+func (s service) Queue(ctx context.Context, status string, page, limit int64) ([]*comment.Comment, int64, error) {
+	ctx, span := tracing.Start(ctx, "service.comment.Queue")
+	defer span.End()
+
+	if !comment.ValidStatus(status) {
+		return nil, 0, config.ErrInvalidModerationStatus
+	}
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = 50
+	}
+
+	return s.repo.ByStatus(ctx, status, page, limit)
+}
+
+// Moderate approves or rejects comments and teaches the classifier
+// from the decision. It returns how many comments were found.
+func (s service) Moderate(ctx context.Context, ids []string, status string) (int64, error) {
+	ctx, span := tracing.Start(ctx, "service.comment.Moderate")
+	defer span.End()
+
+	if status != comment.StatusApproved && status != comment.StatusRejected {
+		return 0, config.ErrInvalidModerationStatus
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	comments, err := s.repo.GetByIDs(ctx, ids)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := s.repo.SetStatus(ctx, ids, status, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	if s.classifier != nil {
+		for _, c := range comments {
+			if c.Status == status {
+				continue
+			}
+			// a lost lesson only makes the classifier slightly less sharp,
+			// and Train replays every decision on the next start anyway
+			_ = s.classifier.Learn(ctx, classifierText(c), status == comment.StatusRejected)
+		}
+	}
+
+	return n, nil
+}
+
+// Train replays recent moderation decisions into the classifier. The
+// built-in classifier keeps no state of its own, so this runs on start.
+func (s service) Train(ctx context.Context) (int, error) {
+	ctx, span := tracing.Start(ctx, "service.comment.Train")
+	defer span.End()
+
+	if s.classifier == nil {
+		return 0, nil
+	}
+
+	n := 0
+	err := s.repo.Moderated(ctx, trainingLimit, func(c *comment.Comment) error {
+		n++
+		return s.classifier.Learn(ctx, classifierText(c), c.Status == comment.StatusRejected)
+	})
+
+	return n, err
+}
+
+func classifierText(c *comment.Comment) string {
+	return c.Author + "\n" + c.Content
+}
+
+// bannedWord finds the first banned word or phrase in text. Single
+// words must match whole words, so "ass" does not catch "class".
+func bannedWord(text string, banned []string) (string, bool) {
+	if len(banned) == 0 {
+		return "", false
+	}
+
+	lower := strings.ToLower(text)
+	words := make(map[string]bool)
+	for _, t := range spam.Tokens(lower) {
+		words[t] = true
+	}
+
+	for _, b := range banned {
+		b = strings.ToLower(strings.TrimSpace(b))
+		switch {
+		case b == "":
+		case strings.Contains(b, " "):
+			if strings.Contains(lower, b) {
+				return b, true
+			}
+		case words[b]:
+			return b, true
+		}
+	}
+
+	return "", false
+}
+
+// identity keys returning commenters by email, or by name when they
+// left none. Anonymous comments have no identity.
+func identity(author, email string) string {
+	if email != "" {
+		return strings.ToLower(email)
+	}
+	if author != "" {
+		return "name:" + strings.ToLower(author)
+	}
+	return ""
+}
+
+// hashIP replaces a client address with a keyed hash, which is enough
+// to count comments per address without storing it.
+func (s service) hashIP(ip string) string {
+	if ip == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, s.salt)
+	mac.Write([]byte(ip))
+	return hex.EncodeToString(mac.Sum(nil)[:16])
+}
@@ -0,0 +1,209 @@
+package comment
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"news-svc/config"
+	"news-svc/internal/entity/comment"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClassifier struct {
+	score   float64
+	learned map[string]bool
+}
+
+func (f *fakeClassifier) Score(ctx context.Context, text string) (float64, error) {
+	return f.score, nil
+}
+
+func (f *fakeClassifier) Learn(ctx context.Context, text string, spam bool) error {
+	f.learned[text] = spam
+	return nil
+}
+
+var testRules = config.Comments{
+	MaxLinks:      2,
+	BannedWords:   []string{"casino", "buy now"},
+	HoldFirstTime: true,
+	RateLimit:     3,
+	RateWindow:    10 * time.Minute,
+	SpamThreshold: 0.9,
+	IPSalt:        "pepper",
+}
+
+// screened creates a comment against a repo where "known@example.com"
+// has been approved before and the given address has sent ipCount
+// comments recently.
+func screened(t *testing.T, c *comment.Comment, ip string, ipCount int64, score float64) *comment.Comment {
+	t.Helper()
+
+	var created *comment.Comment
+	svc := New(&mockRepo{
+		hasApprovedFn: func(ctx context.Context, identity string) (bool, error) {
+			return identity == "known@example.com", nil
+		},
+		countByIPSinceFn: func(ctx context.Context, ipHash string, since time.Time) (int64, error) {
+			assert.NotContains(t, ipHash, ip)
+			assert.WithinDuration(t, time.Now().Add(-10*time.Minute), since, time.Second)
+			return ipCount, nil
+		},
+		createFn: func(ctx context.Context, c *comment.Comment) (string, error) {
+			created = c
+			return "c1", nil
+		},
+	}, testPosts, testRules, &fakeClassifier{score: score})
+
+	c.PostID = publishedID
+	_, err := svc.Create(context.Background(), c, ip)
+	require.NoError(t, err)
+	return created
+}
+
+func TestScreenApproves(t *testing.T) {
+	c := screened(t, &comment.Comment{Email: "Known@Example.com", Content: "See https://a.example"}, "10.0.0.1", 0, 0.1)
+
+	assert.Equal(t, comment.StatusApproved, c.Status)
+	assert.Empty(t, c.HeldFor)
+	assert.Equal(t, 0.1, c.SpamScore)
+	assert.Equal(t, "known@example.com", c.Identity)
+	assert.NotEmpty(t, c.IPHash)
+	assert.NotContains(t, c.IPHash, "10.0.0.1")
+}
+
+func TestScreenHolds(t *testing.T) {
+	cases := map[string]struct {
+		c       comment.Comment
+		ipCount int64
+		score   float64
+		reason  string
+	}{
+		"links": {
+			c:      comment.Comment{Email: "known@example.com", Content: "http://a.example www.b.example https://c.example"},
+			reason: "3 links",
+		},
+		"banned word": {
+			c:      comment.Comment{Email: "known@example.com", Content: "Best CASINO in town"},
+			reason: `banned word "casino"`,
+		},
+		"banned phrase": {
+			c:      comment.Comment{Email: "known@example.com", Content: "Buy now!"},
+			reason: `banned word "buy now"`,
+		},
+		"first time": {
+			c:      comment.Comment{Email: "new@example.com", Content: "Hello"},
+			reason: "first comment",
+		},
+		"anonymous": {
+			c:      comment.Comment{Content: "Hello"},
+			reason: "first comment",
+		},
+		"rate": {
+			c:       comment.Comment{Email: "known@example.com", Content: "Hello"},
+			ipCount: 3,
+			reason:  "over 3 comments in 10m0s from one address",
+		},
+		"classifier": {
+			c:      comment.Comment{Email: "known@example.com", Content: "Hello"},
+			score:  0.95,
+			reason: "spam score 0.95",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			c := screened(t, &tc.c, "10.0.0.1", tc.ipCount, tc.score)
+
+			assert.Equal(t, comment.StatusPending, c.Status)
+			assert.Equal(t, []string{tc.reason}, c.HeldFor)
+		})
+	}
+}
+
+func TestBannedWordMatchesWholeWords(t *testing.T) {
+	_, ok := bannedWord("a classic", []string{"ass"})
+	assert.False(t, ok)
+
+	w, ok := bannedWord("you ass!", []string{"", "ASS"})
+	assert.True(t, ok)
+	assert.Equal(t, "ass", w)
+}
+
+func TestHashIPIsKeyed(t *testing.T) {
+	a := New(&mockRepo{}, testPosts, config.Comments{IPSalt: "one"}, nil)
+	b := New(&mockRepo{}, testPosts, config.Comments{IPSalt: "two"}, nil)
+
+	assert.Equal(t, a.hashIP("10.0.0.1"), a.hashIP("10.0.0.1"))
+	assert.NotEqual(t, a.hashIP("10.0.0.1"), b.hashIP("10.0.0.1"))
+	assert.Empty(t, a.hashIP(""))
+}
+
+func TestModerate(t *testing.T) {
+	classifier := &fakeClassifier{learned: map[string]bool{}}
+	svc := New(&mockRepo{
+		getByIDsFn: func(ctx context.Context, ids []string) ([]*comment.Comment, error) {
+			return []*comment.Comment{
+				{ID: "a", Author: "Bot", Content: "cheap pills", Status: comment.StatusPending},
+				{ID: "b", Content: "already rejected", Status: comment.StatusRejected},
+			}, nil
+		},
+		setStatusFn: func(ctx context.Context, ids []string, status string, at time.Time) (int64, error) {
+			assert.Equal(t, []string{"a", "b"}, ids)
+			assert.Equal(t, comment.StatusRejected, status)
+			return 2, nil
+		},
+	}, testPosts, testRules, classifier)
+
+	n, err := svc.Moderate(context.Background(), []string{"a", "b"}, comment.StatusRejected)
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(2), n)
+	assert.Equal(t, map[string]bool{"Bot\ncheap pills": true}, classifier.learned)
+
+	_, err = svc.Moderate(context.Background(), []string{"a"}, comment.StatusPending)
+	assert.ErrorIs(t, err, config.ErrInvalidModerationStatus)
+}
+
+func TestTrain(t *testing.T) {
+	classifier := &fakeClassifier{learned: map[string]bool{}}
+	svc := New(&mockRepo{
+		moderatedFn: func(ctx context.Context, limit int64, fn func(*comment.Comment) error) error {
+			assert.Equal(t, int64(trainingLimit), limit)
+			for _, c := range []*comment.Comment{
+				{Content: "spam", Status: comment.StatusRejected},
+				{Content: "ham", Status: comment.StatusApproved},
+			} {
+				if err := fn(c); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}, testPosts, testRules, classifier)
+
+	n, err := svc.Train(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, n)
+	assert.Equal(t, map[string]bool{"\nspam": true, "\nham": false}, classifier.learned)
+}
+
+func TestQueue(t *testing.T) {
+	svc := New(&mockRepo{
+		byStatusFn: func(ctx context.Context, status string, page, limit int64) ([]*comment.Comment, int64, error) {
+			assert.Equal(t, comment.StatusPending, status)
+			assert.Equal(t, int64(50), limit)
+			return nil, 0, nil
+		},
+	}, testPosts, testRules, nil)
+
+	_, _, err := svc.Queue(context.Background(), comment.StatusPending, 0, 0)
+	assert.NoError(t, err)
+
+	_, _, err = svc.Queue(context.Background(), "spam", 1, 10)
+	assert.ErrorIs(t, err, config.ErrInvalidModerationStatus)
+}
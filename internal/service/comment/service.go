@@ -0,0 +1,161 @@
+package comment
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"news-svc/config"
+	"news-svc/internal/entity/comment"
+	"news-svc/internal/entity/post"
+	"news-svc/pkg/metrics"
+	"news-svc/pkg/tracing"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Create adds a comment or, when ParentID is set, a reply. Only
+// published posts accept comments. The comment is approved right away
+// unless a moderation rule holds it as pending; ip is the client
+// address and only used in hashed form.
+func (s service) Create(ctx context.Context, c *comment.Comment, ip string) (string, error) {
+	ctx, span := tracing.Start(ctx, "service.comment.Create")
+	defer span.End()
+
+	c.Author = strings.TrimSpace(c.Author)
+	c.Email = strings.TrimSpace(c.Email)
+	c.Content = strings.TrimSpace(c.Content)
+	c.Status = ""
+	if err := c.Validate(); err != nil {
+		return "", err
+	}
+
+	if err := s.commentable(ctx, c.PostID); err != nil {
+		return "", err
+	}
+
+	c.RootID, c.Depth = "", 0
+	if c.ParentID != "" {
+		parent, err := s.repo.GetByID(ctx, c.ParentID)
+		if err != nil {
+			return "", err
+		}
+		if parent.PostID != c.PostID {
+			return "", config.ErrCommentNotFound
+		}
+
+		c.RootID = parent.RootID
+		if c.RootID == "" {
+			c.RootID = parent.ID
+		}
+		c.Depth = min(parent.Depth+1, comment.MaxDepth)
+	}
+
+	c.Identity = identity(c.Author, c.Email)
+	c.IPHash = s.hashIP(ip)
+
+	held, score, err := s.screen(ctx, c)
+	if err != nil {
+		return "", err
+	}
+	c.HeldFor, c.SpamScore = held, score
+	c.Status = comment.StatusApproved
+	if len(held) > 0 {
+		c.Status = comment.StatusPending
+	}
+
+	c.CreatedAt = time.Now()
+	id, err := s.repo.Create(ctx, c)
+	if err != nil {
+		return "", err
+	}
+
+	metrics.CountComment(c.Status)
+	return id, nil
+}
+
+func (s service) GetByID(ctx context.Context, id string) (*comment.Comment, error) {
+	ctx, span := tracing.Start(ctx, "service.comment.GetByID")
+	defer span.End()
+
+	return s.repo.GetByID(ctx, id)
+}
+
+// Threads returns a page of a post's top-level comments, each with the
+// first page of its replies, and the number of top-level comments.
+func (s service) Threads(ctx context.Context, postID string, page, limit int64) ([]*comment.Thread, int64, error) {
+	ctx, span := tracing.Start(ctx, "service.comment.Threads")
+	defer span.End()
+
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+
+	roots, total, err := s.repo.Roots(ctx, postID, page, limit)
+	if err != nil || len(roots) == 0 {
+		return nil, total, err
+	}
+
+	ids := make([]string, len(roots))
+	for i, c := range roots {
+		ids[i] = c.ID
+	}
+
+	replies, err := s.repo.FirstReplies(ctx, ids, comment.RepliesPerPage)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	threads := make([]*comment.Thread, len(roots))
+	for i, c := range roots {
+		t := replies[c.ID]
+		t.Root = c
+		threads[i] = &t
+	}
+
+	return threads, total, nil
+}
+
+// Replies returns a page of the replies in a thread, after those
+// loaded by Threads.
+func (s service) Replies(ctx context.Context, rootID string, page int64) ([]*comment.Comment, int64, error) {
+	ctx, span := tracing.Start(ctx, "service.comment.Replies")
+	defer span.End()
+
+	if page <= 0 {
+		page = 1
+	}
+
+	return s.repo.Replies(ctx, rootID, page, comment.RepliesPerPage)
+}
+
+// Counts returns the number of comments on each post.
+func (s service) Counts(ctx context.Context, postIDs []string) (map[string]int64, error) {
+	ctx, span := tracing.Start(ctx, "service.comment.Counts")
+	defer span.End()
+
+	if len(postIDs) == 0 {
+		return map[string]int64{}, nil
+	}
+
+	return s.repo.CountByPosts(ctx, postIDs)
+}
+
+func (s service) commentable(ctx context.Context, postID string) error {
+	// the post repository reports malformed IDs as driver errors
+	if _, err := bson.ObjectIDFromHex(postID); err != nil {
+		return config.ErrPostNotFound
+	}
+
+	p, err := s.posts.GetByID(ctx, postID)
+	if err != nil {
+		return err
+	}
+	if p.Status == post.StatusDraft {
+		return config.ErrPostNotFound
+	}
+	return nil
+}
@@ -0,0 +1,289 @@
+package comment
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"news-svc/config"
+	"news-svc/internal/entity/comment"
+	"news-svc/internal/entity/post"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type (
+	mockRepo struct {
+		createFn         func(ctx context.Context, c *comment.Comment) (string, error)
+		getByIDFn        func(ctx context.Context, id string) (*comment.Comment, error)
+		rootsFn          func(ctx context.Context, postID string, page, limit int64) ([]*comment.Comment, int64, error)
+		repliesFn        func(ctx context.Context, rootID string, page, limit int64) ([]*comment.Comment, int64, error)
+		firstRepliesFn   func(ctx context.Context, rootIDs []string, limit int64) (map[string]comment.Thread, error)
+		countByPostsFn   func(ctx context.Context, postIDs []string) (map[string]int64, error)
+		upsertImportedFn func(ctx context.Context, c *comment.Comment) error
+		getByIDsFn       func(ctx context.Context, ids []string) ([]*comment.Comment, error)
+		byStatusFn       func(ctx context.Context, status string, page, limit int64) ([]*comment.Comment, int64, error)
+		setStatusFn      func(ctx context.Context, ids []string, status string, at time.Time) (int64, error)
+		countByIPSinceFn func(ctx context.Context, ipHash string, since time.Time) (int64, error)
+		hasApprovedFn    func(ctx context.Context, identity string) (bool, error)
+		moderatedFn      func(ctx context.Context, limit int64, fn func(*comment.Comment) error) error
+	}
+
+	mockPosts struct {
+		posts map[string]*post.Post
+	}
+)
+
+func (m *mockRepo) Create(ctx context.Context, c *comment.Comment) (string, error) {
+	return m.createFn(ctx, c)
+}
+func (m *mockRepo) GetByID(ctx context.Context, id string) (*comment.Comment, error) {
+	return m.getByIDFn(ctx, id)
+}
+func (m *mockRepo) Roots(ctx context.Context, postID string, page, limit int64) ([]*comment.Comment, int64, error) {
+	return m.rootsFn(ctx, postID, page, limit)
+}
+func (m *mockRepo) Replies(ctx context.Context, rootID string, page, limit int64) ([]*comment.Comment, int64, error) {
+	return m.repliesFn(ctx, rootID, page, limit)
+}
+func (m *mockRepo) FirstReplies(ctx context.Context, rootIDs []string, limit int64) (map[string]comment.Thread, error) {
+	return m.firstRepliesFn(ctx, rootIDs, limit)
+}
+func (m *mockRepo) CountByPosts(ctx context.Context, postIDs []string) (map[string]int64, error) {
+	return m.countByPostsFn(ctx, postIDs)
+}
+func (m *mockRepo) UpsertImported(ctx context.Context, c *comment.Comment) error {
+	return m.upsertImportedFn(ctx, c)
+}
+
+func (m *mockRepo) GetByIDs(ctx context.Context, ids []string) ([]*comment.Comment, error) {
+	return m.getByIDsFn(ctx, ids)
+}
+func (m *mockRepo) ByStatus(ctx context.Context, status string, page, limit int64) ([]*comment.Comment, int64, error) {
+	return m.byStatusFn(ctx, status, page, limit)
+}
+func (m *mockRepo) SetStatus(ctx context.Context, ids []string, status string, at time.Time) (int64, error) {
+	return m.setStatusFn(ctx, ids, status, at)
+}
+func (m *mockRepo) CountByIPSince(ctx context.Context, ipHash string, since time.Time) (int64, error) {
+	return m.countByIPSinceFn(ctx, ipHash, since)
+}
+func (m *mockRepo) HasApproved(ctx context.Context, identity string) (bool, error) {
+	return m.hasApprovedFn(ctx, identity)
+}
+func (m *mockRepo) Moderated(ctx context.Context, limit int64, fn func(*comment.Comment) error) error {
+	return m.moderatedFn(ctx, limit, fn)
+}
+
+func (m mockPosts) GetByID(ctx context.Context, id string) (*post.Post, error) {
+	for _, p := range m.posts {
+		if p.ID == id {
+			return p, nil
+		}
+	}
+	return nil, config.ErrPostNotFound
+}
+func (m mockPosts) GetBySlug(ctx context.Context, slug string) (*post.Post, error) {
+	if p, ok := m.posts[slug]; ok {
+		return p, nil
+	}
+	return nil, config.ErrPostNotFound
+}
+
+var (
+	publishedID = bson.NewObjectID().Hex()
+	draftID     = bson.NewObjectID().Hex()
+
+	testPosts = mockPosts{posts: map[string]*post.Post{
+		"hello": {ID: publishedID, Slug: "hello", Status: post.StatusPublished},
+		"draft": {ID: draftID, Slug: "draft", Status: post.StatusDraft},
+	}}
+)
+
+func TestCreateTopLevel(t *testing.T) {
+	svc := New(&mockRepo{
+		createFn: func(ctx context.Context, c *comment.Comment) (string, error) {
+			assert.Equal(t, "Ann", c.Author)
+			assert.Equal(t, "Hi there", c.Content)
+			assert.Empty(t, c.RootID)
+			assert.Zero(t, c.Depth)
+			assert.False(t, c.CreatedAt.IsZero())
+			assert.Equal(t, comment.StatusApproved, c.Status)
+			assert.Equal(t, "name:ann", c.Identity)
+			return "c1", nil
+		},
+	}, testPosts, config.Comments{}, nil)
+
+	id, err := svc.Create(context.Background(), &comment.Comment{PostID: publishedID, Author: " Ann ", Content: "  Hi there\n"}, "")
+	require.NoError(t, err)
+	assert.Equal(t, "c1", id)
+}
+
+func TestCreateReply(t *testing.T) {
+	parents := map[string]*comment.Comment{
+		"root":  {ID: "root", PostID: publishedID},
+		"deep":  {ID: "deep", PostID: publishedID, RootID: "root", Depth: comment.MaxDepth},
+		"other": {ID: "other", PostID: draftID},
+	}
+	var created *comment.Comment
+	svc := New(&mockRepo{
+		getByIDFn: func(ctx context.Context, id string) (*comment.Comment, error) {
+			if c, ok := parents[id]; ok {
+				return c, nil
+			}
+			return nil, config.ErrCommentNotFound
+		},
+		createFn: func(ctx context.Context, c *comment.Comment) (string, error) {
+			created = c
+			return "new", nil
+		},
+	}, testPosts, config.Comments{}, nil)
+	ctx := context.Background()
+
+	_, err := svc.Create(ctx, &comment.Comment{PostID: publishedID, ParentID: "root", Content: "reply"}, "")
+	require.NoError(t, err)
+	assert.Equal(t, "root", created.RootID)
+	assert.Equal(t, 1, created.Depth)
+
+	_, err = svc.Create(ctx, &comment.Comment{PostID: publishedID, ParentID: "deep", Content: "reply"}, "")
+	require.NoError(t, err)
+	assert.Equal(t, "root", created.RootID)
+	assert.Equal(t, comment.MaxDepth, created.Depth)
+
+	_, err = svc.Create(ctx, &comment.Comment{PostID: publishedID, ParentID: "other", Content: "reply"}, "")
+	assert.ErrorIs(t, err, config.ErrCommentNotFound)
+
+	_, err = svc.Create(ctx, &comment.Comment{PostID: publishedID, ParentID: "missing", Content: "reply"}, "")
+	assert.ErrorIs(t, err, config.ErrCommentNotFound)
+}
+
+func TestCreateRejects(t *testing.T) {
+	svc := New(&mockRepo{}, testPosts, config.Comments{}, nil)
+	ctx := context.Background()
+
+	_, err := svc.Create(ctx, &comment.Comment{PostID: publishedID, Content: "   "}, "")
+	assert.ErrorIs(t, err, config.ErrEmptyComment)
+
+	_, err = svc.Create(ctx, &comment.Comment{PostID: draftID, Content: "hi"}, "")
+	assert.ErrorIs(t, err, config.ErrPostNotFound)
+
+	_, err = svc.Create(ctx, &comment.Comment{PostID: "nope", Content: "hi"}, "")
+	assert.ErrorIs(t, err, config.ErrPostNotFound)
+}
+
+func TestThreads(t *testing.T) {
+	svc := New(&mockRepo{
+		rootsFn: func(ctx context.Context, postID string, page, limit int64) ([]*comment.Comment, int64, error) {
+			assert.Equal(t, int64(1), page)
+			assert.Equal(t, int64(20), limit)
+			return []*comment.Comment{{ID: "a"}, {ID: "b"}}, 2, nil
+		},
+		firstRepliesFn: func(ctx context.Context, rootIDs []string, limit int64) (map[string]comment.Thread, error) {
+			assert.Equal(t, []string{"a", "b"}, rootIDs)
+			return map[string]comment.Thread{
+				"b": {Replies: []*comment.Comment{{ID: "b1"}}, ReplyCount: 12},
+			}, nil
+		},
+	}, testPosts, config.Comments{}, nil)
+
+	threads, total, err := svc.Threads(context.Background(), publishedID, 0, 0)
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(2), total)
+	require.Len(t, threads, 2)
+	assert.Equal(t, "a", threads[0].Root.ID)
+	assert.Empty(t, threads[0].Replies)
+	assert.False(t, threads[0].More())
+	assert.Equal(t, "b", threads[1].Root.ID)
+	assert.True(t, threads[1].More())
+}
+
+const wxrSample = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:wp="http://wordpress.org/export/1.2/">
+<channel>
+	<item>
+		<wp:post_name>hello</wp:post_name>
+		<wp:post_type>post</wp:post_type>
+		<wp:comment>
+			<wp:comment_id>9</wp:comment_id>
+			<wp:comment_content>Reply</wp:comment_content>
+			<wp:comment_approved>1</wp:comment_approved>
+			<wp:comment_parent>7</wp:comment_parent>
+		</wp:comment>
+		<wp:comment>
+			<wp:comment_id>7</wp:comment_id>
+			<wp:comment_author>Reader</wp:comment_author>
+			<wp:comment_date_gmt>2024-01-03 08:00:00</wp:comment_date_gmt>
+			<wp:comment_content>Nice</wp:comment_content>
+			<wp:comment_approved>1</wp:comment_approved>
+			<wp:comment_parent>0</wp:comment_parent>
+		</wp:comment>
+		<wp:comment>
+			<wp:comment_id>8</wp:comment_id>
+			<wp:comment_content>Buy now</wp:comment_content>
+			<wp:comment_approved>spam</wp:comment_approved>
+		</wp:comment>
+		<wp:comment>
+			<wp:comment_id>10</wp:comment_id>
+			<wp:comment_content></wp:comment_content>
+			<wp:comment_approved>1</wp:comment_approved>
+		</wp:comment>
+	</item>
+	<item>
+		<wp:post_name>about</wp:post_name>
+		<wp:post_type>page</wp:post_type>
+		<wp:comment>
+			<wp:comment_id>11</wp:comment_id>
+			<wp:comment_content>On a page</wp:comment_content>
+			<wp:comment_approved>1</wp:comment_approved>
+		</wp:comment>
+	</item>
+</channel>
+</rss>`
+
+func TestImportWXR(t *testing.T) {
+	var written []*comment.Comment
+	svc := New(&mockRepo{
+		upsertImportedFn: func(ctx context.Context, c *comment.Comment) error {
+			c.ID = c.ImportID
+			written = append(written, c)
+			return nil
+		},
+	}, testPosts, config.Comments{}, nil)
+
+	report, err := svc.ImportWXR(context.Background(), strings.NewReader(wxrSample))
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, report.Written)
+	assert.Equal(t, 2, report.Skipped)
+	assert.Equal(t, 1, report.Failed)
+	assert.Equal(t, int64(10), report.Errors[0].Comment)
+
+	require.Len(t, written, 2)
+	root, reply := written[0], written[1]
+	assert.Equal(t, "wxr:hello:7", root.ImportID)
+	assert.Equal(t, publishedID, root.PostID)
+	assert.Equal(t, "Reader", root.Author)
+	assert.Equal(t, comment.StatusApproved, root.Status)
+	assert.Equal(t, 2024, root.CreatedAt.Year())
+	assert.Equal(t, root.ID, reply.ParentID)
+	assert.Equal(t, root.ID, reply.RootID)
+	assert.Equal(t, 1, reply.Depth)
+}
+
+func TestImportWXRStopsOnRepoFailure(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	svc := New(&mockRepo{
+		upsertImportedFn: func(ctx context.Context, c *comment.Comment) error {
+			cancel()
+			return errors.New("connection lost")
+		},
+	}, testPosts, config.Comments{}, nil)
+
+	_, err := svc.ImportWXR(ctx, strings.NewReader(wxrSample))
+	assert.Error(t, err)
+}
@@ -0,0 +1,71 @@
+package comment
+
+import (
+	"context"
+	"crypto/rand"
+	"news-svc/config"
+	"news-svc/internal/entity/comment"
+	"news-svc/internal/entity/post"
+	"news-svc/pkg/spam"
+	"time"
+)
+
+type (
+	repository interface {
+		Create(ctx context.Context, c *comment.Comment) (string, error)
+		GetByID(ctx context.Context, id string) (*comment.Comment, error)
+		Roots(ctx context.Context, postID string, page, limit int64) ([]*comment.Comment, int64, error)
+		Replies(ctx context.Context, rootID string, page, limit int64) ([]*comment.Comment, int64, error)
+		FirstReplies(ctx context.Context, rootIDs []string, limit int64) (map[string]comment.Thread, error)
+		CountByPosts(ctx context.Context, postIDs []string) (map[string]int64, error)
+		UpsertImported(ctx context.Context, c *comment.Comment) error
+
+		GetByIDs(ctx context.Context, ids []string) ([]*comment.Comment, error)
+		ByStatus(ctx context.Context, status string, page, limit int64) ([]*comment.Comment, int64, error)
+		SetStatus(ctx context.Context, ids []string, status string, at time.Time) (int64, error)
+		CountByIPSince(ctx context.Context, ipHash string, since time.Time) (int64, error)
+		HasApproved(ctx context.Context, identity string) (bool, error)
+		Moderated(ctx context.Context, limit int64, fn func(*comment.Comment) error) error
+	}
+
+	// posts is the part of the post repository comments depend on.
+	posts interface {
+		GetByID(ctx context.Context, id string) (*post.Post, error)
+		GetBySlug(ctx context.Context, slug string) (*post.Post, error)
+	}
+
+	service struct {
+		repo       repository
+		posts      posts
+		rules      config.Comments
+		classifier spam.Classifier
+		salt       []byte
+	}
+
+	// ImportReport summarises a comment import.
+	ImportReport struct {
+		Written int           `json:"written"`
+		Failed  int           `json:"failed"`
+		Skipped int           `json:"skipped"`
+		Errors  []ImportError `json:"errors,omitempty"`
+	}
+
+	// ImportError identifies a comment that could not be imported.
+	ImportError struct {
+		Post    string `json:"post"`
+		Comment int64  `json:"comment"`
+		Err     string `json:"error"`
+	}
+)
+
+// New creates the comment service. classifier may be nil to moderate
+// by rules alone.
+func New(repo repository, posts posts, rules config.Comments, classifier spam.Classifier) service {
+	salt := []byte(rules.IPSalt)
+	if len(salt) == 0 {
+		salt = make([]byte, 32)
+		_, _ = rand.Read(salt)
+	}
+
+	return service{repo, posts, rules, classifier, salt}
+}
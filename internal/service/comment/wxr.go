@@ -0,0 +1,128 @@
+package comment
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"news-svc/config"
+	"news-svc/internal/entity/comment"
+	"news-svc/pkg/tracing"
+	"news-svc/pkg/wxr"
+)
+
+// ImportWXR imports the approved comments of a WordPress WXR export.
+// Posts must have been imported first; comments are matched to them by
+// slug. Each comment is keyed by post slug and WordPress comment ID, so
+// re-running an import updates comments in place.
+func (s service) ImportWXR(ctx context.Context, r io.Reader) (ImportReport, error) {
+	ctx, span := tracing.Start(ctx, "service.comment.ImportWXR")
+	defer span.End()
+
+	var report ImportReport
+	dec := wxr.NewDecoder(r)
+
+	for {
+		item, err := dec.Next()
+		if errors.Is(err, io.EOF) {
+			return report, nil
+		}
+		if err != nil {
+			return report, err
+		}
+		if len(item.Comments) == 0 {
+			continue
+		}
+
+		p, err := s.posts.GetBySlug(ctx, item.Slug())
+		if errors.Is(err, config.ErrPostNotFound) {
+			// pages, attachments and skipped posts
+			report.Skipped += len(item.Comments)
+			continue
+		}
+		if err != nil {
+			return report, err
+		}
+
+		if err := s.importThread(ctx, p.ID, item, &report); err != nil {
+			return report, err
+		}
+	}
+}
+
+// importThread writes the comments of one post. Parents are written
+// before their replies so that replies can point at them.
+func (s service) importThread(ctx context.Context, postID string, item *wxr.Item, report *ImportReport) error {
+	wpComments := slices.Clone(item.Comments)
+	slices.SortFunc(wpComments, func(a, b wxr.Comment) int { return cmp.Compare(a.ID, b.ID) })
+
+	imported := make(map[int64]*comment.Comment, len(wpComments))
+	for _, wc := range wpComments {
+		// unapproved, spam and trashed comments, pingbacks and trackbacks
+		if wc.Approved != "1" || (wc.Type != "" && wc.Type != "comment") {
+			report.Skipped++
+			continue
+		}
+
+		c := &comment.Comment{
+			PostID:    postID,
+			Author:    truncate(strings.TrimSpace(wc.Author), comment.AuthorMaxLen),
+			Email:     strings.TrimSpace(wc.AuthorEmail),
+			Content:   strings.TrimSpace(wc.Content),
+			ImportID:  fmt.Sprintf("wxr:%s:%d", item.Slug(), wc.ID),
+			CreatedAt: wc.Date(),
+			Status:    comment.StatusApproved,
+		}
+		if !comment.ValidEmail(c.Email) {
+			// WordPress does not insist on real addresses
+			c.Email = ""
+		}
+		c.Identity = identity(c.Author, c.Email)
+
+		// replies to comments that were not imported become top-level
+		if parent, ok := imported[wc.Parent]; ok {
+			c.ParentID = parent.ID
+			c.RootID = parent.RootID
+			if c.RootID == "" {
+				c.RootID = parent.ID
+			}
+			c.Depth = min(parent.Depth+1, comment.MaxDepth)
+		}
+
+		if err := c.Validate(); err != nil {
+			report.reject(item.Slug(), wc.ID, err)
+			continue
+		}
+
+		if err := s.repo.UpsertImported(ctx, c); err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
+			report.reject(item.Slug(), wc.ID, err)
+			continue
+		}
+
+		imported[wc.ID] = c
+		report.Written++
+	}
+
+	return nil
+}
+
+func (r *ImportReport) reject(slug string, id int64, err error) {
+	r.Failed++
+	r.Errors = append(r.Errors, ImportError{Post: slug, Comment: id, Err: err.Error()})
+}
+
+// truncate shortens s to at most n runes.
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
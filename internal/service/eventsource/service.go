@@ -0,0 +1,214 @@
+package eventsource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"news-svc/config"
+	"news-svc/internal/entity/event"
+	"news-svc/internal/entity/post"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Start begins reading changes in the background.
+func (s *service) Start() {
+	s.startOnce.Do(func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		s.cancel = cancel
+		s.started.Store(true)
+		go s.run(ctx)
+	})
+}
+
+// Check reports an error once the change reader has stopped. Nothing is
+// checked before Start.
+func (s *service) Check(ctx context.Context) error {
+	if !s.started.Load() {
+		return nil
+	}
+
+	select {
+	case <-s.done:
+		return config.ErrWorkerStopped
+	default:
+		return nil
+	}
+}
+
+// Close stops reading changes, or gives up waiting when ctx is done.
+func (s *service) Close(ctx context.Context) error {
+	if !s.started.Load() {
+		return nil
+	}
+	s.stopOnce.Do(s.cancel)
+
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *service) run(ctx context.Context) {
+	defer close(s.done)
+
+	var (
+		cursor event.Cursor
+		loaded bool
+		delay  = retryMin
+	)
+	for ctx.Err() == nil {
+		var err error
+		if !loaded {
+			cursor, err = s.repo.Cursor(ctx, s.cfg.Consumer)
+			loaded = err == nil
+		}
+		if err == nil {
+			err = s.watch(ctx, &cursor, &delay)
+		}
+
+		switch {
+		case ctx.Err() != nil:
+			return
+		case errors.Is(err, config.ErrChangeStreamsUnsupported):
+			s.l.Info("change streams unavailable, polling for post changes", "interval", s.cfg.PollInterval)
+			s.poll(ctx, &cursor)
+			return
+		case errors.Is(err, config.ErrResumeTokenLost):
+			s.l.Warn("saved change stream position is gone, some post changes were missed")
+			cursor.Token = nil
+			continue
+		}
+
+		s.l.Error("watch post changes", "err", err, "retry_in", delay)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return
+		}
+		delay = min(2*delay, retryMax)
+	}
+}
+
+// watch publishes changes from the change stream and saves the position
+// after each, so a restart picks up where it stopped.
+func (s *service) watch(ctx context.Context, cursor *event.Cursor, delay *time.Duration) error {
+	return s.repo.Watch(ctx, cursor.Token, func(e event.Event, token bson.Raw) error {
+		*delay = retryMin
+		s.bus.Publish(e)
+
+		cursor.Token = token
+		return s.repo.SaveCursor(ctx, *cursor)
+	})
+}
+
+func (s *service) poll(ctx context.Context, cursor *event.Cursor) {
+	if cursor.At.IsZero() {
+		cursor.At = s.now()
+	}
+
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.pollOnce(ctx, cursor); err != nil && ctx.Err() == nil {
+				s.l.Error("poll post changes", "err", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// pollOnce publishes the changes and deletions since the cursor, oldest
+// first. Posts changed at exactly the cursor time were published by the
+// previous poll unless they are new, which Seen tells apart.
+func (s *service) pollOnce(ctx context.Context, cursor *event.Cursor) error {
+	changed, err := s.posts.ChangedSince(ctx, cursor.At, pollBatch)
+	if err != nil {
+		return err
+	}
+	deleted, err := s.posts.DeletedSince(ctx, cursor.At, pollBatch)
+	if err != nil {
+		return err
+	}
+
+	var events []event.Event
+	for _, p := range changed {
+		// a post's previous status is gone by now, so only new posts
+		// can be told to have been published
+		e := event.Event{Type: event.PostUpdated, PostID: p.ID, Post: p, At: p.UpdatedAt}
+		if p.CreatedAt.Equal(p.UpdatedAt) {
+			e.Type = event.PostCreated
+			e.Published = p.Status == post.StatusPublished
+		}
+		e.ID = fmt.Sprintf("%s:%s:%d", e.Type, p.ID, p.UpdatedAt.UnixMilli())
+		events = append(events, e)
+	}
+	for id, at := range deleted {
+		events = append(events, event.Event{
+			ID:     fmt.Sprintf("%s:%s", event.PostDeleted, id),
+			Type:   event.PostDeleted,
+			PostID: id,
+			At:     at,
+		})
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		if !events[i].At.Equal(events[j].At) {
+			return events[i].At.Before(events[j].At)
+		}
+		return events[i].PostID < events[j].PostID
+	})
+
+	// a full batch may end before the other list does; what lies beyond
+	// its end is left for the next poll so nothing is skipped
+	var cutoff time.Time
+	limit := func(end time.Time) {
+		if cutoff.IsZero() || end.Before(cutoff) {
+			cutoff = end
+		}
+	}
+	if len(changed) == pollBatch {
+		limit(changed[len(changed)-1].UpdatedAt)
+	}
+	if len(deleted) == pollBatch {
+		var end time.Time
+		for _, at := range deleted {
+			if at.After(end) {
+				end = at
+			}
+		}
+		limit(end)
+	}
+
+	at, seen := cursor.At, cursor.Seen
+	for _, e := range events {
+		if !cutoff.IsZero() && e.At.After(cutoff) {
+			break
+		}
+		if e.At.Equal(cursor.At) && slices.Contains(cursor.Seen, e.PostID) {
+			continue
+		}
+
+		s.bus.Publish(e)
+
+		if e.At.After(at) {
+			at, seen = e.At, nil
+		}
+		seen = append(seen, e.PostID)
+	}
+
+	if at.Equal(cursor.At) && len(seen) == len(cursor.Seen) {
+		return nil
+	}
+	cursor.At, cursor.Seen = at, seen
+	return s.repo.SaveCursor(ctx, *cursor)
+}
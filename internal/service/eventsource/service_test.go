@@ -0,0 +1,201 @@
+package eventsource
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"news-svc/config"
+	"news-svc/internal/entity/event"
+	"news-svc/internal/entity/post"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var t0 = time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+
+type (
+	memRepo struct {
+		mu     sync.Mutex
+		cursor event.Cursor
+		saves  int
+		// watch is called for each Watch with the token it resumes from
+		watch func(ctx context.Context, token bson.Raw, fn func(event.Event, bson.Raw) error) error
+	}
+
+	memPosts struct {
+		changed []*post.Post
+		deleted map[string]time.Time
+	}
+
+	memBus struct {
+		mu     sync.Mutex
+		events []event.Event
+	}
+)
+
+func (m *memRepo) Cursor(ctx context.Context, consumer string) (event.Cursor, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c := m.cursor
+	c.Consumer = consumer
+	return c, nil
+}
+func (m *memRepo) SaveCursor(ctx context.Context, c event.Cursor) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.cursor = c
+	m.saves++
+	return nil
+}
+func (m *memRepo) Watch(ctx context.Context, token bson.Raw, fn func(event.Event, bson.Raw) error) error {
+	return m.watch(ctx, token, fn)
+}
+func (m *memRepo) saved() event.Cursor {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.cursor
+}
+
+func (m *memPosts) ChangedSince(ctx context.Context, since time.Time, limit int64) ([]*post.Post, error) {
+	var posts []*post.Post
+	for _, p := range m.changed {
+		if !p.UpdatedAt.Before(since) && int64(len(posts)) < limit {
+			posts = append(posts, p)
+		}
+	}
+	return posts, nil
+}
+func (m *memPosts) DeletedSince(ctx context.Context, since time.Time, limit int64) (map[string]time.Time, error) {
+	deleted := map[string]time.Time{}
+	for id, at := range m.deleted {
+		if !at.Before(since) {
+			deleted[id] = at
+		}
+	}
+	return deleted, nil
+}
+
+func (m *memBus) Publish(e event.Event) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.events = append(m.events, e)
+}
+func (m *memBus) summary() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var s []string
+	for _, e := range m.events {
+		s = append(s, e.Type+" "+e.PostID)
+	}
+	return s
+}
+
+func newService(repo *memRepo, posts *memPosts) (*service, *memBus) {
+	bus := &memBus{}
+	cfg := config.Events{Source: "mongo", Consumer: "web-1", PollInterval: 10 * time.Millisecond}
+	s := New(repo, posts, bus, cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	s.now = func() time.Time { return t0 }
+	return s, bus
+}
+
+func changedPost(id string, created, updated time.Time) *post.Post {
+	return &post.Post{ID: id, CreatedAt: created, UpdatedAt: updated}
+}
+
+func TestWatchPublishesAndSavesPosition(t *testing.T) {
+	var tokens []string
+	repo := &memRepo{cursor: event.Cursor{Token: bson.Raw("saved")}}
+	repo.watch = func(ctx context.Context, token bson.Raw, fn func(event.Event, bson.Raw) error) error {
+		tokens = append(tokens, string(token))
+		if len(tokens) == 1 {
+			// the saved position is too old to resume from
+			return config.ErrResumeTokenLost
+		}
+		require.NoError(t, fn(event.Event{Type: event.PostCreated, PostID: "a"}, bson.Raw("t1")))
+		require.NoError(t, fn(event.Event{Type: event.PostDeleted, PostID: "b"}, bson.Raw("t2")))
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	s, bus := newService(repo, &memPosts{})
+
+	s.Start()
+	require.Eventually(t, func() bool { return len(bus.summary()) == 2 }, time.Second, time.Millisecond)
+	require.NoError(t, s.Close(context.Background()))
+
+	assert.Equal(t, []string{"saved", ""}, tokens)
+	assert.Equal(t, []string{"post.created a", "post.deleted b"}, bus.summary())
+	assert.Equal(t, event.Cursor{Consumer: "web-1", Token: bson.Raw("t2")}, repo.saved())
+}
+
+func TestFallsBackToPolling(t *testing.T) {
+	repo := &memRepo{}
+	repo.watch = func(ctx context.Context, token bson.Raw, fn func(event.Event, bson.Raw) error) error {
+		return config.ErrChangeStreamsUnsupported
+	}
+	posts := &memPosts{changed: []*post.Post{changedPost("a", t0, t0.Add(time.Second))}}
+	s, bus := newService(repo, posts)
+
+	s.Start()
+	require.Eventually(t, func() bool { return len(bus.summary()) == 1 }, time.Second, time.Millisecond)
+	require.NoError(t, s.Close(context.Background()))
+
+	assert.Equal(t, []string{"post.updated a"}, bus.summary())
+	assert.Equal(t, t0.Add(time.Second), repo.saved().At)
+}
+
+func TestPollOnce(t *testing.T) {
+	repo := &memRepo{}
+	posts := &memPosts{
+		changed: []*post.Post{
+			changedPost("new", t0.Add(time.Second), t0.Add(time.Second)),
+			changedPost("edited", t0.Add(-time.Hour), t0.Add(2*time.Second)),
+		},
+		deleted: map[string]time.Time{"gone": t0.Add(2 * time.Second)},
+	}
+	s, bus := newService(repo, posts)
+	cursor := &event.Cursor{Consumer: "web-1", At: t0}
+	ctx := context.Background()
+
+	require.NoError(t, s.pollOnce(ctx, cursor))
+	assert.Equal(t, []string{"post.created new", "post.updated edited", "post.deleted gone"}, bus.summary())
+	assert.Equal(t, "post.created:new:"+fmt.Sprint(t0.Add(time.Second).UnixMilli()), bus.events[0].ID)
+	assert.Equal(t, "post.deleted:gone", bus.events[2].ID)
+	assert.Equal(t, t0.Add(2*time.Second), cursor.At)
+	assert.Equal(t, []string{"edited", "gone"}, cursor.Seen)
+	assert.Equal(t, *cursor, repo.saved())
+
+	// nothing new: changes at the cursor time are not published again
+	require.NoError(t, s.pollOnce(ctx, cursor))
+	assert.Len(t, bus.summary(), 3)
+	assert.Equal(t, 1, repo.saves)
+
+	// written in the same millisecond after the last poll
+	posts.changed = append(posts.changed, changedPost("late", t0.Add(2*time.Second), t0.Add(2*time.Second)))
+	require.NoError(t, s.pollOnce(ctx, cursor))
+	assert.Equal(t, "post.created late", bus.summary()[3])
+	assert.Equal(t, []string{"edited", "gone", "late"}, cursor.Seen)
+}
+
+func TestPollOnceStopsAtFullBatch(t *testing.T) {
+	posts := &memPosts{deleted: map[string]time.Time{"gone": t0.Add(time.Hour)}}
+	for i := range pollBatch + 1 {
+		at := t0.Add(time.Duration(i) * time.Second)
+		posts.changed = append(posts.changed, changedPost(fmt.Sprintf("p%03d", i), at, at))
+	}
+	s, bus := newService(&memRepo{}, posts)
+	cursor := &event.Cursor{Consumer: "web-1", At: t0}
+
+	require.NoError(t, s.pollOnce(context.Background(), cursor))
+	assert.Len(t, bus.summary(), pollBatch)
+	assert.NotContains(t, bus.summary(), "post.deleted gone")
+
+	require.NoError(t, s.pollOnce(context.Background(), cursor))
+	assert.Equal(t, []string{"post.created p500", "post.deleted gone"}, bus.summary()[pollBatch:])
+}
@@ -0,0 +1,71 @@
+package eventsource
+
+import (
+	"context"
+	"log/slog"
+	"news-svc/config"
+	"news-svc/internal/entity/event"
+	"news-svc/internal/entity/post"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const (
+	// pollBatch caps the changes and the deletions read per poll.
+	pollBatch = 500
+	// retryMin and retryMax bound the wait before watching again after
+	// an error, doubling each time in between.
+	retryMin = time.Second
+	retryMax = 30 * time.Second
+)
+
+type (
+	repository interface {
+		Cursor(ctx context.Context, consumer string) (event.Cursor, error)
+		SaveCursor(ctx context.Context, c event.Cursor) error
+		Watch(ctx context.Context, token bson.Raw, fn func(e event.Event, token bson.Raw) error) error
+	}
+
+	// posts is what polling reads changes from.
+	posts interface {
+		ChangedSince(ctx context.Context, since time.Time, limit int64) ([]*post.Post, error)
+		DeletedSince(ctx context.Context, since time.Time, limit int64) (map[string]time.Time, error)
+	}
+
+	publisher interface {
+		Publish(e event.Event)
+	}
+
+	// service feeds post changes made by any instance into the event
+	// bus, from a change stream or, on standalone servers, by polling.
+	service struct {
+		repo  repository
+		posts posts
+		bus   publisher
+		cfg   config.Events
+		l     *slog.Logger
+		now   func() time.Time
+
+		cancel    context.CancelFunc
+		done      chan struct{}
+		startOnce sync.Once
+		stopOnce  sync.Once
+		started   atomic.Bool
+	}
+)
+
+func New(repo repository, posts posts, bus publisher, cfg config.Events, l *slog.Logger) *service {
+	return &service{
+		repo:   repo,
+		posts:  posts,
+		bus:    bus,
+		cfg:    cfg,
+		l:      l,
+		now:    time.Now,
+		cancel: func() {},
+		done:   make(chan struct{}),
+	}
+}
@@ -0,0 +1,114 @@
+package media
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"image/png"
+
+	"news-svc/config"
+	"news-svc/internal/entity/media"
+	"news-svc/pkg/imaging"
+)
+
+const (
+	// maxPixels bounds the decoded size of an upload. A small file can
+	// declare huge dimensions, and decoding allocates 4 bytes per pixel.
+	maxPixels = 50_000_000
+
+	jpegQuality = 85
+)
+
+type (
+	// processed holds everything that gets written to the blob store for
+	// one image.
+	processed struct {
+		original []byte
+		width    int
+		height   int
+		variants []variant
+	}
+
+	variant struct {
+		media.Variant
+		data []byte
+	}
+)
+
+// process strips location and other metadata from an image, turns it
+// upright and renders the resized variants. GIFs are only checked, as
+// resizing would drop their animation.
+func process(data []byte, contentType string) (*processed, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, config.ErrUnsupportedMediaType
+	}
+	if cfg.Width*cfg.Height > maxPixels {
+		return nil, config.ErrMediaTooLarge
+	}
+
+	p := &processed{width: cfg.Width, height: cfg.Height}
+	if contentType == "image/gif" {
+		p.original = data
+		return p, nil
+	}
+
+	orientation := imaging.Orientation(data)
+
+	p.original, err = imaging.StripMetadata(data)
+	if err != nil {
+		return nil, config.ErrUnsupportedMediaType
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, config.ErrUnsupportedMediaType
+	}
+
+	// Without its Exif block a sideways photo would display sideways, so
+	// the rotation is baked into the pixels instead.
+	if orientation > 1 {
+		img = imaging.Orient(img, orientation)
+		if p.original, err = encode(img, contentType); err != nil {
+			return nil, err
+		}
+		p.width, p.height = img.Bounds().Dx(), img.Bounds().Dy()
+	}
+
+	for _, size := range media.Sizes {
+		if size.Width >= p.width {
+			break
+		}
+
+		resized := imaging.Resize(img, size.Width)
+		out, err := encode(resized, contentType)
+		if err != nil {
+			return nil, err
+		}
+
+		p.variants = append(p.variants, variant{
+			Variant: media.Variant{
+				Name:   size.Name,
+				Width:  resized.Bounds().Dx(),
+				Height: resized.Bounds().Dy(),
+				Size:   int64(len(out)),
+			},
+			data: out,
+		})
+	}
+
+	return p, nil
+}
+
+func encode(img image.Image, contentType string) ([]byte, error) {
+	var buf bytes.Buffer
+
+	var err error
+	if contentType == "image/jpeg" {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality})
+	} else {
+		err = png.Encode(&buf, img)
+	}
+
+	return buf.Bytes(), err
+}
@@ -0,0 +1,232 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	_ "image/gif"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"news-svc/config"
+	"news-svc/internal/entity/media"
+	"news-svc/pkg/tracing"
+)
+
+// allowedTypes maps accepted sniffed content types to file extensions.
+var allowedTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+// Upload validates and stores a new image together with its resized
+// variants. The content type is sniffed from the bytes rather than
+// trusted from the client.
+func (s service) Upload(ctx context.Context, filename string, r io.Reader) (*media.Media, error) {
+	ctx, span := tracing.Start(ctx, "service.media.Upload")
+	defer span.End()
+
+	data, err := io.ReadAll(io.LimitReader(r, s.maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > s.maxSize {
+		return nil, config.ErrMediaTooLarge
+	}
+
+	contentType := http.DetectContentType(data)
+	if _, ok := allowedTypes[contentType]; !ok {
+		return nil, config.ErrUnsupportedMediaType
+	}
+
+	p, err := process(data, contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := randomKey()
+	if err != nil {
+		return nil, err
+	}
+
+	m := &media.Media{
+		Filename:    cleanFilename(filename),
+		ContentType: contentType,
+		Key:         dir + "/" + media.Original + allowedTypes[contentType],
+		CreatedAt:   time.Now(),
+	}
+
+	if err := s.store(ctx, m, p); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.repo.Create(ctx, m); err != nil {
+		// best effort; orphaned blobs are harmless
+		s.deleteBlobs(ctx, m)
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// Reprocess regenerates the variants of an existing image and strips
+// its metadata, e.g. for files uploaded before variants existed.
+func (s service) Reprocess(ctx context.Context, id string) error {
+	ctx, span := tracing.Start(ctx, "service.media.Reprocess")
+	defer span.End()
+
+	m, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	rc, err := s.blobs.Open(ctx, m.Key)
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		return err
+	}
+
+	p, err := process(data, m.ContentType)
+	if err != nil {
+		return err
+	}
+
+	if err := s.store(ctx, m, p); err != nil {
+		return err
+	}
+
+	return s.repo.UpdateFiles(ctx, m)
+}
+
+// store writes the original and the variants next to m.Key and records
+// them on m.
+func (s service) store(ctx context.Context, m *media.Media, p *processed) error {
+	m.Size = int64(len(p.original))
+	m.Width, m.Height = p.width, p.height
+	m.Variants = nil
+
+	if err := s.blobs.Put(ctx, m.Key, bytes.NewReader(p.original)); err != nil {
+		return err
+	}
+
+	dir, ext := path.Dir(m.Key), path.Ext(m.Key)
+	for _, v := range p.variants {
+		v.Key = dir + "/" + v.Name + ext
+		if err := s.blobs.Put(ctx, v.Key, bytes.NewReader(v.data)); err != nil {
+			s.deleteBlobs(ctx, m)
+			return err
+		}
+		m.Variants = append(m.Variants, v.Variant)
+	}
+
+	return nil
+}
+
+// deleteBlobs removes the original and every variant of m, ignoring
+// failures.
+func (s service) deleteBlobs(ctx context.Context, m *media.Media) {
+	_ = s.blobs.Delete(ctx, m.Key)
+	for _, v := range m.Variants {
+		_ = s.blobs.Delete(ctx, v.Key)
+	}
+}
+
+func (s service) GetByID(ctx context.Context, id string) (*media.Media, error) {
+	ctx, span := tracing.Start(ctx, "service.media.GetByID")
+	defer span.End()
+
+	return s.repo.GetByID(ctx, id)
+}
+
+// Open returns the metadata of a file and the content of the named
+// variant ("" for the original), see media.Media.File. The caller must
+// close the reader.
+func (s service) Open(ctx context.Context, id, variant string) (*media.Media, io.ReadCloser, error) {
+	ctx, span := tracing.Start(ctx, "service.media.Open")
+	defer span.End()
+
+	m, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	file, ok := m.File(variant)
+	if !ok {
+		return nil, nil, config.ErrMediaNotFound
+	}
+
+	rc, err := s.blobs.Open(ctx, file.Key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return m, rc, nil
+}
+
+func (s service) GetAll(ctx context.Context, page, limit int64) ([]*media.Media, int64, error) {
+	ctx, span := tracing.Start(ctx, "service.media.GetAll")
+	defer span.End()
+
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = 24
+	}
+
+	return s.repo.GetAll(ctx, page, limit)
+}
+
+// Delete removes the metadata first so the file disappears from the
+// library even if removing the blob fails.
+func (s service) Delete(ctx context.Context, id string) error {
+	ctx, span := tracing.Start(ctx, "service.media.Delete")
+	defer span.End()
+
+	m, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	if err := s.blobs.Delete(ctx, m.Key); err != nil {
+		return err
+	}
+	for _, v := range m.Variants {
+		if err := s.blobs.Delete(ctx, v.Key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func randomKey() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// cleanFilename keeps only the base name of a client-supplied filename;
+// browsers on some platforms send full paths.
+func cleanFilename(name string) string {
+	name = path.Base(strings.ReplaceAll(name, `\`, "/"))
+	if name == "." || name == "/" {
+		return "upload"
+	}
+	return name
+}
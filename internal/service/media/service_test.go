@@ -0,0 +1,255 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+
+	"news-svc/config"
+	"news-svc/internal/entity/media"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type (
+	mockRepo struct {
+		createFn  func(ctx context.Context, m *media.Media) (string, error)
+		getByIDFn func(ctx context.Context, id string) (*media.Media, error)
+		getAllFn  func(ctx context.Context, page, limit int64) ([]*media.Media, int64, error)
+		updateFn  func(ctx context.Context, m *media.Media) error
+		deleteFn  func(ctx context.Context, id string) error
+	}
+
+	memBlobs map[string][]byte
+)
+
+func (m *mockRepo) Create(ctx context.Context, md *media.Media) (string, error) {
+	return m.createFn(ctx, md)
+}
+func (m *mockRepo) GetByID(ctx context.Context, id string) (*media.Media, error) {
+	return m.getByIDFn(ctx, id)
+}
+func (m *mockRepo) GetAll(ctx context.Context, page, limit int64) ([]*media.Media, int64, error) {
+	return m.getAllFn(ctx, page, limit)
+}
+func (m *mockRepo) UpdateFiles(ctx context.Context, md *media.Media) error {
+	return m.updateFn(ctx, md)
+}
+func (m *mockRepo) Delete(ctx context.Context, id string) error {
+	return m.deleteFn(ctx, id)
+}
+
+func (b memBlobs) Put(ctx context.Context, key string, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	b[key] = data
+	return err
+}
+func (b memBlobs) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	return io.NopCloser(bytes.NewReader(b[key])), nil
+}
+func (b memBlobs) Delete(ctx context.Context, key string) error {
+	delete(b, key)
+	return nil
+}
+
+func pngBytes(t *testing.T, w, h int) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, w, h))))
+	return buf.Bytes()
+}
+
+func TestUploadSuccess(t *testing.T) {
+	blobs := memBlobs{}
+	svc := New(&mockRepo{
+		createFn: func(ctx context.Context, m *media.Media) (string, error) {
+			m.ID = "m1"
+			return m.ID, nil
+		},
+	}, blobs, 1<<20)
+
+	m, err := svc.Upload(context.Background(), `C:\Users\me\cat.png`, bytes.NewReader(pngBytes(t, 3, 2)))
+	require.NoError(t, err)
+
+	assert.Equal(t, "m1", m.ID)
+	assert.Equal(t, "cat.png", m.Filename)
+	assert.Equal(t, "image/png", m.ContentType)
+	assert.Equal(t, 3, m.Width)
+	assert.Equal(t, 2, m.Height)
+	assert.True(t, strings.HasSuffix(m.Key, "/original.png"))
+	assert.Contains(t, blobs, m.Key)
+}
+
+func TestUploadRejects(t *testing.T) {
+	svc := New(&mockRepo{}, memBlobs{}, 64)
+
+	_, err := svc.Upload(context.Background(), "a.png", bytes.NewReader(pngBytes(t, 100, 100)))
+	assert.ErrorIs(t, err, config.ErrMediaTooLarge)
+
+	_, err = svc.Upload(context.Background(), "a.png", strings.NewReader("<html><script>x</script></html>"))
+	assert.ErrorIs(t, err, config.ErrUnsupportedMediaType)
+}
+
+func TestUploadRemovesBlobOnRepoError(t *testing.T) {
+	blobs := memBlobs{}
+	svc := New(&mockRepo{
+		createFn: func(ctx context.Context, m *media.Media) (string, error) {
+			return "", errors.New("fail")
+		},
+	}, blobs, 1<<20)
+
+	_, err := svc.Upload(context.Background(), "a.png", bytes.NewReader(pngBytes(t, 1, 1)))
+	assert.Error(t, err)
+	assert.Empty(t, blobs)
+}
+
+func TestDelete(t *testing.T) {
+	blobs := memBlobs{"k/original.png": []byte("x"), "k/thumb.png": []byte("y")}
+	deleted := false
+	svc := New(&mockRepo{
+		getByIDFn: func(ctx context.Context, id string) (*media.Media, error) {
+			return &media.Media{
+				ID: id, Key: "k/original.png",
+				Variants: []media.Variant{{Name: "thumb", Key: "k/thumb.png"}},
+			}, nil
+		},
+		deleteFn: func(ctx context.Context, id string) error {
+			deleted = true
+			return nil
+		},
+	}, blobs, 1<<20)
+
+	require.NoError(t, svc.Delete(context.Background(), "m1"))
+	assert.True(t, deleted)
+	assert.Empty(t, blobs)
+}
+
+func TestGetAllDefaults(t *testing.T) {
+	svc := New(&mockRepo{
+		getAllFn: func(ctx context.Context, page, limit int64) ([]*media.Media, int64, error) {
+			assert.Equal(t, int64(1), page)
+			assert.Equal(t, int64(24), limit)
+			return nil, 0, nil
+		},
+	}, memBlobs{}, 1<<20)
+
+	_, _, err := svc.GetAll(context.Background(), 0, 0)
+	assert.NoError(t, err)
+}
+
+// jpegBytes encodes a w×h image with an Exif block holding the given
+// orientation and some stand-in location data.
+func jpegBytes(t *testing.T, w, h int, orientation uint16) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, w, h)), nil))
+
+	tiff := []byte("MM\x00*\x00\x00\x00\x08\x00\x01\x01\x12\x00\x03\x00\x00\x00\x01")
+	tiff = binary.BigEndian.AppendUint16(tiff, orientation)
+	tiff = append(tiff, 0, 0, 0, 0, 0, 0)
+	tiff = append(tiff, "GPSLatitude"...)
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+
+	out := []byte{0xFF, 0xD8, 0xFF, 0xE1}
+	out = binary.BigEndian.AppendUint16(out, uint16(len(payload)+2))
+	out = append(out, payload...)
+	return append(out, buf.Bytes()[2:]...)
+}
+
+func TestUploadVariants(t *testing.T) {
+	blobs := memBlobs{}
+	svc := New(&mockRepo{
+		createFn: func(ctx context.Context, m *media.Media) (string, error) {
+			m.ID = "m1"
+			return m.ID, nil
+		},
+	}, blobs, 10<<20)
+
+	m, err := svc.Upload(context.Background(), "photo.jpg", bytes.NewReader(jpegBytes(t, 1000, 500, 1)))
+	require.NoError(t, err)
+
+	// 1000px wide: thumb and medium, but no upscaled large
+	require.Len(t, m.Variants, 2)
+	assert.Equal(t, "thumb", m.Variants[0].Name)
+	assert.Equal(t, 320, m.Variants[0].Width)
+	assert.Equal(t, 160, m.Variants[0].Height)
+	assert.Equal(t, "medium", m.Variants[1].Name)
+	assert.Equal(t, 768, m.Variants[1].Width)
+	assert.Len(t, blobs, 3)
+
+	for key, data := range blobs {
+		assert.NotContains(t, string(data), "GPSLatitude", key)
+		_, err := jpeg.Decode(bytes.NewReader(data))
+		assert.NoError(t, err, key)
+	}
+	assert.Equal(t, int64(len(blobs[m.Key])), m.Size)
+}
+
+func TestUploadAppliesOrientation(t *testing.T) {
+	blobs := memBlobs{}
+	svc := New(&mockRepo{
+		createFn: func(ctx context.Context, m *media.Media) (string, error) {
+			return "m1", nil
+		},
+	}, blobs, 10<<20)
+
+	// orientation 6: stored landscape, displayed portrait
+	m, err := svc.Upload(context.Background(), "photo.jpg", bytes.NewReader(jpegBytes(t, 400, 200, 6)))
+	require.NoError(t, err)
+
+	assert.Equal(t, 200, m.Width)
+	assert.Equal(t, 400, m.Height)
+
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(blobs[m.Key]))
+	require.NoError(t, err)
+	assert.Equal(t, 200, cfg.Width)
+	assert.Empty(t, m.Variants)
+}
+
+func TestUploadRejectsHugeDimensions(t *testing.T) {
+	svc := New(&mockRepo{}, memBlobs{}, 10<<20)
+
+	// a tiny PNG header claiming 10000x10000 pixels
+	_, err := svc.Upload(context.Background(), "bomb.png", bytes.NewReader(pngHeader(10000, 10000)))
+	assert.ErrorIs(t, err, config.ErrMediaTooLarge)
+}
+
+func pngHeader(w, h uint32) []byte {
+	var buf bytes.Buffer
+	_ = png.Encode(&buf, image.NewGray(image.Rect(0, 0, 1, 1)))
+	data := buf.Bytes()
+	binary.BigEndian.PutUint32(data[16:], w)
+	binary.BigEndian.PutUint32(data[20:], h)
+	binary.BigEndian.PutUint32(data[29:], crc32.ChecksumIEEE(data[12:29]))
+	return data
+}
+
+func TestReprocess(t *testing.T) {
+	blobs := memBlobs{"k/original.jpg": jpegBytes(t, 800, 400, 1)}
+	var updated *media.Media
+	svc := New(&mockRepo{
+		getByIDFn: func(ctx context.Context, id string) (*media.Media, error) {
+			return &media.Media{ID: id, ContentType: "image/jpeg", Key: "k/original.jpg"}, nil
+		},
+		updateFn: func(ctx context.Context, m *media.Media) error {
+			updated = m
+			return nil
+		},
+	}, blobs, 10<<20)
+
+	require.NoError(t, svc.Reprocess(context.Background(), "m1"))
+
+	require.NotNil(t, updated)
+	assert.Equal(t, 800, updated.Width)
+	require.Len(t, updated.Variants, 2)
+	assert.Equal(t, "k/thumb.jpg", updated.Variants[0].Key)
+	assert.Contains(t, blobs, "k/medium.jpg")
+	assert.NotContains(t, string(blobs["k/original.jpg"]), "GPSLatitude")
+}
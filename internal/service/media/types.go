@@ -0,0 +1,33 @@
+package media
+
+import (
+	"context"
+	"io"
+	"news-svc/internal/entity/media"
+)
+
+type (
+	repository interface {
+		Create(ctx context.Context, m *media.Media) (string, error)
+		GetByID(ctx context.Context, id string) (*media.Media, error)
+		GetAll(ctx context.Context, page, limit int64) ([]*media.Media, int64, error)
+		UpdateFiles(ctx context.Context, m *media.Media) error
+		Delete(ctx context.Context, id string) error
+	}
+
+	blobStore interface {
+		Put(ctx context.Context, key string, r io.Reader) error
+		Open(ctx context.Context, key string) (io.ReadCloser, error)
+		Delete(ctx context.Context, key string) error
+	}
+
+	service struct {
+		repo    repository
+		blobs   blobStore
+		maxSize int64
+	}
+)
+
+func New(repo repository, blobs blobStore, maxSize int64) service {
+	return service{repo, blobs, maxSize}
+}
@@ -0,0 +1,154 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"news-svc/config"
+	"news-svc/internal/entity/event"
+	"news-svc/pkg/tracing"
+)
+
+// Transaction runs fn in a transaction and wakes the relay once it has
+// committed.
+func (s *service) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	ctx, span := tracing.Start(ctx, "service.outbox.Transaction")
+	defer span.End()
+
+	if err := s.repo.Transaction(ctx, fn); err != nil {
+		return err
+	}
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Add writes e to the outbox. Call it with the context of a Transaction
+// so the event is stored if and only if the change it describes is.
+func (s *service) Add(ctx context.Context, e event.Event) error {
+	ctx, span := tracing.Start(ctx, "service.outbox.Add")
+	defer span.End()
+
+	return s.repo.AddToOutbox(ctx, e)
+}
+
+// Start launches the relay.
+func (s *service) Start() {
+	s.startOnce.Do(func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		s.cancel = cancel
+		s.started.Store(true)
+		go s.run(ctx)
+	})
+}
+
+// Check reports an error once the relay has stopped. Nothing is
+// checked before Start.
+func (s *service) Check(ctx context.Context) error {
+	if !s.started.Load() {
+		return nil
+	}
+
+	select {
+	case <-s.done:
+		return config.ErrWorkerStopped
+	default:
+		return nil
+	}
+}
+
+// Close stops the relay. An event being relayed is abandoned and
+// relayed again once its lease runs out.
+func (s *service) Close(ctx context.Context) error {
+	s.stopOnce.Do(func() { s.cancel() })
+	if !s.started.Load() {
+		return nil
+	}
+
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *service) run(ctx context.Context) {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+
+	var pruned time.Time
+	for {
+		s.drain(ctx)
+
+		if now := s.now(); now.Sub(pruned) >= pruneEvery {
+			s.prune(ctx, now)
+			pruned = now
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+// drain relays events until the outbox is empty or one fails.
+func (s *service) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		e, err := s.repo.ClaimFromOutbox(ctx, s.now(), lease)
+		if err != nil {
+			if ctx.Err() == nil {
+				s.l.Error("unable to claim outbox event", "err", err)
+			}
+			return
+		}
+		if e == nil {
+			return
+		}
+
+		if err := s.relay(ctx, *e); err != nil {
+			if ctx.Err() == nil {
+				s.l.Error("unable to relay outbox event", "event", e.ID, "type", e.Type, "post", e.PostID, "err", err)
+			}
+			return
+		}
+	}
+}
+
+// relay hands e on and marks it processed. Webhooks come first: if they
+// fail, nothing has been published yet and the event is retried whole.
+// A crash before it is marked means e is relayed twice, so consumers
+// must tolerate repeats, which they can tell apart by e.ID.
+func (s *service) relay(ctx context.Context, e event.Event) error {
+	if s.webhooks != nil {
+		if err := s.webhooks.Enqueue(ctx, e); err != nil {
+			return err
+		}
+	}
+	if s.bus != nil {
+		s.bus.Publish(e)
+	}
+
+	return s.repo.MarkProcessed(ctx, e.ID, s.now())
+}
+
+func (s *service) prune(ctx context.Context, now time.Time) {
+	n, err := s.repo.PruneOutbox(ctx, now.Add(-s.cfg.Retention))
+	if err != nil {
+		if ctx.Err() == nil {
+			s.l.Error("unable to prune outbox", "err", err)
+		}
+		return
+	}
+	if n > 0 {
+		s.l.Debug("pruned outbox", "events", n)
+	}
+}
@@ -0,0 +1,248 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"news-svc/config"
+	"news-svc/internal/entity/event"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var t0 = time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+
+type (
+	record struct {
+		e           event.Event
+		lockedUntil time.Time
+		processedAt time.Time
+	}
+
+	// memRepo keeps events added in a transaction only if it commits.
+	memRepo struct {
+		mu      sync.Mutex
+		records []*record
+		pending []*record
+		prunes  []time.Time
+	}
+
+	memBus struct {
+		mu     sync.Mutex
+		events []event.Event
+	}
+
+	memWebhooks struct {
+		mu     sync.Mutex
+		events []event.Event
+		err    error
+	}
+)
+
+func (m *memRepo) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	m.mu.Lock()
+	m.pending = nil
+	m.mu.Unlock()
+
+	if err := fn(ctx); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.records = append(m.records, m.pending...)
+	return nil
+}
+func (m *memRepo) AddToOutbox(ctx context.Context, e event.Event) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pending = append(m.pending, &record{e: e})
+	return nil
+}
+func (m *memRepo) ClaimFromOutbox(ctx context.Context, now time.Time, lease time.Duration) (*event.Event, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, r := range m.records {
+		if r.processedAt.IsZero() && !r.lockedUntil.After(now) {
+			r.lockedUntil = now.Add(lease)
+			e := r.e
+			return &e, nil
+		}
+	}
+	return nil, nil
+}
+func (m *memRepo) MarkProcessed(ctx context.Context, id string, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, r := range m.records {
+		if r.e.ID == id {
+			r.processedAt = at
+			r.lockedUntil = time.Time{}
+		}
+	}
+	return nil
+}
+func (m *memRepo) PruneOutbox(ctx context.Context, before time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.prunes = append(m.prunes, before)
+	return 0, nil
+}
+func (m *memRepo) unprocessed() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := 0
+	for _, r := range m.records {
+		if r.processedAt.IsZero() {
+			n++
+		}
+	}
+	return n
+}
+
+func (b *memBus) Publish(e event.Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.events = append(b.events, e)
+}
+func (b *memBus) published() []event.Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]event.Event(nil), b.events...)
+}
+
+func (w *memWebhooks) Enqueue(ctx context.Context, e event.Event) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil {
+		return w.err
+	}
+	w.events = append(w.events, e)
+	return nil
+}
+
+var testCfg = config.Outbox{PollInterval: time.Hour, Retention: 24 * time.Hour}
+
+func newTestService(repo *memRepo, bus *memBus, hooks *memWebhooks) *service {
+	s := New(repo, bus, hooks, testCfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	s.now = func() time.Time { return t0 }
+	return s
+}
+
+func write(t *testing.T, s *service, ids ...string) {
+	t.Helper()
+	err := s.Transaction(context.Background(), func(ctx context.Context) error {
+		for _, id := range ids {
+			if err := s.Add(ctx, event.Event{ID: id, Type: event.PostCreated, PostID: "p-" + id}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestRelay(t *testing.T) {
+	repo, bus, hooks := &memRepo{}, &memBus{}, &memWebhooks{}
+	s := newTestService(repo, bus, hooks)
+
+	write(t, s, "e1", "e2")
+	s.drain(context.Background())
+
+	assert.Equal(t, 0, repo.unprocessed())
+	if assert.Len(t, bus.published(), 2) {
+		assert.Equal(t, "e1", bus.events[0].ID)
+		assert.Equal(t, "e2", bus.events[1].ID)
+	}
+	assert.Len(t, hooks.events, 2)
+}
+
+func TestRolledBackWritesAreNotRelayed(t *testing.T) {
+	repo, bus, hooks := &memRepo{}, &memBus{}, &memWebhooks{}
+	s := newTestService(repo, bus, hooks)
+
+	boom := errors.New("boom")
+	err := s.Transaction(context.Background(), func(ctx context.Context) error {
+		require.NoError(t, s.Add(ctx, event.Event{ID: "e1"}))
+		return boom
+	})
+	assert.ErrorIs(t, err, boom)
+
+	s.drain(context.Background())
+	assert.Empty(t, bus.published())
+	// nothing committed, so there is nothing to wake the relay for
+	assert.Empty(t, s.wake)
+}
+
+func TestFailedWebhooksAreRetried(t *testing.T) {
+	repo, bus, hooks := &memRepo{}, &memBus{}, &memWebhooks{err: errors.New("mongo down")}
+	s := newTestService(repo, bus, hooks)
+	now := t0
+	s.now = func() time.Time { return now }
+
+	write(t, s, "e1")
+	s.drain(context.Background())
+
+	// not published anywhere yet, so it is retried whole
+	assert.Equal(t, 1, repo.unprocessed())
+	assert.Empty(t, bus.published())
+
+	hooks.err = nil
+	s.drain(context.Background())
+	assert.Equal(t, 1, repo.unprocessed(), "leased until it runs out")
+
+	now = now.Add(lease)
+	s.drain(context.Background())
+	assert.Equal(t, 0, repo.unprocessed())
+	assert.Len(t, bus.published(), 1)
+	assert.Len(t, hooks.events, 1)
+}
+
+func TestNilConsumers(t *testing.T) {
+	repo := &memRepo{}
+	s := New(repo, nil, nil, testCfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	write(t, s, "e1")
+	s.drain(context.Background())
+
+	assert.Equal(t, 0, repo.unprocessed())
+}
+
+func TestStartRelaysAndPrunes(t *testing.T) {
+	repo, bus, hooks := &memRepo{}, &memBus{}, &memWebhooks{}
+	s := newTestService(repo, bus, hooks)
+
+	s.Start()
+	write(t, s, "e1")
+
+	assert.Eventually(t, func() bool { return len(bus.published()) == 1 }, 2*time.Second, 10*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, s.Close(ctx))
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if assert.NotEmpty(t, repo.prunes) {
+		assert.Equal(t, t0.Add(-testCfg.Retention), repo.prunes[0])
+	}
+}
+
+func TestCheck(t *testing.T) {
+	s := newTestService(&memRepo{}, &memBus{}, &memWebhooks{})
+	assert.NoError(t, s.Check(context.Background()))
+
+	s.Start()
+	assert.NoError(t, s.Check(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, s.Close(ctx))
+	assert.ErrorIs(t, s.Check(context.Background()), config.ErrWorkerStopped)
+}
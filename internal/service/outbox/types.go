@@ -0,0 +1,74 @@
+package outbox
+
+import (
+	"context"
+	"log/slog"
+	"news-svc/config"
+	"news-svc/internal/entity/event"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// lease is how long a claimed event stays hidden from other relays.
+	lease = 30 * time.Second
+	// pruneEvery is how often relayed events past retention are deleted.
+	pruneEvery = 10 * time.Minute
+)
+
+type (
+	repository interface {
+		Transaction(ctx context.Context, fn func(ctx context.Context) error) error
+		AddToOutbox(ctx context.Context, e event.Event) error
+		ClaimFromOutbox(ctx context.Context, now time.Time, lease time.Duration) (*event.Event, error)
+		MarkProcessed(ctx context.Context, id string, at time.Time) error
+		PruneOutbox(ctx context.Context, before time.Time) (int64, error)
+	}
+
+	// publisher is the in-process event bus.
+	publisher interface {
+		Publish(e event.Event)
+	}
+
+	// enqueuer takes events that must not be lost, such as webhooks. An
+	// error leaves the event in the outbox to be relayed again.
+	enqueuer interface {
+		Enqueue(ctx context.Context, e event.Event) error
+	}
+
+	// service is the transactional outbox: writers add events in the
+	// same transaction as the change they describe, and a relay hands
+	// them on at least once afterwards.
+	service struct {
+		repo     repository
+		bus      publisher
+		webhooks enqueuer
+		cfg      config.Outbox
+		l        *slog.Logger
+		now      func() time.Time
+
+		wake      chan struct{}
+		cancel    context.CancelFunc
+		done      chan struct{}
+		startOnce sync.Once
+		stopOnce  sync.Once
+		started   atomic.Bool
+	}
+)
+
+// New - creates the outbox. bus and webhooks may be nil when another
+// part of the app feeds them.
+func New(repo repository, bus publisher, webhooks enqueuer, cfg config.Outbox, l *slog.Logger) *service {
+	return &service{
+		repo:     repo,
+		bus:      bus,
+		webhooks: webhooks,
+		cfg:      cfg,
+		l:        l,
+		now:      time.Now,
+		wake:     make(chan struct{}, 1),
+		cancel:   func() {},
+		done:     make(chan struct{}),
+	}
+}
@@ -1,19 +1,51 @@
 package post
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"encoding/json"
+	"io"
+	"news-svc/internal/entity/event"
 	"news-svc/internal/entity/post"
+	"news-svc/pkg/metrics"
+	"news-svc/pkg/tracing"
+	"time"
 )
 
-func (s service) Create(ctx context.Context, post *post.Post) (string, error) {
-	if err := post.Validate(); err != nil {
+func (s service) Create(ctx context.Context, p *post.Post) (string, error) {
+	ctx, span := tracing.Start(ctx, "service.post.Create")
+	defer span.End()
+
+	if p.Status == "" {
+		p.Status = post.StatusPublished
+	}
+	if err := p.Validate(); err != nil {
 		return "", err
 	}
 
-	return s.repo.Create(ctx, post)
+	var id string
+	err := s.write(ctx, func(ctx context.Context) (err error) {
+		if id, err = s.repo.Create(ctx, p); err != nil {
+			return err
+		}
+		return s.record(ctx, event.PostCreated, id, false)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	metrics.CountPost(metrics.PostCreated)
+	if s.related != nil {
+		s.related.Relate(ctx, id)
+	}
+	return id, nil
 }
 
 func (s service) GetAll(ctx context.Context, page, limit int64) ([]*post.Post, int64, error) {
+	ctx, span := tracing.Start(ctx, "service.post.GetAll")
+	defer span.End()
+
 	if page <= 0 {
 		page = 1
 	}
@@ -24,23 +56,119 @@ func (s service) GetAll(ctx context.Context, page, limit int64) ([]*post.Post, i
 	return s.repo.GetAll(ctx, page, limit)
 }
 
+// GetAllSorted lists posts of any status for the admin, newest first
+// or by reaction count.
+func (s service) GetAllSorted(ctx context.Context, sort string, page, limit int64) ([]*post.Post, int64, error) {
+	ctx, span := tracing.Start(ctx, "service.post.GetAllSorted")
+	defer span.End()
+
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+	if sort != post.SortReactions {
+		sort = post.SortNewest
+	}
+
+	return s.repo.GetAllSorted(ctx, sort, page, limit)
+}
+
 func (s service) GetByID(ctx context.Context, id string) (*post.Post, error) {
+	ctx, span := tracing.Start(ctx, "service.post.GetByID")
+	defer span.End()
+
 	return s.repo.GetByID(ctx, id)
 }
 
-func (s service) Update(ctx context.Context, post *post.Post) error {
-	if err := post.Validate(); err != nil {
+func (s service) Update(ctx context.Context, p *post.Post) error {
+	ctx, span := tracing.Start(ctx, "service.post.Update")
+	defer span.End()
+
+	if err := p.Validate(); err != nil {
 		return err
 	}
 
-	return s.repo.Update(ctx, post)
+	err := s.write(ctx, func(ctx context.Context) error {
+		// whether the update publishes the post is only known beforehand
+		wasPublished := false
+		if s.events != nil {
+			if old, err := s.repo.GetByID(ctx, p.ID); err == nil {
+				wasPublished = old.Status == post.StatusPublished
+			}
+		}
+
+		if err := s.repo.Update(ctx, p); err != nil {
+			return err
+		}
+		return s.record(ctx, event.PostUpdated, p.ID, wasPublished)
+	})
+	if err != nil {
+		return err
+	}
+
+	metrics.CountPost(metrics.PostUpdated)
+	if s.related != nil {
+		s.related.Relate(ctx, p.ID)
+	}
+	return nil
 }
 
 func (s service) Delete(ctx context.Context, id string) error {
-	return s.repo.Delete(ctx, id)
+	ctx, span := tracing.Start(ctx, "service.post.Delete")
+	defer span.End()
+
+	err := s.write(ctx, func(ctx context.Context) error {
+		if err := s.repo.Delete(ctx, id); err != nil {
+			return err
+		}
+		return s.record(ctx, event.PostDeleted, id, false)
+	})
+	if err != nil {
+		return err
+	}
+
+	metrics.CountPost(metrics.PostDeleted)
+	if s.related != nil {
+		s.related.Forget(ctx, id)
+	}
+	return nil
+}
+
+// write runs fn, which changes a post and records the event, in a
+// transaction so that neither happens without the other.
+func (s service) write(ctx context.Context, fn func(ctx context.Context) error) error {
+	if s.events == nil {
+		return fn(ctx)
+	}
+	return s.events.Transaction(ctx, fn)
+}
+
+// record adds the event for a post write to the outbox. Events carry
+// the post as stored, which also includes fields the write did not
+// touch.
+func (s service) record(ctx context.Context, typ, id string, wasPublished bool) error {
+	if s.events == nil {
+		return nil
+	}
+
+	e := event.Event{ID: event.NewID(), Type: typ, PostID: id, At: time.Now()}
+	if typ != event.PostDeleted {
+		p, err := s.repo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		e.Post = p
+		e.Published = p.Status == post.StatusPublished && !wasPublished
+	}
+	return s.events.Add(ctx, e)
 }
 
 func (s service) Search(ctx context.Context, query string, page, limit int64) ([]*post.Post, int64, error) {
+	ctx, span := tracing.Start(ctx, "service.post.Search")
+	defer span.End()
+
 	if page <= 0 {
 		page = 1
 	}
@@ -52,9 +180,182 @@ func (s service) Search(ctx context.Context, query string, page, limit int64) ([
 }
 
 func (s service) GetRecent(ctx context.Context, limit int64) ([]*post.Post, error) {
+	ctx, span := tracing.Start(ctx, "service.post.GetRecent")
+	defer span.End()
+
 	if limit <= 0 {
 		limit = 5
 	}
 
 	return s.repo.GetRecent(ctx, limit)
 }
+
+func (s service) GetRecentBy(ctx context.Context, f post.Filter, limit int64) ([]*post.Post, error) {
+	ctx, span := tracing.Start(ctx, "service.post.GetRecentBy")
+	defer span.End()
+
+	if limit <= 0 {
+		limit = 5
+	}
+
+	return s.repo.GetRecentBy(ctx, f, limit)
+}
+
+func (s service) GetAllBy(ctx context.Context, f post.Filter, page, limit int64) ([]*post.Post, int64, error) {
+	ctx, span := tracing.Start(ctx, "service.post.GetAllBy")
+	defer span.End()
+
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+
+	return s.repo.GetAllBy(ctx, f, page, limit)
+}
+
+// CountPublished returns the number of published posts.
+func (s service) CountPublished(ctx context.Context) (int64, error) {
+	ctx, span := tracing.Start(ctx, "service.post.CountPublished")
+	defer span.End()
+
+	return s.repo.CountBy(ctx, post.Filter{Status: post.StatusPublished})
+}
+
+// PublishedSummaries returns published posts, oldest first, with only
+// their ID and timestamps loaded.
+func (s service) PublishedSummaries(ctx context.Context, skip, limit int64) ([]*post.Post, error) {
+	ctx, span := tracing.Start(ctx, "service.post.PublishedSummaries")
+	defer span.End()
+
+	return s.repo.Summaries(ctx, post.Filter{Status: post.StatusPublished}, skip, limit)
+}
+
+// Tags returns every tag used by a published post.
+func (s service) Tags(ctx context.Context) ([]post.Term, error) {
+	ctx, span := tracing.Start(ctx, "service.post.Tags")
+	defer span.End()
+
+	return s.repo.Terms(ctx, "tags", post.Filter{Status: post.StatusPublished})
+}
+
+// Categories returns every category used by a published post.
+func (s service) Categories(ctx context.Context) ([]post.Term, error) {
+	ctx, span := tracing.Start(ctx, "service.post.Categories")
+	defer span.End()
+
+	return s.repo.Terms(ctx, "categories", post.Filter{Status: post.StatusPublished})
+}
+
+// Export writes every post matching f to w as JSON Lines.
+func (s service) Export(ctx context.Context, f post.Filter, w io.Writer) error {
+	ctx, span := tracing.Start(ctx, "service.post.Export")
+	defer span.End()
+
+	enc := json.NewEncoder(w)
+	return s.repo.Export(ctx, f, func(p *post.Post) error {
+		return enc.Encode(p)
+	})
+}
+
+// Import reads JSON Lines from r and upserts each record by id or slug.
+// Invalid records are reported per line and do not abort the import;
+// the returned error is only set when reading or writing fails as a whole.
+func (s service) Import(ctx context.Context, r io.Reader) (ImportReport, error) {
+	ctx, span := tracing.Start(ctx, "service.post.Import")
+	defer span.End()
+
+	var report ImportReport
+	w := s.newBatchWriter(ctx, &report)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), importMaxLineSize)
+
+	for line := 1; scanner.Scan(); line++ {
+		raw := bytes.TrimSpace(scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+
+		p := new(post.Post)
+		if err := json.Unmarshal(raw, p); err != nil {
+			report.reject(line, err)
+			continue
+		}
+
+		if err := w.add(p, line); err != nil {
+			return report, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return report, err
+	}
+
+	return report, w.flush()
+}
+
+// batchWriter validates records and upserts them in batches,
+// recording the outcome of every record in report.
+type batchWriter struct {
+	ctx    context.Context
+	repo   repository
+	report *ImportReport
+	batch  []*post.Post
+	lines  []int
+}
+
+func (s service) newBatchWriter(ctx context.Context, report *ImportReport) *batchWriter {
+	return &batchWriter{
+		ctx:    ctx,
+		repo:   s.repo,
+		report: report,
+		batch:  make([]*post.Post, 0, importBatchSize),
+		lines:  make([]int, 0, importBatchSize),
+	}
+}
+
+func (w *batchWriter) add(p *post.Post, line int) error {
+	if p.Status == "" {
+		p.Status = post.StatusPublished
+	}
+	if err := p.Validate(); err != nil {
+		w.report.reject(line, err)
+		return nil
+	}
+
+	w.batch = append(w.batch, p)
+	w.lines = append(w.lines, line)
+
+	if len(w.batch) == importBatchSize {
+		return w.flush()
+	}
+	return nil
+}
+
+func (w *batchWriter) flush() error {
+	if len(w.batch) == 0 {
+		return nil
+	}
+
+	failed, err := w.repo.BulkUpsert(w.ctx, w.batch)
+	if err != nil {
+		return err
+	}
+
+	for i, line := range w.lines {
+		if err, ok := failed[i]; ok {
+			w.report.reject(line, err)
+			continue
+		}
+		w.report.Written++
+	}
+
+	w.batch, w.lines = w.batch[:0], w.lines[:0]
+	return nil
+}
+
+func (r *ImportReport) reject(line int, err error) {
+	r.Failed++
+	r.Errors = append(r.Errors, LineError{Line: line, Err: err.Error()})
+}
@@ -1,9 +1,14 @@
 package post
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"strings"
 	"testing"
 
+	"news-svc/config"
+	"news-svc/internal/entity/event"
 	"news-svc/internal/entity/post"
 
 	"github.com/stretchr/testify/assert"
@@ -12,11 +17,19 @@ import (
 type mockRepo struct {
 	createFn    func(ctx context.Context, p *post.Post) (string, error)
 	getAllFn    func(ctx context.Context, page, limit int64) ([]*post.Post, int64, error)
+	sortedFn    func(ctx context.Context, sort string, page, limit int64) ([]*post.Post, int64, error)
 	getByIDFn   func(ctx context.Context, id string) (*post.Post, error)
 	updateFn    func(ctx context.Context, p *post.Post) error
 	deleteFn    func(ctx context.Context, id string) error
 	searchFn    func(ctx context.Context, q string, page, limit int64) ([]*post.Post, int64, error)
 	getRecentFn func(ctx context.Context, limit int64) ([]*post.Post, error)
+	recentByFn  func(ctx context.Context, f post.Filter, limit int64) ([]*post.Post, error)
+	getAllByFn  func(ctx context.Context, f post.Filter, page, limit int64) ([]*post.Post, int64, error)
+	countByFn   func(ctx context.Context, f post.Filter) (int64, error)
+	summariesFn func(ctx context.Context, f post.Filter, skip, limit int64) ([]*post.Post, error)
+	termsFn     func(ctx context.Context, field string, f post.Filter) ([]post.Term, error)
+	exportFn    func(ctx context.Context, f post.Filter, fn func(*post.Post) error) error
+	bulkFn      func(ctx context.Context, posts []*post.Post) (map[int]error, error)
 }
 
 func (m *mockRepo) Create(ctx context.Context, p *post.Post) (string, error) {
@@ -25,6 +38,9 @@ func (m *mockRepo) Create(ctx context.Context, p *post.Post) (string, error) {
 func (m *mockRepo) GetAll(ctx context.Context, page, limit int64) ([]*post.Post, int64, error) {
 	return m.getAllFn(ctx, page, limit)
 }
+func (m *mockRepo) GetAllSorted(ctx context.Context, sort string, page, limit int64) ([]*post.Post, int64, error) {
+	return m.sortedFn(ctx, sort, page, limit)
+}
 func (m *mockRepo) GetByID(ctx context.Context, id string) (*post.Post, error) {
 	return m.getByIDFn(ctx, id)
 }
@@ -41,12 +57,34 @@ func (m *mockRepo) GetRecent(ctx context.Context, limit int64) ([]*post.Post, er
 	return m.getRecentFn(ctx, limit)
 }
 
+func (m *mockRepo) GetRecentBy(ctx context.Context, f post.Filter, limit int64) ([]*post.Post, error) {
+	return m.recentByFn(ctx, f, limit)
+}
+func (m *mockRepo) GetAllBy(ctx context.Context, f post.Filter, page, limit int64) ([]*post.Post, int64, error) {
+	return m.getAllByFn(ctx, f, page, limit)
+}
+func (m *mockRepo) CountBy(ctx context.Context, f post.Filter) (int64, error) {
+	return m.countByFn(ctx, f)
+}
+func (m *mockRepo) Summaries(ctx context.Context, f post.Filter, skip, limit int64) ([]*post.Post, error) {
+	return m.summariesFn(ctx, f, skip, limit)
+}
+func (m *mockRepo) Terms(ctx context.Context, field string, f post.Filter) ([]post.Term, error) {
+	return m.termsFn(ctx, field, f)
+}
+func (m *mockRepo) Export(ctx context.Context, f post.Filter, fn func(*post.Post) error) error {
+	return m.exportFn(ctx, f, fn)
+}
+func (m *mockRepo) BulkUpsert(ctx context.Context, posts []*post.Post) (map[int]error, error) {
+	return m.bulkFn(ctx, posts)
+}
+
 func TestCreateSuccess(t *testing.T) {
 	svc := New(&mockRepo{
 		createFn: func(ctx context.Context, p *post.Post) (string, error) {
 			return "123", nil
 		},
-	})
+	}, nil, nil)
 
 	p := &post.Post{Title: "Test", Content: "Content"}
 	id, err := svc.Create(context.Background(), p)
@@ -56,7 +94,7 @@ func TestCreateSuccess(t *testing.T) {
 }
 
 func TestCreateValidationError(t *testing.T) {
-	svc := New(&mockRepo{})
+	svc := New(&mockRepo{}, nil, nil)
 
 	p := &post.Post{} // missing title/content
 	_, err := svc.Create(context.Background(), p)
@@ -73,13 +111,31 @@ func TestGetAllDefaults(t *testing.T) {
 			assert.Equal(t, int64(10), limit)
 			return []*post.Post{}, 0, nil
 		},
-	})
+	}, nil, nil)
 
 	_, _, err := svc.GetAll(context.Background(), 0, 0)
 	assert.NoError(t, err)
 	assert.True(t, called)
 }
 
+func TestGetAllSortedDefaults(t *testing.T) {
+	var sorts []string
+	svc := New(&mockRepo{
+		sortedFn: func(ctx context.Context, sort string, page, limit int64) ([]*post.Post, int64, error) {
+			sorts = append(sorts, sort)
+			assert.Equal(t, int64(1), page)
+			assert.Equal(t, int64(10), limit)
+			return nil, 0, nil
+		},
+	}, nil, nil)
+
+	for _, sort := range []string{"", "bogus", post.SortReactions} {
+		_, _, err := svc.GetAllSorted(context.Background(), sort, 0, 0)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, []string{post.SortNewest, post.SortNewest, post.SortReactions}, sorts)
+}
+
 func TestGetByID(t *testing.T) {
 	example := &post.Post{ID: "id1"}
 	svc := New(&mockRepo{
@@ -87,7 +143,7 @@ func TestGetByID(t *testing.T) {
 			assert.Equal(t, "id1", id)
 			return example, nil
 		},
-	})
+	}, nil, nil)
 
 	res, err := svc.GetByID(context.Background(), "id1")
 	assert.NoError(t, err)
@@ -101,14 +157,113 @@ func TestUpdateSuccess(t *testing.T) {
 			assert.Equal(t, p, post)
 			return nil
 		},
-	})
+	}, nil, nil)
 
 	err := svc.Update(context.Background(), p)
 	assert.NoError(t, err)
 }
 
+type mockRelater struct {
+	calls []string
+}
+
+func (m *mockRelater) Relate(ctx context.Context, id string) { m.calls = append(m.calls, "relate "+id) }
+func (m *mockRelater) Forget(ctx context.Context, id string) { m.calls = append(m.calls, "forget "+id) }
+
+func TestWritesUpdateRelatedPosts(t *testing.T) {
+	mr := &mockRelater{}
+	failing := true
+	svc := New(&mockRepo{
+		createFn: func(ctx context.Context, p *post.Post) (string, error) { return "id1", nil },
+		updateFn: func(ctx context.Context, p *post.Post) error {
+			if failing {
+				return errors.New("boom")
+			}
+			return nil
+		},
+		deleteFn: func(ctx context.Context, id string) error { return nil },
+	}, mr, nil)
+	ctx := context.Background()
+
+	_, err := svc.Create(ctx, &post.Post{Title: "T", Content: "C"})
+	assert.NoError(t, err)
+	assert.Error(t, svc.Update(ctx, &post.Post{ID: "id1", Title: "T", Content: "C"}))
+	failing = false
+	assert.NoError(t, svc.Update(ctx, &post.Post{ID: "id1", Title: "T", Content: "C"}))
+	assert.NoError(t, svc.Delete(ctx, "id1"))
+
+	assert.Equal(t, []string{"relate id1", "relate id1", "forget id1"}, mr.calls)
+}
+
+// mockOutbox keeps the events of committed transactions only.
+type mockOutbox struct {
+	events  []event.Event
+	pending []event.Event
+}
+
+func (m *mockOutbox) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	m.pending = nil
+	if err := fn(ctx); err != nil {
+		return err
+	}
+	m.events = append(m.events, m.pending...)
+	return nil
+}
+func (m *mockOutbox) Add(ctx context.Context, e event.Event) error {
+	m.pending = append(m.pending, e)
+	return nil
+}
+
+func TestWritesRecordEvents(t *testing.T) {
+	mp := &mockOutbox{}
+	svc := New(&mockRepo{
+		createFn: func(ctx context.Context, p *post.Post) (string, error) { return "id1", nil },
+		updateFn: func(ctx context.Context, p *post.Post) error { return nil },
+		deleteFn: func(ctx context.Context, id string) error { return nil },
+		getByIDFn: func(ctx context.Context, id string) (*post.Post, error) {
+			return &post.Post{ID: id, Title: "Stored", Status: post.StatusPublished}, nil
+		},
+	}, nil, mp)
+	ctx := context.Background()
+
+	_, err := svc.Create(ctx, &post.Post{Title: "T", Content: "C"})
+	assert.NoError(t, err)
+	assert.NoError(t, svc.Update(ctx, &post.Post{ID: "id1", Title: "T", Content: "C"}))
+	assert.NoError(t, svc.Delete(ctx, "id1"))
+
+	if assert.Len(t, mp.events, 3) {
+		assert.Equal(t, event.PostCreated, mp.events[0].Type)
+		assert.Equal(t, "Stored", mp.events[0].Post.Title)
+		assert.True(t, mp.events[0].Published)
+		assert.NotEmpty(t, mp.events[0].ID)
+		assert.Equal(t, event.PostUpdated, mp.events[1].Type)
+		// it was published before the update already
+		assert.False(t, mp.events[1].Published)
+		assert.NotEqual(t, mp.events[0].ID, mp.events[1].ID)
+		assert.Equal(t, event.PostDeleted, mp.events[2].Type)
+		assert.Equal(t, "id1", mp.events[2].PostID)
+		assert.Nil(t, mp.events[2].Post)
+	}
+}
+
+func TestFailedWritesRecordNoEvents(t *testing.T) {
+	mo := &mockOutbox{}
+	svc := New(&mockRepo{
+		updateFn: func(ctx context.Context, p *post.Post) error { return errors.New("boom") },
+		deleteFn: func(ctx context.Context, id string) error { return config.ErrPostNotFound },
+		getByIDFn: func(ctx context.Context, id string) (*post.Post, error) {
+			return &post.Post{ID: id, Status: post.StatusPublished}, nil
+		},
+	}, nil, mo)
+	ctx := context.Background()
+
+	assert.Error(t, svc.Update(ctx, &post.Post{ID: "id1", Title: "T", Content: "C"}))
+	assert.ErrorIs(t, svc.Delete(ctx, "id1"), config.ErrPostNotFound)
+	assert.Empty(t, mo.events)
+}
+
 func TestUpdateValidationError(t *testing.T) {
-	svc := New(&mockRepo{})
+	svc := New(&mockRepo{}, nil, nil)
 	p := &post.Post{} // invalid
 	err := svc.Update(context.Background(), p)
 	assert.Error(t, err)
@@ -123,7 +278,7 @@ func TestDelete(t *testing.T) {
 			assert.Equal(t, id, got)
 			return nil
 		},
-	})
+	}, nil, nil)
 
 	err := svc.Delete(context.Background(), id)
 	assert.NoError(t, err)
@@ -140,7 +295,7 @@ func TestSearchDefaults(t *testing.T) {
 			assert.Equal(t, int64(10), limit)
 			return []*post.Post{}, 0, nil
 		},
-	})
+	}, nil, nil)
 
 	_, _, err := svc.Search(context.Background(), "query", 0, 0)
 	assert.NoError(t, err)
@@ -155,9 +310,205 @@ func TestGetRecentDefault(t *testing.T) {
 			assert.Equal(t, int64(5), limit)
 			return []*post.Post{}, nil
 		},
-	})
+	}, nil, nil)
 
 	_, err := svc.GetRecent(context.Background(), 0)
 	assert.NoError(t, err)
 	assert.True(t, called)
 }
+
+func TestGetRecentByDefault(t *testing.T) {
+	f := post.Filter{Tag: "go"}
+	svc := New(&mockRepo{
+		recentByFn: func(ctx context.Context, got post.Filter, limit int64) ([]*post.Post, error) {
+			assert.Equal(t, f, got)
+			assert.Equal(t, int64(5), limit)
+			return []*post.Post{}, nil
+		},
+	}, nil, nil)
+
+	_, err := svc.GetRecentBy(context.Background(), f, 0)
+	assert.NoError(t, err)
+}
+
+func TestGetAllByDefaults(t *testing.T) {
+	f := post.Filter{Category: "politics"}
+	svc := New(&mockRepo{
+		getAllByFn: func(ctx context.Context, got post.Filter, page, limit int64) ([]*post.Post, int64, error) {
+			assert.Equal(t, f, got)
+			assert.Equal(t, int64(1), page)
+			assert.Equal(t, int64(10), limit)
+			return []*post.Post{}, 0, nil
+		},
+	}, nil, nil)
+
+	_, _, err := svc.GetAllBy(context.Background(), f, 0, 0)
+	assert.NoError(t, err)
+}
+
+func TestTermsArePublishedOnly(t *testing.T) {
+	var fields []string
+	svc := New(&mockRepo{
+		termsFn: func(ctx context.Context, field string, f post.Filter) ([]post.Term, error) {
+			fields = append(fields, field)
+			assert.Equal(t, post.StatusPublished, f.Status)
+			return nil, nil
+		},
+	}, nil, nil)
+
+	_, err := svc.Tags(context.Background())
+	assert.NoError(t, err)
+	_, err = svc.Categories(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"tags", "categories"}, fields)
+}
+
+func TestCreateDefaultsStatus(t *testing.T) {
+	svc := New(&mockRepo{
+		createFn: func(ctx context.Context, p *post.Post) (string, error) {
+			assert.Equal(t, post.StatusPublished, p.Status)
+			return "123", nil
+		},
+	}, nil, nil)
+
+	_, err := svc.Create(context.Background(), &post.Post{Title: "T", Content: "C"})
+	assert.NoError(t, err)
+}
+
+func TestExport(t *testing.T) {
+	f := post.Filter{Status: post.StatusDraft}
+	svc := New(&mockRepo{
+		exportFn: func(ctx context.Context, got post.Filter, fn func(*post.Post) error) error {
+			assert.Equal(t, f, got)
+			for _, id := range []string{"a", "b"} {
+				if err := fn(&post.Post{ID: id, Title: "T", Content: "C"}); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}, nil, nil)
+
+	var buf bytes.Buffer
+	err := svc.Export(context.Background(), f, &buf)
+	assert.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 2)
+	assert.Contains(t, lines[0], `"id":"a"`)
+	assert.Contains(t, lines[1], `"id":"b"`)
+}
+
+func TestImportReportsPerLine(t *testing.T) {
+	input := strings.Join([]string{
+		`{"title":"A","content":"a"}`,
+		`not json`,
+		``,
+		`{"title":"","content":"b"}`,
+		`{"slug":"dup","title":"C","content":"c"}`,
+		`{"title":"D","content":"d","status":"draft"}`,
+	}, "\n")
+
+	var written []*post.Post
+	svc := New(&mockRepo{
+		bulkFn: func(ctx context.Context, posts []*post.Post) (map[int]error, error) {
+			written = posts
+			return map[int]error{1: errors.New("duplicate key")}, nil
+		},
+	}, nil, nil)
+
+	report, err := svc.Import(context.Background(), strings.NewReader(input))
+	assert.NoError(t, err)
+
+	assert.Len(t, written, 3)
+	assert.Equal(t, post.StatusPublished, written[0].Status)
+	assert.Equal(t, post.StatusDraft, written[2].Status)
+
+	assert.Equal(t, 2, report.Written)
+	assert.Equal(t, 3, report.Failed)
+	assert.Equal(t, 2, report.Errors[0].Line)
+	assert.Equal(t, 4, report.Errors[1].Line)
+	assert.Equal(t, LineError{Line: 5, Err: "duplicate key"}, report.Errors[2])
+}
+
+func TestImportBatches(t *testing.T) {
+	var lines []string
+	for range importBatchSize + 1 {
+		lines = append(lines, `{"title":"T","content":"C"}`)
+	}
+
+	var batches []int
+	svc := New(&mockRepo{
+		bulkFn: func(ctx context.Context, posts []*post.Post) (map[int]error, error) {
+			batches = append(batches, len(posts))
+			return nil, nil
+		},
+	}, nil, nil)
+
+	report, err := svc.Import(context.Background(), strings.NewReader(strings.Join(lines, "\n")))
+	assert.NoError(t, err)
+	assert.Equal(t, []int{importBatchSize, 1}, batches)
+	assert.Equal(t, importBatchSize+1, report.Written)
+}
+
+func TestImportBulkError(t *testing.T) {
+	svc := New(&mockRepo{
+		bulkFn: func(ctx context.Context, posts []*post.Post) (map[int]error, error) {
+			return nil, errors.New("connection lost")
+		},
+	}, nil, nil)
+
+	_, err := svc.Import(context.Background(), strings.NewReader(`{"title":"T","content":"C"}`))
+	assert.Error(t, err)
+}
+
+func TestImportWXR(t *testing.T) {
+	input := `<rss xmlns:content="http://purl.org/rss/1.0/modules/content/"
+	xmlns:dc="http://purl.org/dc/elements/1.1/"
+	xmlns:wp="http://wordpress.org/export/1.2/"><channel>
+	<wp:author><wp:author_login>jdoe</wp:author_login><wp:author_display_name>Jane Doe</wp:author_display_name></wp:author>
+	<item>
+		<title>Published</title><dc:creator>jdoe</dc:creator>
+		<content:encoded>Body</content:encoded>
+		<wp:post_id>1</wp:post_id><wp:post_name>published</wp:post_name>
+		<wp:post_date_gmt>2024-01-01 10:00:00</wp:post_date_gmt>
+		<wp:status>publish</wp:status><wp:post_type>post</wp:post_type>
+		<category domain="post_tag">Go</category>
+	</item>
+	<item>
+		<title>Pending</title><content:encoded>Body</content:encoded>
+		<wp:post_id>2</wp:post_id><wp:status>pending</wp:status><wp:post_type>post</wp:post_type>
+	</item>
+	<item>
+		<title>About</title><content:encoded>Body</content:encoded>
+		<wp:post_id>3</wp:post_id><wp:status>publish</wp:status><wp:post_type>page</wp:post_type>
+	</item>
+	<item>
+		<title></title><content:encoded>Body</content:encoded>
+		<wp:post_id>4</wp:post_id><wp:status>publish</wp:status><wp:post_type>post</wp:post_type>
+	</item>
+	</channel></rss>`
+
+	var written []*post.Post
+	svc := New(&mockRepo{
+		bulkFn: func(ctx context.Context, posts []*post.Post) (map[int]error, error) {
+			written = posts
+			return nil, nil
+		},
+	}, nil, nil)
+
+	report, err := svc.ImportWXR(context.Background(), strings.NewReader(input))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, report.Written)
+	assert.Equal(t, 1, report.Skipped)
+	assert.Equal(t, 1, report.Failed)
+	assert.Equal(t, 4, report.Errors[0].Line)
+
+	assert.Len(t, written, 2)
+	assert.Equal(t, "published", written[0].Slug)
+	assert.Equal(t, "Jane Doe", written[0].Author)
+	assert.Equal(t, []string{"Go"}, written[0].Tags)
+	assert.Equal(t, post.StatusPublished, written[0].Status)
+	assert.Equal(t, "wp-2", written[1].Slug)
+	assert.Equal(t, post.StatusDraft, written[1].Status)
+}
@@ -2,25 +2,74 @@ package post
 
 import (
 	"context"
+	"news-svc/internal/entity/event"
 	"news-svc/internal/entity/post"
 )
 
+const (
+	// importBatchSize is the number of records sent per BulkWrite.
+	importBatchSize = 500
+	// importMaxLineSize caps a single JSONL record.
+	importMaxLineSize = 4 << 20
+)
+
 type (
 	repository interface {
 		Create(ctx context.Context, post *post.Post) (string, error)
 		GetAll(ctx context.Context, page, limit int64) ([]*post.Post, int64, error)
+		GetAllSorted(ctx context.Context, sort string, page, limit int64) ([]*post.Post, int64, error)
 		GetByID(ctx context.Context, id string) (*post.Post, error)
 		Update(ctx context.Context, post *post.Post) error
 		Delete(ctx context.Context, id string) error
 		Search(ctx context.Context, query string, page, limit int64) ([]*post.Post, int64, error)
 		GetRecent(ctx context.Context, limit int64) ([]*post.Post, error)
+		GetRecentBy(ctx context.Context, f post.Filter, limit int64) ([]*post.Post, error)
+		GetAllBy(ctx context.Context, f post.Filter, page, limit int64) ([]*post.Post, int64, error)
+		CountBy(ctx context.Context, f post.Filter) (int64, error)
+		Summaries(ctx context.Context, f post.Filter, skip, limit int64) ([]*post.Post, error)
+		Terms(ctx context.Context, field string, f post.Filter) ([]post.Term, error)
+		Export(ctx context.Context, f post.Filter, fn func(*post.Post) error) error
+		BulkUpsert(ctx context.Context, posts []*post.Post) (map[int]error, error)
+	}
+
+	// relater keeps related posts up to date as posts change.
+	relater interface {
+		Relate(ctx context.Context, id string)
+		Forget(ctx context.Context, id string)
+	}
+
+	// outbox records post events in the same transaction as the write
+	// they describe; they are published once it has committed.
+	outbox interface {
+		Transaction(ctx context.Context, fn func(ctx context.Context) error) error
+		Add(ctx context.Context, e event.Event) error
 	}
 
 	service struct {
-		repo repository
+		repo    repository
+		related relater
+		events  outbox
+	}
+
+	// ImportReport summarises an Import or ImportWXR run.
+	ImportReport struct {
+		Written int         `json:"written"`
+		Failed  int         `json:"failed"`
+		Skipped int         `json:"skipped,omitempty"`
+		Errors  []LineError `json:"errors,omitempty"`
+	}
+
+	// LineError describes why a single record was rejected. For JSONL
+	// imports Line is the line number, for WXR the item number.
+	LineError struct {
+		Line int    `json:"line"`
+		Err  string `json:"error"`
 	}
 )
 
-func New(repo repository) service {
-	return service{repo}
+// New - creates the post service. related and events may be nil, e.g.
+// for command-line tools, in which case related posts are left alone
+// and no events are recorded.
+func New(repo repository, related relater, events outbox) service {
+	return service{repo, related, events}
 }
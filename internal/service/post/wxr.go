@@ -0,0 +1,91 @@
+package post
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"news-svc/internal/entity/post"
+	"news-svc/pkg/tracing"
+	"news-svc/pkg/wxr"
+)
+
+// ImportWXR imports the posts of a WordPress WXR export. Items are upserted
+// by their WordPress slug, so re-running an import updates posts in place
+// instead of duplicating them. Pages, attachments and trashed posts are
+// skipped.
+func (s service) ImportWXR(ctx context.Context, r io.Reader) (ImportReport, error) {
+	ctx, span := tracing.Start(ctx, "service.post.ImportWXR")
+	defer span.End()
+
+	var report ImportReport
+	w := s.newBatchWriter(ctx, &report)
+	dec := wxr.NewDecoder(r)
+
+	for n := 1; ; n++ {
+		item, err := dec.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return report, err
+		}
+
+		p, ok := fromWXR(dec, item)
+		if !ok {
+			report.Skipped++
+			continue
+		}
+
+		if err := w.add(p, n); err != nil {
+			return report, err
+		}
+	}
+
+	return report, w.flush()
+}
+
+func fromWXR(dec *wxr.Decoder, item *wxr.Item) (*post.Post, bool) {
+	if item.Type != "" && item.Type != "post" {
+		return nil, false
+	}
+
+	var status string
+	switch item.Status {
+	case "publish":
+		status = post.StatusPublished
+	case "trash", "auto-draft", "inherit":
+		return nil, false
+	default: // draft, pending, future, private
+		status = post.StatusDraft
+	}
+
+	author := item.Creator
+	if a, ok := dec.Author(item.Creator); ok && a.DisplayName != "" {
+		author = a.DisplayName
+	}
+
+	return &post.Post{
+		Slug:       item.Slug(),
+		Title:      strings.TrimSpace(item.Title),
+		Content:    strings.TrimSpace(item.Content),
+		Summary:    truncate(strings.TrimSpace(item.Excerpt), post.SummaryMaxLen),
+		Status:     status,
+		Author:     author,
+		Tags:       item.Tags(),
+		Categories: item.Categories(),
+		CreatedAt:  item.Published(),
+		UpdatedAt:  item.Modified(),
+	}, true
+}
+
+// truncate shortens s to at most n runes, ending in an ellipsis when cut.
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	r := []rune(s)
+	return strings.TrimSpace(string(r[:n-1])) + "…"
+}
@@ -0,0 +1,69 @@
+package reaction
+
+import (
+	"context"
+	"news-svc/config"
+	"news-svc/internal/entity/post"
+	"news-svc/internal/entity/reaction"
+	"news-svc/pkg/tracing"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Toggle adds the reactor's reaction of the given kind to a published
+// post, or takes it back if they already reacted that way. It returns
+// the post's counts afterwards and whether the reaction is now on.
+func (s service) Toggle(ctx context.Context, postID, kind, reactor string) (map[string]int64, bool, error) {
+	ctx, span := tracing.Start(ctx, "service.reaction.Toggle")
+	defer span.End()
+
+	if !reaction.ValidKind(kind) {
+		return nil, false, config.ErrInvalidReaction
+	}
+
+	// the post repository reports malformed IDs as driver errors
+	if _, err := bson.ObjectIDFromHex(postID); err != nil {
+		return nil, false, config.ErrPostNotFound
+	}
+
+	p, err := s.posts.GetByID(ctx, postID)
+	if err != nil {
+		return nil, false, err
+	}
+	if p.Status == post.StatusDraft {
+		return nil, false, config.ErrPostNotFound
+	}
+
+	added, err := s.repo.Add(ctx, &reaction.Reaction{PostID: postID, Reactor: reactor, Kind: kind})
+	if err != nil {
+		return nil, false, err
+	}
+	if added {
+		counts, err := s.posts.AddReaction(ctx, postID, kind, 1)
+		return counts, true, err
+	}
+
+	// only the request that actually removed the record decrements, so
+	// double clicks cannot push a count below what was recorded
+	removed, err := s.repo.Remove(ctx, postID, reactor, kind)
+	if err != nil {
+		return nil, false, err
+	}
+	if !removed {
+		return p.Reactions, false, nil
+	}
+
+	counts, err := s.posts.AddReaction(ctx, postID, kind, -1)
+	return counts, false, err
+}
+
+// Mine returns the kinds the reactor has reacted with, keyed by post ID.
+func (s service) Mine(ctx context.Context, reactor string, postIDs []string) (map[string][]string, error) {
+	ctx, span := tracing.Start(ctx, "service.reaction.Mine")
+	defer span.End()
+
+	if reactor == "" || len(postIDs) == 0 {
+		return map[string][]string{}, nil
+	}
+	return s.repo.Mine(ctx, reactor, postIDs)
+}
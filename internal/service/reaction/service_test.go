@@ -0,0 +1,124 @@
+package reaction
+
+import (
+	"context"
+	"testing"
+
+	"news-svc/config"
+	"news-svc/internal/entity/post"
+	"news-svc/internal/entity/reaction"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type (
+	// memRepo keeps reactions in a set, like the unique index does.
+	memRepo struct {
+		seen map[[3]string]bool
+	}
+
+	mockPosts struct {
+		posts map[string]*post.Post
+	}
+)
+
+func (m *memRepo) Add(ctx context.Context, r *reaction.Reaction) (bool, error) {
+	key := [3]string{r.PostID, r.Reactor, r.Kind}
+	if m.seen[key] {
+		return false, nil
+	}
+	m.seen[key] = true
+	return true, nil
+}
+func (m *memRepo) Remove(ctx context.Context, postID, reactor, kind string) (bool, error) {
+	key := [3]string{postID, reactor, kind}
+	if !m.seen[key] {
+		return false, nil
+	}
+	delete(m.seen, key)
+	return true, nil
+}
+func (m *memRepo) Mine(ctx context.Context, reactor string, postIDs []string) (map[string][]string, error) {
+	mine := map[string][]string{}
+	for key := range m.seen {
+		if key[1] == reactor {
+			mine[key[0]] = append(mine[key[0]], key[2])
+		}
+	}
+	return mine, nil
+}
+
+func (m *mockPosts) GetByID(ctx context.Context, id string) (*post.Post, error) {
+	p, ok := m.posts[id]
+	if !ok {
+		return nil, config.ErrPostNotFound
+	}
+	return p, nil
+}
+func (m *mockPosts) AddReaction(ctx context.Context, id, kind string, delta int64) (map[string]int64, error) {
+	p := m.posts[id]
+	if p.Reactions == nil {
+		p.Reactions = map[string]int64{}
+	}
+	p.Reactions[kind] += delta
+	return p.Reactions, nil
+}
+
+var (
+	publishedID = bson.NewObjectID().Hex()
+	draftID     = bson.NewObjectID().Hex()
+)
+
+func newService() service {
+	return New(&memRepo{seen: map[[3]string]bool{}}, &mockPosts{posts: map[string]*post.Post{
+		publishedID: {ID: publishedID, Status: post.StatusPublished},
+		draftID:     {ID: draftID, Status: post.StatusDraft},
+	}})
+}
+
+func TestToggle(t *testing.T) {
+	ctx := context.Background()
+	svc := newService()
+
+	counts, on, err := svc.Toggle(ctx, publishedID, "like", "anon:a")
+	require.NoError(t, err)
+	assert.True(t, on)
+	assert.Equal(t, int64(1), counts["like"])
+
+	// a second reader adds to the count
+	counts, _, err = svc.Toggle(ctx, publishedID, "like", "user:bob")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), counts["like"])
+
+	// the same reader takes theirs back instead of counting twice
+	counts, on, err = svc.Toggle(ctx, publishedID, "like", "anon:a")
+	require.NoError(t, err)
+	assert.False(t, on)
+	assert.Equal(t, int64(1), counts["like"])
+
+	mine, err := svc.Mine(ctx, "user:bob", []string{publishedID})
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{publishedID: {"like"}}, mine)
+}
+
+func TestToggleErrors(t *testing.T) {
+	ctx := context.Background()
+	svc := newService()
+
+	_, _, err := svc.Toggle(ctx, publishedID, "angry", "anon:a")
+	assert.ErrorIs(t, err, config.ErrInvalidReaction)
+
+	_, _, err = svc.Toggle(ctx, draftID, "like", "anon:a")
+	assert.ErrorIs(t, err, config.ErrPostNotFound)
+
+	_, _, err = svc.Toggle(ctx, "nope", "like", "anon:a")
+	assert.ErrorIs(t, err, config.ErrPostNotFound)
+}
+
+func TestMineWithoutReactor(t *testing.T) {
+	mine, err := newService().Mine(context.Background(), "", []string{publishedID})
+	require.NoError(t, err)
+	assert.Empty(t, mine)
+}
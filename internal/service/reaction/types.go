@@ -0,0 +1,30 @@
+package reaction
+
+import (
+	"context"
+	"news-svc/internal/entity/post"
+	"news-svc/internal/entity/reaction"
+)
+
+type (
+	repository interface {
+		Add(ctx context.Context, r *reaction.Reaction) (bool, error)
+		Remove(ctx context.Context, postID, reactor, kind string) (bool, error)
+		Mine(ctx context.Context, reactor string, postIDs []string) (map[string][]string, error)
+	}
+
+	// posts is the part of the post repository reactions depend on.
+	posts interface {
+		GetByID(ctx context.Context, id string) (*post.Post, error)
+		AddReaction(ctx context.Context, id, kind string, delta int64) (map[string]int64, error)
+	}
+
+	service struct {
+		repo  repository
+		posts posts
+	}
+)
+
+func New(repo repository, posts posts) service {
+	return service{repo, posts}
+}
@@ -0,0 +1,186 @@
+package related
+
+import (
+	"context"
+	"regexp"
+	"slices"
+	"sort"
+
+	"news-svc/internal/entity/post"
+	"news-svc/pkg/tfidf"
+	"news-svc/pkg/tracing"
+)
+
+// tags strips markup from post content before it is compared.
+var tags = regexp.MustCompile(`<[^>]*>`)
+
+// Relate recomputes the related posts of the post with the given ID
+// after it has been created or updated. Drafts have none. Related posts
+// are a nicety, so failures are logged rather than failing the write.
+func (s service) Relate(ctx context.Context, id string) {
+	ctx, span := tracing.Start(ctx, "service.related.Relate")
+	defer span.End()
+
+	if err := s.relate(ctx, id); err != nil {
+		s.l.Error("compute related posts", "post", id, "err", err)
+	}
+}
+
+// Forget recomputes the related posts of every post that listed the
+// deleted post with the given ID.
+func (s service) Forget(ctx context.Context, id string) {
+	ctx, span := tracing.Start(ctx, "service.related.Forget")
+	defer span.End()
+
+	ids, err := s.posts.RelatedTo(ctx, id)
+	if err != nil {
+		s.l.Error("find posts related to deleted post", "post", id, "err", err)
+		return
+	}
+	for _, other := range ids {
+		s.Relate(ctx, other)
+	}
+}
+
+// RelateAll recomputes the related posts of every published post in the
+// corpus, e.g. after an import.
+func (s service) RelateAll(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "service.related.RelateAll")
+	defer span.End()
+
+	corpus, err := s.corpus(ctx)
+	if err != nil {
+		return err
+	}
+
+	vectors := vectorize(corpus)
+	for i, p := range corpus {
+		if err := s.posts.SetRelated(ctx, p.ID, rank(corpus, vectors, i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Load fills in RelatedPosts from the stored IDs, keeping their order and
+// leaving out posts that have since been unpublished or deleted.
+func (s service) Load(ctx context.Context, p *post.Post) error {
+	ctx, span := tracing.Start(ctx, "service.related.Load")
+	defer span.End()
+
+	if len(p.Related) == 0 {
+		return nil
+	}
+
+	found, err := s.posts.GetByIDs(ctx, p.Related)
+	if err != nil {
+		return err
+	}
+	byID := make(map[string]*post.Post, len(found))
+	for _, r := range found {
+		byID[r.ID] = r
+	}
+
+	p.RelatedPosts = p.RelatedPosts[:0]
+	for _, id := range p.Related {
+		if r, ok := byID[id]; ok && r.Status == post.StatusPublished {
+			p.RelatedPosts = append(p.RelatedPosts, r)
+		}
+	}
+	return nil
+}
+
+func (s service) relate(ctx context.Context, id string) error {
+	p, err := s.posts.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if p.Status != post.StatusPublished {
+		if len(p.Related) == 0 {
+			return nil
+		}
+		return s.posts.SetRelated(ctx, id, nil)
+	}
+
+	corpus, err := s.corpus(ctx)
+	if err != nil {
+		return err
+	}
+
+	// the post may be older than the corpus; it must be part of it so
+	// that its words count towards document frequencies
+	i := slices.IndexFunc(corpus, func(c *post.Post) bool { return c.ID == id })
+	if i < 0 {
+		corpus = append(corpus, p)
+		i = len(corpus) - 1
+	} else {
+		corpus[i] = p
+	}
+
+	return s.posts.SetRelated(ctx, id, rank(corpus, vectorize(corpus), i))
+}
+
+func (s service) corpus(ctx context.Context) ([]*post.Post, error) {
+	return s.posts.GetRecentBy(ctx, post.Filter{Status: post.StatusPublished}, candidateLimit)
+}
+
+// vectorize turns each post's title and text into a TF-IDF vector. The
+// title is counted twice as it says the most about the topic.
+func vectorize(corpus []*post.Post) []tfidf.Vector {
+	docs := make([][]string, len(corpus))
+	for i, p := range corpus {
+		title := tfidf.Tokenize(p.Title)
+		docs[i] = append(append(title, title...), tfidf.Tokenize(tags.ReplaceAllString(p.Content, " "))...)
+	}
+	return tfidf.Vectors(docs)
+}
+
+// rank returns the IDs of the posts most similar to corpus[i], best
+// first, newer posts first on ties.
+func rank(corpus []*post.Post, vectors []tfidf.Vector, i int) []string {
+	type scored struct {
+		post  *post.Post
+		score float64
+	}
+
+	target := corpus[i]
+	var candidates []scored
+	for j, p := range corpus {
+		if j == i || p.ID == target.ID {
+			continue
+		}
+		sc := score(target, p, tfidf.Cosine(vectors[i], vectors[j]))
+		if sc >= minScore {
+			candidates = append(candidates, scored{p, sc})
+		}
+	}
+	sort.Slice(candidates, func(a, b int) bool {
+		if candidates[a].score != candidates[b].score {
+			return candidates[a].score > candidates[b].score
+		}
+		return candidates[a].post.CreatedAt.After(candidates[b].post.CreatedAt)
+	})
+
+	ids := make([]string, 0, min(len(candidates), Size))
+	for _, c := range candidates[:min(len(candidates), Size)] {
+		ids = append(ids, c.post.ID)
+	}
+	return ids
+}
+
+// score combines shared tags, shared categories and text similarity.
+func score(a, b *post.Post, similarity float64) float64 {
+	return tagWeight*float64(shared(a.Tags, b.Tags)) +
+		categoryWeight*float64(shared(a.Categories, b.Categories)) +
+		textWeight*similarity
+}
+
+func shared(a, b []string) int {
+	n := 0
+	for _, x := range a {
+		if slices.Contains(b, x) {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,149 @@
+package related
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+
+	"news-svc/config"
+	"news-svc/internal/entity/post"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memPosts stores posts the way the Mongo repo does.
+type memPosts struct {
+	posts []*post.Post
+}
+
+func (m *memPosts) get(id string) *post.Post {
+	for _, p := range m.posts {
+		if p.ID == id {
+			return p
+		}
+	}
+	return nil
+}
+
+func (m *memPosts) GetByID(ctx context.Context, id string) (*post.Post, error) {
+	if p := m.get(id); p != nil {
+		cp := *p
+		return &cp, nil
+	}
+	return nil, config.ErrPostNotFound
+}
+func (m *memPosts) GetByIDs(ctx context.Context, ids []string) ([]*post.Post, error) {
+	var posts []*post.Post
+	for _, id := range ids {
+		if p := m.get(id); p != nil {
+			posts = append(posts, p)
+		}
+	}
+	return posts, nil
+}
+func (m *memPosts) GetRecentBy(ctx context.Context, f post.Filter, limit int64) ([]*post.Post, error) {
+	var posts []*post.Post
+	for _, p := range m.posts {
+		if p.Status == f.Status {
+			cp := *p
+			posts = append(posts, &cp)
+		}
+	}
+	return posts, nil
+}
+func (m *memPosts) SetRelated(ctx context.Context, id string, related []string) error {
+	p := m.get(id)
+	if p == nil {
+		return config.ErrPostNotFound
+	}
+	p.Related = related
+	return nil
+}
+func (m *memPosts) RelatedTo(ctx context.Context, id string) ([]string, error) {
+	var ids []string
+	for _, p := range m.posts {
+		if slices.Contains(p.Related, id) {
+			ids = append(ids, p.ID)
+		}
+	}
+	return ids, nil
+}
+
+func newService(posts ...*post.Post) (service, *memPosts) {
+	m := &memPosts{posts: posts}
+	return New(m, slog.New(slog.NewTextHandler(io.Discard, nil))), m
+}
+
+func published(id, title, content string, tags ...string) *post.Post {
+	return &post.Post{ID: id, Title: title, Content: content, Tags: tags, Status: post.StatusPublished, CreatedAt: time.Now()}
+}
+
+func TestScore(t *testing.T) {
+	a := &post.Post{Tags: []string{"go", "mongo"}, Categories: []string{"tech"}}
+	b := &post.Post{Tags: []string{"mongo", "go"}, Categories: []string{"tech", "news"}}
+	c := &post.Post{Tags: []string{"cooking"}}
+
+	assert.Equal(t, 2*tagWeight+categoryWeight, score(a, b, 0))
+	assert.Equal(t, textWeight/2, score(a, c, 0.5))
+	assert.Zero(t, score(a, c, 0))
+}
+
+func TestRelate(t *testing.T) {
+	svc, m := newService(
+		published("budget", "Council approves city budget", "The council voted on the budget for schools and roads."),
+		published("vote", "Budget vote delayed", "Council members delayed the budget vote over road repairs."),
+		published("tagged", "Mayor speaks", "A short statement.", "council"),
+		published("football", "Local team wins cup", "Football fans celebrate the cup final."),
+		&post.Post{ID: "draft", Title: "Council budget draft", Content: "Council budget budget.", Status: post.StatusDraft},
+	)
+	m.get("budget").Tags = []string{"council"}
+
+	svc.Relate(context.Background(), "budget")
+
+	assert.Equal(t, []string{"vote", "tagged"}, m.get("budget").Related)
+}
+
+func TestRelateClearsDrafts(t *testing.T) {
+	svc, m := newService(&post.Post{ID: "d", Status: post.StatusDraft, Related: []string{"x"}})
+
+	svc.Relate(context.Background(), "d")
+
+	assert.Empty(t, m.get("d").Related)
+}
+
+func TestForget(t *testing.T) {
+	svc, m := newService(
+		published("a", "Election results announced", "Election night results."),
+		published("b", "Election turnout record", "Turnout at the election was a record."),
+		published("c", "Election recount ordered", "A recount of the election results."),
+	)
+	require.NoError(t, svc.RelateAll(context.Background()))
+	require.Contains(t, m.get("a").Related, "c")
+
+	m.posts = slices.DeleteFunc(m.posts, func(p *post.Post) bool { return p.ID == "c" })
+	svc.Forget(context.Background(), "c")
+
+	assert.Equal(t, []string{"b"}, m.get("a").Related)
+	assert.Equal(t, []string{"a"}, m.get("b").Related)
+}
+
+func TestLoad(t *testing.T) {
+	svc, _ := newService(
+		published("a", "A", "a"),
+		&post.Post{ID: "draft", Status: post.StatusDraft},
+		published("b", "B", "b"),
+	)
+
+	p := &post.Post{Related: []string{"b", "gone", "draft", "a"}}
+	require.NoError(t, svc.Load(context.Background(), p))
+
+	var ids []string
+	for _, r := range p.RelatedPosts {
+		ids = append(ids, r.ID)
+	}
+	assert.Equal(t, []string{"b", "a"}, ids)
+}
@@ -0,0 +1,46 @@
+package related
+
+import (
+	"context"
+	"log/slog"
+	"news-svc/internal/entity/post"
+)
+
+const (
+	// Size is how many related posts are kept per post.
+	Size = 5
+	// candidateLimit caps the corpus to the newest published posts.
+	candidateLimit = 2000
+)
+
+// Weights of the similarity signals. A shared tag is a strong hint, a
+// shared category a weaker one, and text similarity (0 to 1) carries
+// the most weight so that untagged posts still find their match.
+const (
+	tagWeight      = 1.0
+	categoryWeight = 0.5
+	textWeight     = 4.0
+	// minScore keeps posts that share only a stray word out of the list.
+	minScore = 0.2
+)
+
+type (
+	// posts is the part of the post repository related posts are
+	// computed from and stored in.
+	posts interface {
+		GetByID(ctx context.Context, id string) (*post.Post, error)
+		GetByIDs(ctx context.Context, ids []string) ([]*post.Post, error)
+		GetRecentBy(ctx context.Context, f post.Filter, limit int64) ([]*post.Post, error)
+		SetRelated(ctx context.Context, id string, related []string) error
+		RelatedTo(ctx context.Context, id string) ([]string, error)
+	}
+
+	service struct {
+		posts posts
+		l     *slog.Logger
+	}
+)
+
+func New(posts posts, l *slog.Logger) service {
+	return service{posts, l}
+}
@@ -0,0 +1,250 @@
+package trending
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"news-svc/config"
+	"news-svc/internal/entity/post"
+	"news-svc/pkg/tracing"
+)
+
+// Start launches the background recomputation. Rankings are computed
+// once up front so the first pages served already have them.
+func (s *service) Start() {
+	s.startOnce.Do(func() {
+		s.started.Store(true)
+		go s.run()
+	})
+}
+
+// Check reports an error once the background recomputation has stopped. Nothing is
+// checked before Start.
+func (s *service) Check(ctx context.Context) error {
+	if !s.started.Load() {
+		return nil
+	}
+
+	select {
+	case <-s.done:
+		return config.ErrWorkerStopped
+	default:
+		return nil
+	}
+}
+
+// Close stops the background recomputation, or gives up when ctx is done.
+func (s *service) Close(ctx context.Context) error {
+	s.stopOnce.Do(func() { close(s.stop) })
+	if !s.started.Load() {
+		return nil
+	}
+
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Trending returns up to limit published posts ranked by a time-decayed
+// score of their recent views and reactions.
+func (s *service) Trending(ctx context.Context, limit int) ([]*post.Post, error) {
+	ctx, span := tracing.Start(ctx, "service.trending.Trending")
+	defer span.End()
+
+	r, err := s.current(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return head(r.trending, limit), nil
+}
+
+// MostRead returns up to limit published posts with the most views in
+// the last seven days.
+func (s *service) MostRead(ctx context.Context, limit int) ([]*post.Post, error) {
+	ctx, span := tracing.Start(ctx, "service.trending.MostRead")
+	defer span.End()
+
+	r, err := s.current(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return head(r.mostRead, limit), nil
+}
+
+// Refresh recomputes the rankings and replaces the cached ones.
+func (s *service) Refresh(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "service.trending.Refresh")
+	defer span.End()
+
+	s.refresh.Lock()
+	defer s.refresh.Unlock()
+
+	now := s.now()
+	trending, err := s.trending(ctx, now)
+	if err != nil {
+		return err
+	}
+	mostRead, err := s.mostRead(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	s.rankings.Store(&rankings{trending: trending, mostRead: mostRead})
+	return nil
+}
+
+// current returns the cached rankings, computing them first when the
+// background recomputation has not run yet.
+func (s *service) current(ctx context.Context) (*rankings, error) {
+	if r := s.rankings.Load(); r != nil {
+		return r, nil
+	}
+	if err := s.Refresh(ctx); err != nil {
+		return nil, err
+	}
+	return s.rankings.Load(), nil
+}
+
+func (s *service) run() {
+	defer close(s.done)
+
+	s.refreshLogged()
+
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.refreshLogged()
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+func (s *service) refreshLogged() {
+	ctx, cancel := context.WithTimeout(context.Background(), refreshTimeout)
+	defer cancel()
+
+	if err := s.Refresh(ctx); err != nil {
+		s.l.Error("refresh trending posts", "error", err)
+	}
+}
+
+// trending scores the posts published within the window together with
+// the most viewed ones, which may be older.
+func (s *service) trending(ctx context.Context, now time.Time) ([]*post.Post, error) {
+	from := now.Add(-s.cfg.Window)
+
+	recent, err := s.posts.GetRecentBy(ctx, post.Filter{From: from, Status: post.StatusPublished}, candidateLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := s.views.Top(ctx, from, now, candidateLimit)
+	if err != nil {
+		return nil, err
+	}
+	views := make(map[string]int64, len(stats))
+	for _, st := range stats {
+		views[st.PostID] = st.Views
+	}
+
+	candidates := make(map[string]*post.Post, len(recent)+len(stats))
+	for _, p := range recent {
+		candidates[p.ID] = p
+	}
+	var missing []string
+	for _, st := range stats {
+		if _, ok := candidates[st.PostID]; !ok {
+			missing = append(missing, st.PostID)
+		}
+	}
+	if len(missing) > 0 {
+		viewed, err := s.posts.GetByIDs(ctx, missing)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range viewed {
+			if p.Status == post.StatusPublished {
+				candidates[p.ID] = p
+			}
+		}
+	}
+
+	type scored struct {
+		post  *post.Post
+		score float64
+	}
+	ranked := make([]scored, 0, len(candidates))
+	for _, p := range candidates {
+		sc := score(views[p.ID], p.ReactionCount, now.Sub(p.CreatedAt), s.cfg.Gravity, s.cfg.ReactionWeight)
+		if sc > 0 {
+			ranked = append(ranked, scored{p, sc})
+		}
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].score != ranked[j].score {
+			return ranked[i].score > ranked[j].score
+		}
+		return ranked[i].post.CreatedAt.After(ranked[j].post.CreatedAt)
+	})
+
+	posts := make([]*post.Post, 0, min(len(ranked), rankingSize))
+	for _, r := range head(ranked, rankingSize) {
+		posts = append(posts, r.post)
+	}
+	return posts, nil
+}
+
+// mostRead keeps the published posts among the most viewed of the week,
+// in order of views.
+func (s *service) mostRead(ctx context.Context, now time.Time) ([]*post.Post, error) {
+	// drafts and deleted posts are skipped, so ask for a few more
+	stats, err := s.views.Top(ctx, now.Add(-mostReadWindow), now, 2*rankingSize)
+	if err != nil || len(stats) == 0 {
+		return nil, err
+	}
+
+	ids := make([]string, len(stats))
+	for i, st := range stats {
+		ids[i] = st.PostID
+	}
+	found, err := s.posts.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]*post.Post, len(found))
+	for _, p := range found {
+		byID[p.ID] = p
+	}
+
+	posts := make([]*post.Post, 0, rankingSize)
+	for _, id := range ids {
+		if p, ok := byID[id]; ok && p.Status == post.StatusPublished {
+			posts = append(posts, p)
+		}
+	}
+	return head(posts, rankingSize), nil
+}
+
+// score is a Hacker News style ranking: the post's points decay with age
+// so that fresh posts with some attention outrank old favourites.
+func score(views, reactions int64, age time.Duration, gravity, reactionWeight float64) float64 {
+	points := float64(views) + reactionWeight*float64(reactions)
+	hours := max(age.Hours(), 0)
+	return points / math.Pow(hours+2, gravity)
+}
+
+func head[T any](s []T, n int) []T {
+	if n >= 0 && n < len(s) {
+		return s[:n]
+	}
+	return s
+}
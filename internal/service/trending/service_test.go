@@ -0,0 +1,165 @@
+package trending
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"news-svc/config"
+	"news-svc/internal/entity/analytics"
+	"news-svc/internal/entity/post"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var now = time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+
+type (
+	memPosts struct {
+		posts []*post.Post
+	}
+
+	memViews struct {
+		top   []analytics.PostStats
+		calls int
+	}
+)
+
+func (m *memPosts) GetRecentBy(ctx context.Context, f post.Filter, limit int64) ([]*post.Post, error) {
+	var posts []*post.Post
+	for _, p := range m.posts {
+		if p.Status == f.Status && !p.CreatedAt.Before(f.From) {
+			posts = append(posts, p)
+		}
+	}
+	return posts, nil
+}
+func (m *memPosts) GetByIDs(ctx context.Context, ids []string) ([]*post.Post, error) {
+	var posts []*post.Post
+	for _, p := range m.posts {
+		for _, id := range ids {
+			if p.ID == id {
+				posts = append(posts, p)
+			}
+		}
+	}
+	return posts, nil
+}
+
+func (m *memViews) Top(ctx context.Context, from, to time.Time, limit int64) ([]analytics.PostStats, error) {
+	m.calls++
+	return m.top, nil
+}
+
+func newService(posts *memPosts, views *memViews) *service {
+	cfg := config.Trending{Interval: time.Hour, Window: 72 * time.Hour, Gravity: 1.8, ReactionWeight: 3}
+	s := New(posts, views, cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	s.now = func() time.Time { return now }
+	return s
+}
+
+func published(id string, age time.Duration, reactions int64) *post.Post {
+	return &post.Post{ID: id, Title: id, Status: post.StatusPublished, CreatedAt: now.Add(-age), ReactionCount: reactions}
+}
+
+func TestScore(t *testing.T) {
+	assert.Zero(t, score(0, 0, time.Hour, 1.8, 3))
+	assert.Equal(t, 5/4.0, score(2, 1, 0, 2, 3))
+
+	// the same attention is worth less the older the post is
+	assert.Greater(t, score(10, 0, time.Hour, 1.8, 3), score(10, 0, 10*time.Hour, 1.8, 3))
+	// and reactions count for more than views
+	assert.Greater(t, score(0, 1, time.Hour, 1.8, 3), score(1, 0, time.Hour, 1.8, 3))
+	// posts dated in the future are treated as brand new
+	assert.Equal(t, score(1, 0, 0, 1.8, 3), score(1, 0, -time.Hour, 1.8, 3))
+}
+
+func TestTrending(t *testing.T) {
+	posts := &memPosts{posts: []*post.Post{
+		published("fresh", time.Hour, 2),
+		published("stale", 48*time.Hour, 0),
+		published("old", 30*24*time.Hour, 0),
+		published("quiet", time.Hour, 0),
+		{ID: "draft", Status: post.StatusDraft, CreatedAt: now},
+	}}
+	views := &memViews{top: []analytics.PostStats{
+		{PostID: "old", Views: 5000},
+		{PostID: "stale", Views: 100},
+		{PostID: "fresh", Views: 10},
+		{PostID: "draft", Views: 1000},
+	}}
+	s := newService(posts, views)
+
+	got, err := s.Trending(context.Background(), 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"fresh", "stale", "old"}, ids(got))
+
+	got, err = s.Trending(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"fresh"}, ids(got))
+}
+
+func TestMostRead(t *testing.T) {
+	posts := &memPosts{posts: []*post.Post{
+		published("a", time.Hour, 0),
+		published("b", 30*24*time.Hour, 0),
+		{ID: "draft", Status: post.StatusDraft, CreatedAt: now},
+	}}
+	views := &memViews{top: []analytics.PostStats{
+		{PostID: "b", Views: 50},
+		{PostID: "draft", Views: 40},
+		{PostID: "deleted", Views: 30},
+		{PostID: "a", Views: 20},
+	}}
+	s := newService(posts, views)
+
+	got, err := s.MostRead(context.Background(), 5)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b", "a"}, ids(got))
+}
+
+func TestRankingsAreCached(t *testing.T) {
+	posts := &memPosts{posts: []*post.Post{published("a", time.Hour, 1)}}
+	views := &memViews{}
+	s := newService(posts, views)
+
+	_, err := s.Trending(context.Background(), 5)
+	require.NoError(t, err)
+	_, err = s.MostRead(context.Background(), 5)
+	require.NoError(t, err)
+	assert.Equal(t, 2, views.calls)
+
+	posts.posts = append(posts.posts, published("b", 0, 10))
+	got, err := s.Trending(context.Background(), 5)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a"}, ids(got))
+
+	require.NoError(t, s.Refresh(context.Background()))
+	got, err = s.Trending(context.Background(), 5)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b", "a"}, ids(got))
+}
+
+func TestStartClose(t *testing.T) {
+	s := newService(&memPosts{posts: []*post.Post{published("a", time.Hour, 1)}}, &memViews{})
+	s.Start()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, s.Close(ctx))
+
+	got, err := s.Trending(context.Background(), 5)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a"}, ids(got))
+}
+
+func ids(posts []*post.Post) []string {
+	ids := make([]string, len(posts))
+	for i, p := range posts {
+		ids[i] = p.ID
+	}
+	return ids
+}
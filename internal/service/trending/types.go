@@ -0,0 +1,73 @@
+package trending
+
+import (
+	"context"
+	"log/slog"
+	"news-svc/config"
+	"news-svc/internal/entity/analytics"
+	"news-svc/internal/entity/post"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// rankingSize is how many posts each cached ranking keeps.
+	rankingSize = 20
+	// candidateLimit caps the posts scored per refresh, from each of
+	// the newest and the most viewed.
+	candidateLimit = 500
+	// mostReadWindow is the period of the "most read" ranking.
+	mostReadWindow = 7 * 24 * time.Hour
+	// refreshTimeout bounds a single recomputation.
+	refreshTimeout = time.Minute
+)
+
+type (
+	// posts is the part of the post repository rankings depend on.
+	posts interface {
+		GetRecentBy(ctx context.Context, f post.Filter, limit int64) ([]*post.Post, error)
+		GetByIDs(ctx context.Context, ids []string) ([]*post.Post, error)
+	}
+
+	// views is the part of the analytics repository rankings depend on.
+	views interface {
+		Top(ctx context.Context, from, to time.Time, limit int64) ([]analytics.PostStats, error)
+	}
+
+	// service keeps rankings in memory and recomputes them in the
+	// background, so sidebars never wait for the aggregation.
+	service struct {
+		posts posts
+		views views
+		cfg   config.Trending
+		l     *slog.Logger
+		now   func() time.Time
+
+		rankings atomic.Pointer[rankings]
+		refresh  sync.Mutex
+
+		stop      chan struct{}
+		done      chan struct{}
+		startOnce sync.Once
+		stopOnce  sync.Once
+		started   atomic.Bool
+	}
+
+	rankings struct {
+		trending []*post.Post
+		mostRead []*post.Post
+	}
+)
+
+func New(posts posts, views views, cfg config.Trending, l *slog.Logger) *service {
+	return &service{
+		posts: posts,
+		views: views,
+		cfg:   cfg,
+		l:     l,
+		now:   time.Now,
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+}
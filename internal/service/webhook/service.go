@@ -0,0 +1,386 @@
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"news-svc/config"
+	"news-svc/internal/entity/event"
+	"news-svc/internal/entity/post"
+	"news-svc/internal/entity/webhook"
+	"news-svc/pkg/metrics"
+	"news-svc/pkg/tracing"
+)
+
+// Create registers a webhook. It is active right away and signs with a
+// generated secret unless one is given.
+func (s *service) Create(ctx context.Context, w *webhook.Webhook) (string, error) {
+	ctx, span := tracing.Start(ctx, "service.webhook.Create")
+	defer span.End()
+
+	if err := w.Validate(); err != nil {
+		return "", err
+	}
+
+	if w.Secret == "" {
+		b := make([]byte, secretBytes)
+		if _, err := rand.Read(b); err != nil {
+			return "", err
+		}
+		w.Secret = hex.EncodeToString(b)
+	}
+	w.Active = true
+	w.CreatedAt = s.now()
+
+	return s.repo.Create(ctx, w)
+}
+
+func (s *service) GetByID(ctx context.Context, id string) (*webhook.Webhook, error) {
+	ctx, span := tracing.Start(ctx, "service.webhook.GetByID")
+	defer span.End()
+
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *service) GetAll(ctx context.Context) ([]*webhook.Webhook, error) {
+	ctx, span := tracing.Start(ctx, "service.webhook.GetAll")
+	defer span.End()
+
+	return s.repo.GetAll(ctx)
+}
+
+func (s *service) SetActive(ctx context.Context, id string, active bool) error {
+	ctx, span := tracing.Start(ctx, "service.webhook.SetActive")
+	defer span.End()
+
+	return s.repo.SetActive(ctx, id, active)
+}
+
+func (s *service) Delete(ctx context.Context, id string) error {
+	ctx, span := tracing.Start(ctx, "service.webhook.Delete")
+	defer span.End()
+
+	return s.repo.Delete(ctx, id)
+}
+
+func (s *service) Deliveries(ctx context.Context, webhookID string, page, limit int64) ([]*webhook.Delivery, int64, error) {
+	ctx, span := tracing.Start(ctx, "service.webhook.Deliveries")
+	defer span.End()
+
+	return s.repo.Deliveries(ctx, webhookID, page, limit)
+}
+
+// Replay queues the payload of a past delivery again, signed with the
+// webhook's current secret.
+func (s *service) Replay(ctx context.Context, deliveryID string) (*webhook.Delivery, error) {
+	ctx, span := tracing.Start(ctx, "service.webhook.Replay")
+	defer span.End()
+
+	d, err := s.repo.GetDelivery(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.repo.GetByID(ctx, d.WebhookID); err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	replay := &webhook.Delivery{
+		WebhookID:     d.WebhookID,
+		EventID:       d.EventID + ":replay:" + event.NewID(),
+		Event:         d.Event,
+		Payload:       d.Payload,
+		Status:        webhook.DeliveryPending,
+		NextAttemptAt: now,
+		ReplayOf:      d.ID,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if err := s.repo.Enqueue(ctx, []*webhook.Delivery{replay}); err != nil {
+		return nil, err
+	}
+
+	s.notify()
+	return replay, nil
+}
+
+// Enqueue queues a delivery of e for every active webhook subscribed to
+// it. Events readers cannot see, such as draft saves, are skipped.
+func (s *service) Enqueue(ctx context.Context, e event.Event) error {
+	ctx, span := tracing.Start(ctx, "service.webhook.Enqueue")
+	defer span.End()
+
+	name := eventName(e)
+	if name == "" {
+		return nil
+	}
+
+	hooks, err := s.repo.Subscribed(ctx, name)
+	if err != nil || len(hooks) == 0 {
+		return err
+	}
+
+	payload, err := json.Marshal(s.payload(name, e))
+	if err != nil {
+		return err
+	}
+
+	now := s.now()
+	deliveries := make([]*webhook.Delivery, len(hooks))
+	for i, h := range hooks {
+		deliveries[i] = &webhook.Delivery{
+			WebhookID:     h.ID,
+			EventID:       e.ID,
+			Event:         name,
+			Payload:       string(payload),
+			Status:        webhook.DeliveryPending,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		}
+	}
+	if err := s.repo.Enqueue(ctx, deliveries); err != nil {
+		return err
+	}
+
+	s.notify()
+	return nil
+}
+
+// eventName maps a post event to the webhook event it stands for, or ""
+// when no webhook should hear about it.
+func eventName(e event.Event) string {
+	switch {
+	case e.Type == event.PostDeleted:
+		return webhook.EventDeleted
+	case e.Post == nil || e.Post.Status != post.StatusPublished:
+		return ""
+	case e.Published:
+		return webhook.EventPublished
+	case e.Type == event.PostUpdated:
+		return webhook.EventUpdated
+	default:
+		return ""
+	}
+}
+
+func (s *service) payload(name string, e event.Event) webhook.Payload {
+	p := webhook.Payload{
+		ID:        e.ID,
+		Event:     name,
+		CreatedAt: e.At,
+		Post:      webhook.PostPayload{ID: e.PostID},
+	}
+	if e.Post != nil && name != webhook.EventDeleted {
+		p.Post = webhook.PostPayload{
+			ID:         e.Post.ID,
+			URL:        s.site.URL + "/posts/" + e.Post.ID,
+			Slug:       e.Post.Slug,
+			Title:      e.Post.Title,
+			Summary:    e.Post.Summary,
+			Status:     e.Post.Status,
+			Author:     e.Post.Author,
+			Tags:       e.Post.Tags,
+			Categories: e.Post.Categories,
+			CreatedAt:  e.Post.CreatedAt,
+			UpdatedAt:  e.Post.UpdatedAt,
+		}
+	}
+	return p
+}
+
+// notify wakes a worker without waiting for the next poll.
+func (s *service) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Start launches the delivery workers.
+func (s *service) Start() {
+	s.startOnce.Do(func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		s.cancel = cancel
+		s.started.Store(true)
+
+		var wg sync.WaitGroup
+		for range max(s.cfg.Workers, 1) {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				s.run(ctx)
+			}()
+		}
+		go func() {
+			wg.Wait()
+			close(s.done)
+		}()
+	})
+}
+
+// Check reports an error once the delivery workers have stopped. Nothing is
+// checked before Start.
+func (s *service) Check(ctx context.Context) error {
+	if !s.started.Load() {
+		return nil
+	}
+
+	select {
+	case <-s.done:
+		return config.ErrWorkerStopped
+	default:
+		return nil
+	}
+}
+
+// Close stops the workers. Requests in flight are abandoned and their
+// deliveries sent again once their lease runs out.
+func (s *service) Close(ctx context.Context) error {
+	s.stopOnce.Do(func() { s.cancel() })
+	if !s.started.Load() {
+		return nil
+	}
+
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *service) run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		s.drain(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+// drain sends due deliveries until none are left.
+func (s *service) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		d, err := s.repo.Claim(ctx, s.now(), s.cfg.Timeout+leaseMargin)
+		if err != nil {
+			if ctx.Err() == nil {
+				s.l.Error("unable to claim webhook delivery", "err", err)
+			}
+			return
+		}
+		if d == nil {
+			return
+		}
+		s.deliver(ctx, d)
+	}
+}
+
+// deliver makes one attempt at a claimed delivery and records it.
+func (s *service) deliver(ctx context.Context, d *webhook.Delivery) {
+	var a webhook.Attempt
+	retry := false
+	hook, err := s.repo.GetByID(ctx, d.WebhookID)
+	switch {
+	case errors.Is(err, config.ErrWebhookNotFound):
+		a = webhook.Attempt{At: s.now(), Error: "webhook was deleted"}
+	case err != nil:
+		s.l.Error("unable to load webhook", "webhook", d.WebhookID, "err", err)
+		return
+	case !hook.Active:
+		a = webhook.Attempt{At: s.now(), Error: "webhook is disabled"}
+	default:
+		a = s.send(ctx, hook, d)
+		if ctx.Err() != nil {
+			// shutting down; the lease runs out and the delivery is retried
+			return
+		}
+		retry = true
+	}
+
+	status, next := s.outcome(d, a, retry)
+	if err := s.repo.Record(ctx, d.ID, a, status, next); err != nil {
+		s.l.Error("unable to record webhook delivery", "delivery", d.ID, "err", err)
+		return
+	}
+	metrics.CountDelivery(status)
+	if status == webhook.DeliveryFailed {
+		s.l.Warn("webhook delivery failed", "delivery", d.ID, "webhook", d.WebhookID, "attempts", len(d.Attempts)+1)
+	}
+}
+
+// send posts the payload to the webhook.
+func (s *service) send(ctx context.Context, hook *webhook.Webhook, d *webhook.Delivery) webhook.Attempt {
+	start := s.now()
+	a := webhook.Attempt{At: start}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, strings.NewReader(d.Payload))
+	if err != nil {
+		a.Error = err.Error()
+		return a
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "news-svc-webhooks")
+	req.Header.Set(webhook.EventHeader, d.Event)
+	req.Header.Set(webhook.DeliveryHeader, d.ID)
+	req.Header.Set(webhook.SignatureHeader, webhook.Signature(hook.Secret, []byte(d.Payload)))
+
+	resp, err := s.client.Do(req)
+	a.Duration = s.now().Sub(start)
+	if err != nil {
+		a.Error = err.Error()
+		return a
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponse))
+
+	a.StatusCode = resp.StatusCode
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		a.Error = fmt.Sprintf("unexpected status %s", resp.Status)
+	}
+	return a
+}
+
+// outcome decides what happens to a delivery after attempt a: done on
+// a 2xx, otherwise retried with exponential backoff until the attempts
+// run out. Without retry, e.g. for a disabled webhook, it fails right
+// away.
+func (s *service) outcome(d *webhook.Delivery, a webhook.Attempt, retry bool) (string, time.Time) {
+	switch {
+	case a.StatusCode >= 200 && a.StatusCode <= 299:
+		return webhook.DeliverySucceeded, a.At
+	case !retry:
+		return webhook.DeliveryFailed, a.At
+	}
+
+	n := len(d.Attempts) + 1
+	if n >= s.cfg.MaxAttempts {
+		return webhook.DeliveryFailed, a.At
+	}
+	return webhook.DeliveryPending, a.At.Add(s.backoff(n))
+}
+
+// backoff returns the wait after the nth failed attempt.
+func (s *service) backoff(n int) time.Duration {
+	d := s.cfg.RetryBase
+	for i := 1; i < n && d < s.cfg.RetryMax; i++ {
+		d *= 2
+	}
+	return min(d, s.cfg.RetryMax)
+}
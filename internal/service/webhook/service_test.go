@@ -0,0 +1,412 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"news-svc/config"
+	"news-svc/internal/entity/event"
+	"news-svc/internal/entity/post"
+	"news-svc/internal/entity/webhook"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var t0 = time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+
+type memRepo struct {
+	mu         sync.Mutex
+	hooks      map[string]*webhook.Webhook
+	deliveries []*webhook.Delivery
+	leased     map[string]time.Time
+}
+
+func newMemRepo() *memRepo {
+	return &memRepo{hooks: map[string]*webhook.Webhook{}, leased: map[string]time.Time{}}
+}
+
+func (m *memRepo) Create(ctx context.Context, w *webhook.Webhook) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	w.ID = fmt.Sprintf("%024d", len(m.hooks)+1)
+	m.hooks[w.ID] = w
+	return w.ID, nil
+}
+func (m *memRepo) GetByID(ctx context.Context, id string) (*webhook.Webhook, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	w, ok := m.hooks[id]
+	if !ok {
+		return nil, config.ErrWebhookNotFound
+	}
+	c := *w
+	return &c, nil
+}
+func (m *memRepo) GetAll(ctx context.Context) (hooks []*webhook.Webhook, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, w := range m.hooks {
+		hooks = append(hooks, w)
+	}
+	return hooks, nil
+}
+func (m *memRepo) Subscribed(ctx context.Context, event string) (hooks []*webhook.Webhook, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, w := range m.hooks {
+		if w.Active && w.Subscribed(event) {
+			hooks = append(hooks, w)
+		}
+	}
+	return hooks, nil
+}
+func (m *memRepo) SetActive(ctx context.Context, id string, active bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	w, ok := m.hooks[id]
+	if !ok {
+		return config.ErrWebhookNotFound
+	}
+	w.Active = active
+	return nil
+}
+func (m *memRepo) Delete(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.hooks, id)
+	return nil
+}
+func (m *memRepo) Enqueue(ctx context.Context, deliveries []*webhook.Delivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+next:
+	for _, d := range deliveries {
+		for _, old := range m.deliveries {
+			if old.WebhookID == d.WebhookID && old.EventID == d.EventID {
+				continue next
+			}
+		}
+		d.ID = fmt.Sprintf("d%d", len(m.deliveries)+1)
+		c := *d
+		m.deliveries = append(m.deliveries, &c)
+	}
+	return nil
+}
+func (m *memRepo) Claim(ctx context.Context, now time.Time, lease time.Duration) (*webhook.Delivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, d := range m.deliveries {
+		if d.Status == webhook.DeliveryPending && !d.NextAttemptAt.After(now) && !m.leased[d.ID].After(now) {
+			m.leased[d.ID] = now.Add(lease)
+			c := *d
+			return &c, nil
+		}
+	}
+	return nil, nil
+}
+func (m *memRepo) Record(ctx context.Context, id string, a webhook.Attempt, status string, next time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, d := range m.deliveries {
+		if d.ID == id {
+			d.Attempts = append(d.Attempts, a)
+			d.Status = status
+			d.NextAttemptAt = next
+			delete(m.leased, id)
+			return nil
+		}
+	}
+	return config.ErrDeliveryNotFound
+}
+func (m *memRepo) GetDelivery(ctx context.Context, id string) (*webhook.Delivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, d := range m.deliveries {
+		if d.ID == id {
+			c := *d
+			return &c, nil
+		}
+	}
+	return nil, config.ErrDeliveryNotFound
+}
+func (m *memRepo) Deliveries(ctx context.Context, webhookID string, page, limit int64) (ds []*webhook.Delivery, total int64, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, d := range m.deliveries {
+		if d.WebhookID == webhookID {
+			ds = append(ds, d)
+		}
+	}
+	return ds, int64(len(ds)), nil
+}
+func (m *memRepo) delivery(i int) webhook.Delivery {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return *m.deliveries[i]
+}
+
+var testCfg = config.Webhooks{
+	Workers:      1,
+	PollInterval: time.Hour,
+	MaxAttempts:  3,
+	RetryBase:    time.Minute,
+	RetryMax:     3 * time.Minute,
+	Timeout:      time.Second,
+}
+
+func newTestService(repo *memRepo) *service {
+	s := New(repo, testCfg, config.Site{URL: "https://news.example"}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	s.now = func() time.Time { return t0 }
+	return s
+}
+
+func publishedPost(id string) *post.Post {
+	return &post.Post{ID: id, Title: "Hello", Slug: "hello", Status: post.StatusPublished, Tags: []string{"go"}}
+}
+
+func TestCreate(t *testing.T) {
+	s := newTestService(newMemRepo())
+	ctx := context.Background()
+
+	_, err := s.Create(ctx, &webhook.Webhook{URL: "ftp://example.com", Events: []string{webhook.EventPublished}})
+	assert.ErrorIs(t, err, config.ErrInvalidWebhookURL)
+
+	w := &webhook.Webhook{URL: "https://example.com/hook", Events: []string{webhook.EventPublished}}
+	_, err = s.Create(ctx, w)
+	require.NoError(t, err)
+	assert.True(t, w.Active)
+	assert.Len(t, w.Secret, 2*secretBytes)
+	assert.Equal(t, t0, w.CreatedAt)
+}
+
+func TestEventName(t *testing.T) {
+	draft := &post.Post{ID: "1", Status: post.StatusDraft}
+	tests := []struct {
+		name string
+		e    event.Event
+		want string
+	}{
+		{"published on create", event.Event{Type: event.PostCreated, Post: publishedPost("1"), Published: true}, webhook.EventPublished},
+		{"published on update", event.Event{Type: event.PostUpdated, Post: publishedPost("1"), Published: true}, webhook.EventPublished},
+		{"edit of a published post", event.Event{Type: event.PostUpdated, Post: publishedPost("1")}, webhook.EventUpdated},
+		{"draft created", event.Event{Type: event.PostCreated, Post: draft}, ""},
+		{"draft edited", event.Event{Type: event.PostUpdated, Post: draft}, ""},
+		{"deleted", event.Event{Type: event.PostDeleted, PostID: "1"}, webhook.EventDeleted},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, eventName(tt.e), tt.name)
+	}
+}
+
+func TestEnqueue(t *testing.T) {
+	repo := newMemRepo()
+	s := newTestService(repo)
+	ctx := context.Background()
+
+	all, err := s.Create(ctx, &webhook.Webhook{URL: "https://a.example", Events: webhook.Events})
+	require.NoError(t, err)
+	_, err = s.Create(ctx, &webhook.Webhook{URL: "https://b.example", Events: []string{webhook.EventDeleted}})
+	require.NoError(t, err)
+	off, err := s.Create(ctx, &webhook.Webhook{URL: "https://c.example", Events: webhook.Events})
+	require.NoError(t, err)
+	require.NoError(t, s.SetActive(ctx, off, false))
+
+	e := event.Event{ID: "e1", Type: event.PostCreated, PostID: "p1", Post: publishedPost("p1"), Published: true, At: t0}
+	require.NoError(t, s.Enqueue(ctx, e))
+	// the same event seen twice, e.g. by two instances, is queued once
+	require.NoError(t, s.Enqueue(ctx, e))
+
+	require.Len(t, repo.deliveries, 1)
+	d := repo.delivery(0)
+	assert.Equal(t, all, d.WebhookID)
+	assert.Equal(t, "e1", d.EventID)
+	assert.Equal(t, webhook.EventPublished, d.Event)
+	assert.Equal(t, webhook.DeliveryPending, d.Status)
+
+	var p webhook.Payload
+	require.NoError(t, json.Unmarshal([]byte(d.Payload), &p))
+	assert.Equal(t, "e1", p.ID)
+	assert.Equal(t, webhook.EventPublished, p.Event)
+	assert.Equal(t, "https://news.example/posts/p1", p.Post.URL)
+	assert.Equal(t, "Hello", p.Post.Title)
+	assert.Equal(t, []string{"go"}, p.Post.Tags)
+
+	require.NoError(t, s.Enqueue(ctx, event.Event{ID: "e2", Type: event.PostDeleted, PostID: "p1", At: t0}))
+	assert.Len(t, repo.deliveries, 3)
+}
+
+// receiver records requests and answers with the given status codes in
+// turn, repeating the last.
+type receiver struct {
+	mu       sync.Mutex
+	codes    []int
+	requests []*http.Request
+	bodies   []string
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	code := rc.codes[min(len(rc.requests), len(rc.codes)-1)]
+	rc.requests = append(rc.requests, r)
+	rc.bodies = append(rc.bodies, string(body))
+	w.WriteHeader(code)
+}
+
+func TestDeliverSignsRequests(t *testing.T) {
+	rc := &receiver{codes: []int{http.StatusNoContent}}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	repo := newMemRepo()
+	s := newTestService(repo)
+	ctx := context.Background()
+
+	_, err := s.Create(ctx, &webhook.Webhook{URL: srv.URL, Secret: "s3cret", Events: webhook.Events})
+	require.NoError(t, err)
+	require.NoError(t, s.Enqueue(ctx, event.Event{ID: "e1", Type: event.PostUpdated, PostID: "p1", Post: publishedPost("p1"), At: t0}))
+
+	s.drain(ctx)
+
+	require.Len(t, rc.requests, 1)
+	r := rc.requests[0]
+	assert.Equal(t, http.MethodPost, r.Method)
+	assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+	assert.Equal(t, webhook.EventUpdated, r.Header.Get(webhook.EventHeader))
+	assert.Equal(t, "d1", r.Header.Get(webhook.DeliveryHeader))
+	assert.Equal(t, webhook.Signature("s3cret", []byte(rc.bodies[0])), r.Header.Get(webhook.SignatureHeader))
+
+	d := repo.delivery(0)
+	assert.Equal(t, webhook.DeliverySucceeded, d.Status)
+	require.Len(t, d.Attempts, 1)
+	assert.Equal(t, http.StatusNoContent, d.Attempts[0].StatusCode)
+	assert.Empty(t, d.Attempts[0].Error)
+}
+
+func TestDeliverRetriesWithBackoff(t *testing.T) {
+	rc := &receiver{codes: []int{http.StatusInternalServerError}}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	repo := newMemRepo()
+	s := newTestService(repo)
+	ctx := context.Background()
+
+	_, err := s.Create(ctx, &webhook.Webhook{URL: srv.URL, Events: webhook.Events})
+	require.NoError(t, err)
+	require.NoError(t, s.Enqueue(ctx, event.Event{ID: "e1", Type: event.PostDeleted, PostID: "p1", At: t0}))
+
+	now := t0
+	s.now = func() time.Time { return now }
+
+	s.drain(ctx)
+	d := repo.delivery(0)
+	assert.Equal(t, webhook.DeliveryPending, d.Status)
+	assert.Equal(t, t0.Add(time.Minute), d.NextAttemptAt)
+
+	// not due yet
+	s.drain(ctx)
+	assert.Len(t, rc.requests, 1)
+
+	now = d.NextAttemptAt
+	s.drain(ctx)
+	d = repo.delivery(0)
+	assert.Equal(t, webhook.DeliveryPending, d.Status)
+	assert.Equal(t, now.Add(2*time.Minute), d.NextAttemptAt)
+
+	now = d.NextAttemptAt
+	s.drain(ctx)
+	d = repo.delivery(0)
+	assert.Equal(t, webhook.DeliveryFailed, d.Status)
+	assert.Len(t, d.Attempts, 3)
+	assert.Equal(t, http.StatusInternalServerError, d.Attempts[2].StatusCode)
+	assert.Contains(t, d.Attempts[2].Error, "500")
+}
+
+func TestBackoff(t *testing.T) {
+	s := newTestService(newMemRepo())
+	assert.Equal(t, time.Minute, s.backoff(1))
+	assert.Equal(t, 2*time.Minute, s.backoff(2))
+	assert.Equal(t, 3*time.Minute, s.backoff(3))
+	assert.Equal(t, 3*time.Minute, s.backoff(60))
+}
+
+func TestDeliverToDisabledWebhookFails(t *testing.T) {
+	repo := newMemRepo()
+	s := newTestService(repo)
+	ctx := context.Background()
+
+	id, err := s.Create(ctx, &webhook.Webhook{URL: "https://unused.example", Events: webhook.Events})
+	require.NoError(t, err)
+	require.NoError(t, s.Enqueue(ctx, event.Event{ID: "e1", Type: event.PostDeleted, PostID: "p1", At: t0}))
+	require.NoError(t, s.SetActive(ctx, id, false))
+
+	s.drain(ctx)
+
+	d := repo.delivery(0)
+	assert.Equal(t, webhook.DeliveryFailed, d.Status)
+	require.Len(t, d.Attempts, 1)
+	assert.Equal(t, "webhook is disabled", d.Attempts[0].Error)
+}
+
+func TestReplay(t *testing.T) {
+	rc := &receiver{codes: []int{http.StatusOK}}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	repo := newMemRepo()
+	s := newTestService(repo)
+	ctx := context.Background()
+
+	_, err := s.Create(ctx, &webhook.Webhook{URL: srv.URL, Events: webhook.Events})
+	require.NoError(t, err)
+	require.NoError(t, s.Enqueue(ctx, event.Event{ID: "e1", Type: event.PostDeleted, PostID: "p1", At: t0}))
+	s.drain(ctx)
+
+	replay, err := s.Replay(ctx, "d1")
+	require.NoError(t, err)
+	assert.Equal(t, "d1", replay.ReplayOf)
+	assert.NotEqual(t, "e1", replay.EventID)
+
+	s.drain(ctx)
+	require.Len(t, rc.bodies, 2)
+	assert.Equal(t, rc.bodies[0], rc.bodies[1])
+	assert.Equal(t, webhook.DeliverySucceeded, repo.delivery(1).Status)
+
+	_, err = s.Replay(ctx, "missing")
+	assert.ErrorIs(t, err, config.ErrDeliveryNotFound)
+}
+
+func TestStartAndClose(t *testing.T) {
+	rc := &receiver{codes: []int{http.StatusOK}}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	repo := newMemRepo()
+	s := newTestService(repo)
+	ctx := context.Background()
+
+	_, err := s.Create(ctx, &webhook.Webhook{URL: srv.URL, Events: webhook.Events})
+	require.NoError(t, err)
+
+	s.Start()
+	require.NoError(t, s.Enqueue(ctx, event.Event{ID: "e1", Type: event.PostDeleted, PostID: "p1", At: t0}))
+
+	assert.Eventually(t, func() bool {
+		return repo.delivery(0).Status == webhook.DeliverySucceeded
+	}, 2*time.Second, 10*time.Millisecond)
+
+	closeCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	assert.NoError(t, s.Close(closeCtx))
+}
@@ -0,0 +1,72 @@
+package webhook
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"news-svc/config"
+	"news-svc/internal/entity/webhook"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// leaseMargin is added to the request timeout to get how long a
+	// claimed delivery stays hidden from other workers.
+	leaseMargin = time.Minute
+	// secretBytes is the size of generated signing secrets.
+	secretBytes = 32
+	// maxResponse caps how much of a response body is read.
+	maxResponse = 64 << 10
+)
+
+type (
+	repository interface {
+		Create(ctx context.Context, w *webhook.Webhook) (string, error)
+		GetByID(ctx context.Context, id string) (*webhook.Webhook, error)
+		GetAll(ctx context.Context) ([]*webhook.Webhook, error)
+		Subscribed(ctx context.Context, event string) ([]*webhook.Webhook, error)
+		SetActive(ctx context.Context, id string, active bool) error
+		Delete(ctx context.Context, id string) error
+
+		Enqueue(ctx context.Context, deliveries []*webhook.Delivery) error
+		Claim(ctx context.Context, now time.Time, lease time.Duration) (*webhook.Delivery, error)
+		Record(ctx context.Context, id string, a webhook.Attempt, status string, next time.Time) error
+		GetDelivery(ctx context.Context, id string) (*webhook.Delivery, error)
+		Deliveries(ctx context.Context, webhookID string, page, limit int64) ([]*webhook.Delivery, int64, error)
+	}
+
+	// service queues a delivery per subscribed webhook for every post
+	// event and sends them from a pool of workers. The queue lives in
+	// Mongo, so deliveries survive restarts and instances share the work.
+	service struct {
+		repo   repository
+		cfg    config.Webhooks
+		site   config.Site
+		client *http.Client
+		l      *slog.Logger
+		now    func() time.Time
+
+		wake      chan struct{}
+		cancel    context.CancelFunc
+		done      chan struct{}
+		startOnce sync.Once
+		stopOnce  sync.Once
+		started   atomic.Bool
+	}
+)
+
+func New(repo repository, cfg config.Webhooks, site config.Site, l *slog.Logger) *service {
+	return &service{
+		repo:   repo,
+		cfg:    cfg,
+		site:   site,
+		client: &http.Client{Timeout: cfg.Timeout},
+		l:      l,
+		now:    time.Now,
+		wake:   make(chan struct{}, 1),
+		cancel: func() {},
+		done:   make(chan struct{}),
+	}
+}
@@ -0,0 +1,289 @@
+package analytics
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"news-svc/internal/entity/analytics"
+	"news-svc/internal/storage/mongo/instrument"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// duplicateKey is the server error code for unique index violations.
+const duplicateKey = 11000
+
+type repo struct {
+	db *mongo.Database
+}
+
+func New(db *mongo.Database) repo {
+	return repo{db}
+}
+
+// Salt returns the salt for the day containing day, creating it on first
+// use. Every instance gets the same salt, so a visitor hashes the same
+// no matter which one served them.
+func (r repo) Salt(ctx context.Context, day time.Time) ([]byte, error) {
+	ctx, end := instrument.Op(ctx, "analytics", "Salt")
+	defer end()
+
+	coll := r.db.Collection(analytics.SaltsCollectionName)
+
+	salt := make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	filter := bson.M{"_id": analytics.Start(analytics.Daily, day).Format(time.DateOnly)}
+	update := bson.M{"$setOnInsert": bson.M{"salt": salt, "created_at": time.Now()}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var doc struct {
+		Salt []byte `bson:"salt"`
+	}
+
+	// two instances creating the salt at once make one upsert fail on
+	// the _id index; the retry then finds the winner's salt
+	var err error
+	for range 2 {
+		err = coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&doc)
+		if !mongo.IsDuplicateKeyError(err) {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return doc.Salt, nil
+}
+
+// AddVisits records visitors and returns those not seen on the same post
+// and day before.
+func (r repo) AddVisits(ctx context.Context, visits []analytics.Visit) ([]analytics.Visit, error) {
+	ctx, end := instrument.Op(ctx, "analytics", "AddVisits")
+	defer end()
+
+	if len(visits) == 0 {
+		return nil, nil
+	}
+
+	coll := r.db.Collection(analytics.VisitorsCollectionName)
+
+	now := time.Now()
+	docs := make([]any, len(visits))
+	for i, v := range visits {
+		postID, err := bson.ObjectIDFromHex(v.PostID)
+		if err != nil {
+			return nil, err
+		}
+		docs[i] = bson.M{
+			"day":        v.Day,
+			"post_id":    postID,
+			"visitor":    v.Visitor,
+			"created_at": now,
+		}
+	}
+
+	_, err := coll.InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+
+	seen := make(map[int]bool)
+	var bulkErr mongo.BulkWriteException
+	if errors.As(err, &bulkErr) && bulkErr.WriteConcernError == nil {
+		for _, we := range bulkErr.WriteErrors {
+			if we.Code != duplicateKey {
+				return nil, err
+			}
+			seen[we.Index] = true
+		}
+	} else if err != nil {
+		return nil, err
+	}
+
+	added := make([]analytics.Visit, 0, len(visits)-len(seen))
+	for i, v := range visits {
+		if !seen[i] {
+			added = append(added, v)
+		}
+	}
+	return added, nil
+}
+
+// Increment adds the views and visitors of each bucket to the stored
+// counters in one unordered batch.
+func (r repo) Increment(ctx context.Context, buckets []analytics.Bucket) error {
+	ctx, end := instrument.Op(ctx, "analytics", "Increment")
+	defer end()
+
+	if len(buckets) == 0 {
+		return nil
+	}
+
+	coll := r.db.Collection(analytics.CollectionName)
+
+	models := make([]mongo.WriteModel, 0, len(buckets))
+	for _, b := range buckets {
+		postID, err := bson.ObjectIDFromHex(b.PostID)
+		if err != nil {
+			return err
+		}
+
+		inc := bson.M{"views": b.Views}
+		if b.Visitors > 0 {
+			inc["visitors"] = b.Visitors
+		}
+
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"post_id": postID, "period": b.Period, "start": b.Start}).
+			SetUpdate(bson.M{"$inc": inc}).
+			SetUpsert(true))
+	}
+
+	_, err := coll.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+// Series sums buckets of the given period in [from, to) by start time,
+// for one post or, when postID is empty, for all of them. Empty buckets
+// are missing from the result.
+func (r repo) Series(ctx context.Context, period, postID string, from, to time.Time) ([]analytics.Point, error) {
+	ctx, end := instrument.Op(ctx, "analytics", "Series")
+	defer end()
+
+	coll := r.db.Collection(analytics.CollectionName)
+
+	match := bson.M{"period": period, "start": bson.M{"$gte": from, "$lt": to}}
+	if postID != "" {
+		objID, err := bson.ObjectIDFromHex(postID)
+		if err != nil {
+			return nil, err
+		}
+		match["post_id"] = objID
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{
+			"_id":      "$start",
+			"views":    bson.M{"$sum": "$views"},
+			"visitors": bson.M{"$sum": "$visitors"},
+		}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	}
+
+	cursor, err := coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		Start    time.Time `bson:"_id"`
+		Views    int64     `bson:"views"`
+		Visitors int64     `bson:"visitors"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	points := make([]analytics.Point, len(rows))
+	for i, row := range rows {
+		points[i] = analytics.Point{Start: row.Start.UTC(), Views: row.Views, Visitors: row.Visitors}
+	}
+	return points, nil
+}
+
+// Top returns the most viewed posts over the days in [from, to).
+func (r repo) Top(ctx context.Context, from, to time.Time, limit int64) ([]analytics.PostStats, error) {
+	ctx, end := instrument.Op(ctx, "analytics", "Top")
+	defer end()
+
+	coll := r.db.Collection(analytics.CollectionName)
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"period": analytics.Daily,
+			"start":  bson.M{"$gte": from, "$lt": to},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":      "$post_id",
+			"views":    bson.M{"$sum": "$views"},
+			"visitors": bson.M{"$sum": "$visitors"},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "views", Value: -1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$limit", Value: limit}},
+	}
+
+	cursor, err := coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		PostID   bson.ObjectID `bson:"_id"`
+		Views    int64         `bson:"views"`
+		Visitors int64         `bson:"visitors"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	stats := make([]analytics.PostStats, len(rows))
+	for i, row := range rows {
+		stats[i] = analytics.PostStats{PostID: row.PostID.Hex(), Views: row.Views, Visitors: row.Visitors}
+	}
+	return stats, nil
+}
+
+func (r repo) EnsureIndexes(ctx context.Context) error {
+	ttl := int32(analytics.VisitorTTL.Seconds())
+
+	collections := map[string][]mongo.IndexModel{
+		analytics.CollectionName: {
+			{
+				Keys: bson.D{
+					{Key: "post_id", Value: 1},
+					{Key: "period", Value: 1},
+					{Key: "start", Value: 1},
+				},
+				Options: options.Index().SetName("post_period_start_unique").SetUnique(true),
+			},
+			{
+				Keys:    bson.D{{Key: "period", Value: 1}, {Key: "start", Value: 1}},
+				Options: options.Index().SetName("period_start"),
+			},
+		},
+		analytics.VisitorsCollectionName: {
+			{
+				Keys: bson.D{
+					{Key: "day", Value: 1},
+					{Key: "post_id", Value: 1},
+					{Key: "visitor", Value: 1},
+				},
+				Options: options.Index().SetName("day_post_visitor_unique").SetUnique(true),
+			},
+			{
+				Keys:    bson.D{{Key: "created_at", Value: 1}},
+				Options: options.Index().SetName("created_at_ttl").SetExpireAfterSeconds(ttl),
+			},
+		},
+		analytics.SaltsCollectionName: {
+			{
+				Keys:    bson.D{{Key: "created_at", Value: 1}},
+				Options: options.Index().SetName("created_at_ttl").SetExpireAfterSeconds(ttl),
+			},
+		},
+	}
+
+	for name, indexes := range collections {
+		if _, err := r.db.Collection(name).Indexes().CreateMany(ctx, indexes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
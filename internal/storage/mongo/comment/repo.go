@@ -0,0 +1,451 @@
+package comment
+
+import (
+	"context"
+	"errors"
+	"news-svc/config"
+	"news-svc/internal/entity/comment"
+	"news-svc/internal/storage/mongo/instrument"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// visible matches approved comments, including those written before
+// comments had a status.
+var visible = bson.M{"$in": bson.A{comment.StatusApproved, "", nil}}
+
+type repo struct {
+	db *mongo.Database
+}
+
+func New(db *mongo.Database) repo {
+	return repo{db}
+}
+
+func (r repo) Create(ctx context.Context, c *comment.Comment) (string, error) {
+	ctx, end := instrument.Op(ctx, "comment", "Create")
+	defer end()
+
+	coll := r.db.Collection(comment.CollectionName)
+
+	result, err := coll.InsertOne(ctx, c)
+	if err != nil {
+		return "", err
+	}
+
+	oid, ok := result.InsertedID.(bson.ObjectID)
+	if !ok {
+		return "", errors.New("failed to get inserted ID")
+	}
+
+	c.ID = oid.Hex()
+	return c.ID, nil
+}
+
+func (r repo) GetByID(ctx context.Context, id string) (*comment.Comment, error) {
+	ctx, end := instrument.Op(ctx, "comment", "GetByID")
+	defer end()
+
+	coll := r.db.Collection(comment.CollectionName)
+
+	objID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, config.ErrCommentNotFound
+	}
+
+	var c comment.Comment
+	err = coll.FindOne(ctx, bson.M{"_id": objID}).Decode(&c)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, config.ErrCommentNotFound
+		}
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+// Roots returns a page of a post's top-level comments, oldest first.
+func (r repo) Roots(ctx context.Context, postID string, page, limit int64) (roots []*comment.Comment, total int64, err error) {
+	ctx, end := instrument.Op(ctx, "comment", "Roots")
+	defer end()
+
+	coll := r.db.Collection(comment.CollectionName)
+
+	objID, err := bson.ObjectIDFromHex(postID)
+	if err != nil {
+		return nil, 0, config.ErrPostNotFound
+	}
+
+	// a missing root_id matches null
+	filter := bson.M{"post_id": objID, "root_id": nil, "status": visible}
+
+	total, err = coll.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSkip(max((page-1)*limit, 0)).
+		SetLimit(limit).
+		SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+
+	cursor, err := coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	err = cursor.All(ctx, &roots)
+	return
+}
+
+// Replies returns a page of the replies in a thread, oldest first.
+func (r repo) Replies(ctx context.Context, rootID string, page, limit int64) (replies []*comment.Comment, total int64, err error) {
+	ctx, end := instrument.Op(ctx, "comment", "Replies")
+	defer end()
+
+	coll := r.db.Collection(comment.CollectionName)
+
+	objID, err := bson.ObjectIDFromHex(rootID)
+	if err != nil {
+		return nil, 0, config.ErrCommentNotFound
+	}
+
+	filter := bson.M{"root_id": objID, "status": visible}
+
+	total, err = coll.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSkip(max((page-1)*limit, 0)).
+		SetLimit(limit).
+		SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+
+	cursor, err := coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	err = cursor.All(ctx, &replies)
+	return
+}
+
+// FirstReplies loads the first limit replies of several threads in one
+// round trip, together with each thread's reply count. Threads without
+// replies are absent from the result.
+func (r repo) FirstReplies(ctx context.Context, rootIDs []string, limit int64) (map[string]comment.Thread, error) {
+	ctx, end := instrument.Op(ctx, "comment", "FirstReplies")
+	defer end()
+
+	coll := r.db.Collection(comment.CollectionName)
+
+	ids, err := objectIDs(rootIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"root_id": bson.M{"$in": ids}, "status": visible}}},
+		{{Key: "$sort", Value: bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":     "$root_id",
+			"replies": bson.M{"$push": "$$ROOT"},
+			"count":   bson.M{"$sum": 1},
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"replies": bson.M{"$slice": bson.A{"$replies", limit}},
+			"count":   1,
+		}}},
+	}
+
+	cursor, err := coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var groups []struct {
+		RootID  bson.ObjectID      `bson:"_id"`
+		Replies []*comment.Comment `bson:"replies"`
+		Count   int64              `bson:"count"`
+	}
+	if err := cursor.All(ctx, &groups); err != nil {
+		return nil, err
+	}
+
+	threads := make(map[string]comment.Thread, len(groups))
+	for _, g := range groups {
+		threads[g.RootID.Hex()] = comment.Thread{Replies: g.Replies, ReplyCount: g.Count}
+	}
+
+	return threads, nil
+}
+
+// CountByPosts returns the number of comments on each of the given
+// posts. Posts without comments are absent from the result.
+func (r repo) CountByPosts(ctx context.Context, postIDs []string) (map[string]int64, error) {
+	ctx, end := instrument.Op(ctx, "comment", "CountByPosts")
+	defer end()
+
+	coll := r.db.Collection(comment.CollectionName)
+
+	ids, err := objectIDs(postIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"post_id": bson.M{"$in": ids}, "status": visible}}},
+		{{Key: "$group", Value: bson.M{"_id": "$post_id", "count": bson.M{"$sum": 1}}}},
+	}
+
+	cursor, err := coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var groups []struct {
+		PostID bson.ObjectID `bson:"_id"`
+		Count  int64         `bson:"count"`
+	}
+	if err := cursor.All(ctx, &groups); err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(groups))
+	for _, g := range groups {
+		counts[g.PostID.Hex()] = g.Count
+	}
+
+	return counts, nil
+}
+
+// UpsertImported writes a comment keyed by its ImportID, so re-running
+// an import updates comments in place, and sets c.ID.
+func (r repo) UpsertImported(ctx context.Context, c *comment.Comment) error {
+	ctx, end := instrument.Op(ctx, "comment", "UpsertImported")
+	defer end()
+
+	coll := r.db.Collection(comment.CollectionName)
+
+	opts := options.FindOneAndReplace().
+		SetUpsert(true).
+		SetReturnDocument(options.After).
+		SetProjection(bson.M{"_id": 1})
+
+	var doc struct {
+		ID bson.ObjectID `bson:"_id"`
+	}
+	err := coll.FindOneAndReplace(ctx, bson.M{"import_id": c.ImportID}, c, opts).Decode(&doc)
+	if err != nil {
+		return err
+	}
+
+	c.ID = doc.ID.Hex()
+	return nil
+}
+
+// GetByIDs returns the comments that exist among ids, in no particular
+// order.
+func (r repo) GetByIDs(ctx context.Context, ids []string) (comments []*comment.Comment, err error) {
+	ctx, end := instrument.Op(ctx, "comment", "GetByIDs")
+	defer end()
+
+	coll := r.db.Collection(comment.CollectionName)
+
+	objIDs, err := objectIDs(ids)
+	if err != nil {
+		return nil, config.ErrCommentNotFound
+	}
+
+	cursor, err := coll.Find(ctx, bson.M{"_id": bson.M{"$in": objIDs}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	err = cursor.All(ctx, &comments)
+	return
+}
+
+// ByStatus returns a page of comments with the given moderation status,
+// newest first.
+func (r repo) ByStatus(ctx context.Context, status string, page, limit int64) (comments []*comment.Comment, total int64, err error) {
+	ctx, end := instrument.Op(ctx, "comment", "ByStatus")
+	defer end()
+
+	coll := r.db.Collection(comment.CollectionName)
+
+	filter := bson.M{"status": status}
+	if status == comment.StatusApproved {
+		filter["status"] = visible
+	}
+
+	total, err = coll.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSkip(max((page-1)*limit, 0)).
+		SetLimit(limit).
+		SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	err = cursor.All(ctx, &comments)
+	return
+}
+
+// SetStatus records a moderation decision on the given comments.
+func (r repo) SetStatus(ctx context.Context, ids []string, status string, at time.Time) (int64, error) {
+	ctx, end := instrument.Op(ctx, "comment", "SetStatus")
+	defer end()
+
+	coll := r.db.Collection(comment.CollectionName)
+
+	objIDs, err := objectIDs(ids)
+	if err != nil {
+		return 0, config.ErrCommentNotFound
+	}
+
+	result, err := coll.UpdateMany(ctx,
+		bson.M{"_id": bson.M{"$in": objIDs}},
+		bson.M{"$set": bson.M{"status": status, "moderated_at": at}},
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.MatchedCount, nil
+}
+
+// CountByIPSince counts the comments from an address since a moment,
+// whatever their status.
+func (r repo) CountByIPSince(ctx context.Context, ipHash string, since time.Time) (int64, error) {
+	ctx, end := instrument.Op(ctx, "comment", "CountByIPSince")
+	defer end()
+
+	coll := r.db.Collection(comment.CollectionName)
+
+	return coll.CountDocuments(ctx, bson.M{
+		"ip_hash":    ipHash,
+		"created_at": bson.M{"$gte": since},
+	})
+}
+
+// HasApproved reports whether someone already has an approved comment.
+func (r repo) HasApproved(ctx context.Context, identity string) (bool, error) {
+	ctx, end := instrument.Op(ctx, "comment", "HasApproved")
+	defer end()
+
+	coll := r.db.Collection(comment.CollectionName)
+
+	err := coll.FindOne(ctx,
+		bson.M{"identity": identity, "status": visible},
+		options.FindOne().SetProjection(bson.M{"_id": 1}),
+	).Err()
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+// Moderated streams the most recent moderation decisions, newest first,
+// e.g. to train a spam classifier.
+func (r repo) Moderated(ctx context.Context, limit int64, fn func(*comment.Comment) error) error {
+	ctx, end := instrument.Op(ctx, "comment", "Moderated")
+	defer end()
+
+	coll := r.db.Collection(comment.CollectionName)
+
+	opts := options.Find().
+		SetLimit(limit).
+		SetSort(bson.D{{Key: "moderated_at", Value: -1}}).
+		SetProjection(bson.M{"author": 1, "content": 1, "status": 1})
+
+	cursor, err := coll.Find(ctx, bson.M{"moderated_at": bson.M{"$exists": true}}, opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var c comment.Comment
+		if err := cursor.Decode(&c); err != nil {
+			return err
+		}
+		if err := fn(&c); err != nil {
+			return err
+		}
+	}
+
+	return cursor.Err()
+}
+
+func (r repo) EnsureIndexes(ctx context.Context) error {
+	coll := r.db.Collection(comment.CollectionName)
+
+	indexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "post_id", Value: 1},
+				{Key: "root_id", Value: 1},
+				{Key: "created_at", Value: 1},
+			},
+			Options: options.Index().SetName("post_root_created_at"),
+		},
+		{
+			Keys:    bson.D{{Key: "root_id", Value: 1}, {Key: "created_at", Value: 1}},
+			Options: options.Index().SetName("root_created_at").SetSparse(true),
+		},
+		{
+			Keys:    bson.D{{Key: "import_id", Value: 1}},
+			Options: options.Index().SetName("import_id_unique").SetUnique(true).SetSparse(true),
+		},
+		{
+			Keys:    bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("status_created_at"),
+		},
+		{
+			Keys:    bson.D{{Key: "ip_hash", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("ip_hash_created_at").SetSparse(true),
+		},
+		{
+			Keys:    bson.D{{Key: "identity", Value: 1}, {Key: "status", Value: 1}},
+			Options: options.Index().SetName("identity_status").SetSparse(true),
+		},
+		{
+			Keys:    bson.D{{Key: "moderated_at", Value: -1}},
+			Options: options.Index().SetName("moderated_at_desc").SetSparse(true),
+		},
+	}
+
+	_, err := coll.Indexes().CreateMany(ctx, indexes)
+	return err
+}
+
+func objectIDs(hexes []string) ([]bson.ObjectID, error) {
+	ids := make([]bson.ObjectID, 0, len(hexes))
+	for _, h := range hexes {
+		id, err := bson.ObjectIDFromHex(h)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
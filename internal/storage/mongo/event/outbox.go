@@ -0,0 +1,162 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"news-svc/internal/entity/event"
+	"news-svc/internal/entity/post"
+	"news-svc/internal/storage/mongo/instrument"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// outboxRecord is an event as stored in the outbox. Records are
+// claimed by setting locked_until and done once processed_at is set.
+type outboxRecord struct {
+	ID        bson.ObjectID `bson:"_id"`
+	Type      string        `bson:"type"`
+	PostID    string        `bson:"post_id"`
+	Post      *post.Post    `bson:"post,omitempty"`
+	Published bool          `bson:"published,omitempty"`
+	At        time.Time     `bson:"at"`
+}
+
+func (rec outboxRecord) event() *event.Event {
+	return &event.Event{
+		ID:        rec.ID.Hex(),
+		Type:      rec.Type,
+		PostID:    rec.PostID,
+		Post:      rec.Post,
+		Published: rec.Published,
+		At:        rec.At,
+	}
+}
+
+// Transaction runs fn in a transaction; writes made with the context
+// passed to fn commit or roll back together. Standalone servers have
+// no transactions, so there fn runs on its own and its writes are not
+// atomic.
+func (r repo) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if r.standalone.Load() {
+		return fn(ctx)
+	}
+
+	sess, err := r.db.Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer sess.EndSession(ctx)
+
+	_, err = sess.WithTransaction(ctx, func(ctx context.Context) (any, error) {
+		return nil, fn(ctx)
+	})
+
+	var se mongo.ServerError
+	if errors.As(err, &se) && se.HasErrorCode(illegalOperation) && se.HasErrorMessage("Transaction numbers") {
+		// the first write was refused, so nothing has been written yet
+		r.standalone.Store(true)
+		return fn(ctx)
+	}
+	return err
+}
+
+// AddToOutbox stores e, which must have an ID from event.NewID, to be
+// relayed later.
+func (r repo) AddToOutbox(ctx context.Context, e event.Event) error {
+	ctx, end := instrument.Op(ctx, "event", "AddToOutbox")
+	defer end()
+
+	coll := r.db.Collection(event.OutboxCollectionName)
+
+	id, err := bson.ObjectIDFromHex(e.ID)
+	if err != nil {
+		return err
+	}
+
+	_, err = coll.InsertOne(ctx, outboxRecord{
+		ID:        id,
+		Type:      e.Type,
+		PostID:    e.PostID,
+		Post:      e.Post,
+		Published: e.Published,
+		At:        e.At,
+	})
+	return err
+}
+
+// ClaimFromOutbox takes the oldest unprocessed event and hides it from
+// other relays until the lease runs out, so it is relayed again if this
+// one dies. It returns nil when the outbox is empty.
+func (r repo) ClaimFromOutbox(ctx context.Context, now time.Time, lease time.Duration) (*event.Event, error) {
+	ctx, end := instrument.Op(ctx, "event", "ClaimFromOutbox")
+	defer end()
+
+	coll := r.db.Collection(event.OutboxCollectionName)
+
+	filter := bson.M{
+		"processed_at": bson.M{"$exists": false},
+		"$or": bson.A{
+			bson.M{"locked_until": bson.M{"$exists": false}},
+			bson.M{"locked_until": bson.M{"$lte": now}},
+		},
+	}
+	update := bson.M{"$set": bson.M{"locked_until": now.Add(lease)}}
+	opts := options.FindOneAndUpdate().SetSort(bson.D{{Key: "_id", Value: 1}})
+
+	var rec outboxRecord
+	err := coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&rec)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return rec.event(), nil
+}
+
+// MarkProcessed records that the event with id has been relayed.
+func (r repo) MarkProcessed(ctx context.Context, id string, at time.Time) error {
+	ctx, end := instrument.Op(ctx, "event", "MarkProcessed")
+	defer end()
+
+	coll := r.db.Collection(event.OutboxCollectionName)
+
+	objID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	_, err = coll.UpdateOne(ctx, bson.M{"_id": objID}, bson.M{
+		"$set":   bson.M{"processed_at": at},
+		"$unset": bson.M{"locked_until": ""},
+	})
+	return err
+}
+
+// PruneOutbox deletes events processed before the given time.
+func (r repo) PruneOutbox(ctx context.Context, before time.Time) (int64, error) {
+	ctx, end := instrument.Op(ctx, "event", "PruneOutbox")
+	defer end()
+
+	coll := r.db.Collection(event.OutboxCollectionName)
+
+	result, err := coll.DeleteMany(ctx, bson.M{"processed_at": bson.M{"$lt": before}})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+// EnsureIndexes also creates the outbox collection, which transactions
+// on older servers cannot do implicitly.
+func (r repo) EnsureIndexes(ctx context.Context) error {
+	_, err := r.db.Collection(event.OutboxCollectionName).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "processed_at", Value: 1}, {Key: "_id", Value: 1}},
+		Options: options.Index().SetName("processed_at_id"),
+	})
+	return err
+}
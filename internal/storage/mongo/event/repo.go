@@ -0,0 +1,198 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"news-svc/config"
+	"news-svc/internal/entity/event"
+	"news-svc/internal/entity/post"
+	"news-svc/internal/storage/mongo/instrument"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// Server error codes that decide how watching can go on.
+const (
+	// changeStreamsUnsupported is returned by standalone servers.
+	changeStreamsUnsupported = 40573
+	invalidResumeToken       = 260
+	changeStreamFatal        = 280
+	changeStreamHistoryLost  = 286
+	// illegalOperation is returned by standalone servers for
+	// transactions, among other things.
+	illegalOperation = 20
+)
+
+// derivedFields are kept up to date on posts by the service itself.
+// Updates touching nothing else are not worth telling anyone about.
+var derivedFields = []string{"reactions", "reaction_count", "related"}
+
+type repo struct {
+	db *mongo.Database
+	// standalone is set once the server turned out not to support
+	// transactions.
+	standalone *atomic.Bool
+}
+
+func New(db *mongo.Database) repo {
+	return repo{db, new(atomic.Bool)}
+}
+
+// Cursor returns the saved read position of consumer, or an empty one
+// if it has none yet.
+func (r repo) Cursor(ctx context.Context, consumer string) (event.Cursor, error) {
+	ctx, end := instrument.Op(ctx, "event", "Cursor")
+	defer end()
+
+	coll := r.db.Collection(event.CursorsCollectionName)
+
+	c := event.Cursor{Consumer: consumer}
+	err := coll.FindOne(ctx, bson.M{"_id": consumer}).Decode(&c)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return c, nil
+	}
+	return c, err
+}
+
+func (r repo) SaveCursor(ctx context.Context, c event.Cursor) error {
+	ctx, end := instrument.Op(ctx, "event", "SaveCursor")
+	defer end()
+
+	coll := r.db.Collection(event.CursorsCollectionName)
+
+	_, err := coll.ReplaceOne(ctx, bson.M{"_id": c.Consumer}, c, options.Replace().SetUpsert(true))
+	return err
+}
+
+// Watch tails changes to posts from just after token, or from now when
+// token is empty, calling fn with each event and the token to resume
+// after it. It returns when ctx is done, fn fails or the stream breaks;
+// config.ErrChangeStreamsUnsupported and config.ErrResumeTokenLost tell
+// the caller to poll instead or to start over without the token.
+func (r repo) Watch(ctx context.Context, token bson.Raw, fn func(e event.Event, token bson.Raw) error) error {
+	coll := r.db.Collection(post.CollectionName)
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"operationType": bson.M{"$in": bson.A{"insert", "update", "replace", "delete"}},
+		}}},
+	}
+	opts := options.ChangeStream().SetFullDocument(options.UpdateLookup)
+	if len(token) > 0 {
+		opts.SetResumeAfter(token)
+	}
+
+	stream, err := coll.Watch(ctx, pipeline, opts)
+	if err != nil {
+		return watchError(err)
+	}
+	defer stream.Close(context.WithoutCancel(ctx))
+
+	for stream.Next(ctx) {
+		var ch change
+		if err := stream.Decode(&ch); err != nil {
+			return err
+		}
+
+		e, ok := ch.event()
+		if !ok {
+			continue
+		}
+		if err := fn(e, stream.ResumeToken()); err != nil {
+			return err
+		}
+	}
+
+	return watchError(stream.Err())
+}
+
+type change struct {
+	// ID is the resume token, the same on every instance
+	ID            bson.Raw   `bson:"_id"`
+	OperationType string     `bson:"operationType"`
+	FullDocument  *post.Post `bson:"fullDocument"`
+	WallTime      time.Time  `bson:"wallTime"`
+	DocumentKey   struct {
+		ID bson.ObjectID `bson:"_id"`
+	} `bson:"documentKey"`
+	UpdateDescription struct {
+		UpdatedFields bson.Raw `bson:"updatedFields"`
+		RemovedFields []string `bson:"removedFields"`
+	} `bson:"updateDescription"`
+}
+
+func (ch change) event() (event.Event, bool) {
+	e := event.Event{PostID: ch.DocumentKey.ID.Hex(), Post: ch.FullDocument, At: ch.WallTime}
+	e.ID, _ = ch.ID.Lookup("_data").StringValueOK()
+	if e.At.IsZero() {
+		e.At = time.Now()
+	}
+
+	switch ch.OperationType {
+	case "insert":
+		e.Type = event.PostCreated
+		e.Published = e.Post != nil && e.Post.Status == post.StatusPublished
+	case "update", "replace":
+		if ch.OperationType == "update" && ch.derivedOnly() {
+			return e, false
+		}
+		e.Type = event.PostUpdated
+		// only fields that actually changed are listed
+		status, _ := ch.UpdateDescription.UpdatedFields.Lookup("status").StringValueOK()
+		e.Published = status == post.StatusPublished
+	case "delete":
+		e.Type = event.PostDeleted
+		e.Post = nil
+		return e, true
+	default:
+		return e, false
+	}
+
+	// deleted again before the lookup; its own event follows
+	return e, e.Post != nil
+}
+
+func (ch change) derivedOnly() bool {
+	var fields []string
+	if elems, err := ch.UpdateDescription.UpdatedFields.Elements(); err == nil {
+		for _, el := range elems {
+			fields = append(fields, el.Key())
+		}
+	}
+	fields = append(fields, ch.UpdateDescription.RemovedFields...)
+
+	for _, f := range fields {
+		if !derived(f) {
+			return false
+		}
+	}
+	return len(fields) > 0
+}
+
+func derived(field string) bool {
+	for _, d := range derivedFields {
+		if field == d || strings.HasPrefix(field, d+".") {
+			return true
+		}
+	}
+	return false
+}
+
+func watchError(err error) error {
+	var se mongo.ServerError
+	if errors.As(err, &se) {
+		switch {
+		case se.HasErrorCode(changeStreamsUnsupported):
+			return config.ErrChangeStreamsUnsupported
+		case se.HasErrorCode(invalidResumeToken), se.HasErrorCode(changeStreamFatal),
+			se.HasErrorCode(changeStreamHistoryLost):
+			return config.ErrResumeTokenLost
+		}
+	}
+	return err
+}
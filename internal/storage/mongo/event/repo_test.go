@@ -0,0 +1,71 @@
+package event
+
+import (
+	"testing"
+
+	"news-svc/internal/entity/event"
+	"news-svc/internal/entity/post"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func updateChange(t *testing.T, updated bson.M, removed ...string) change {
+	raw, err := bson.Marshal(updated)
+	assert.NoError(t, err)
+
+	ch := change{OperationType: "update", FullDocument: &post.Post{Title: "T"}}
+	ch.UpdateDescription.UpdatedFields = raw
+	ch.UpdateDescription.RemovedFields = removed
+	return ch
+}
+
+func TestChangeEvent(t *testing.T) {
+	id := bson.NewObjectID()
+
+	token, err := bson.Marshal(bson.M{"_data": "8266"})
+	assert.NoError(t, err)
+	ch := change{ID: token, OperationType: "insert", FullDocument: &post.Post{ID: id.Hex(), Status: post.StatusPublished}}
+	ch.DocumentKey.ID = id
+	e, ok := ch.event()
+	assert.True(t, ok)
+	assert.Equal(t, "8266", e.ID)
+	assert.Equal(t, event.PostCreated, e.Type)
+	assert.True(t, e.Published)
+	assert.Equal(t, id.Hex(), e.PostID)
+	assert.False(t, e.At.IsZero())
+
+	ch = change{OperationType: "delete"}
+	ch.DocumentKey.ID = id
+	e, ok = ch.event()
+	assert.True(t, ok)
+	assert.Equal(t, event.PostDeleted, e.Type)
+	assert.Nil(t, e.Post)
+
+	// updated, then deleted before the full document was looked up
+	_, ok = change{OperationType: "replace"}.event()
+	assert.False(t, ok)
+
+	_, ok = change{OperationType: "drop"}.event()
+	assert.False(t, ok)
+}
+
+func TestDerivedUpdatesAreSkipped(t *testing.T) {
+	e, ok := updateChange(t, bson.M{"title": "New", "updated_at": 1}).event()
+	assert.True(t, ok)
+	assert.Equal(t, event.PostUpdated, e.Type)
+	assert.False(t, e.Published)
+
+	e, ok = updateChange(t, bson.M{"status": post.StatusPublished}).event()
+	assert.True(t, ok)
+	assert.True(t, e.Published)
+
+	_, ok = updateChange(t, bson.M{"reactions.like": 2, "reaction_count": 2}).event()
+	assert.False(t, ok)
+
+	_, ok = updateChange(t, bson.M{}, "related").event()
+	assert.False(t, ok)
+
+	_, ok = updateChange(t, bson.M{"related_note": 1}).event()
+	assert.True(t, ok)
+}
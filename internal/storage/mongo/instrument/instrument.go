@@ -0,0 +1,26 @@
+// Package instrument times and traces repository methods.
+package instrument
+
+import (
+	"context"
+
+	"news-svc/pkg/metrics"
+	"news-svc/pkg/tracing"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// Op - starts timing a repository method and, within a traced request,
+// a span for it. Call the returned function when the method returns:
+//
+//	ctx, end := instrument.Op(ctx, "post", "GetByID")
+//	defer end()
+func Op(ctx context.Context, repo, method string) (context.Context, func()) {
+	done := metrics.MongoOp(repo, method)
+	ctx, span := tracing.StartChild(ctx, "repo."+repo+"."+method, semconv.DBSystemMongoDB)
+
+	return ctx, func() {
+		span.End()
+		done()
+	}
+}
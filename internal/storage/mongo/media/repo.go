@@ -0,0 +1,154 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"news-svc/config"
+	"news-svc/internal/entity/media"
+	"news-svc/internal/storage/mongo/instrument"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type repo struct {
+	db *mongo.Database
+}
+
+func New(db *mongo.Database) repo {
+	return repo{db}
+}
+
+func (r repo) Create(ctx context.Context, m *media.Media) (string, error) {
+	ctx, end := instrument.Op(ctx, "media", "Create")
+	defer end()
+
+	coll := r.db.Collection(media.CollectionName)
+
+	result, err := coll.InsertOne(ctx, m)
+	if err != nil {
+		return "", err
+	}
+
+	oid, ok := result.InsertedID.(bson.ObjectID)
+	if !ok {
+		return "", errors.New("failed to get inserted ID")
+	}
+
+	m.ID = oid.Hex()
+	return m.ID, nil
+}
+
+func (r repo) GetByID(ctx context.Context, id string) (*media.Media, error) {
+	ctx, end := instrument.Op(ctx, "media", "GetByID")
+	defer end()
+
+	coll := r.db.Collection(media.CollectionName)
+
+	objID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, config.ErrMediaNotFound
+	}
+
+	var m media.Media
+	err = coll.FindOne(ctx, bson.M{"_id": objID}).Decode(&m)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, config.ErrMediaNotFound
+		}
+		return nil, err
+	}
+
+	return &m, nil
+}
+
+func (r repo) GetAll(ctx context.Context, page, limit int64) (items []*media.Media, total int64, err error) {
+	ctx, end := instrument.Op(ctx, "media", "GetAll")
+	defer end()
+
+	coll := r.db.Collection(media.CollectionName)
+
+	skip := max((page-1)*limit, 0)
+
+	total, err = coll.CountDocuments(ctx, bson.D{})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSkip(skip).
+		SetLimit(limit).
+		SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := coll.Find(ctx, bson.D{}, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	err = cursor.All(ctx, &items)
+	return
+}
+
+// UpdateFiles records the stored size, dimensions and variants of m.
+func (r repo) UpdateFiles(ctx context.Context, m *media.Media) error {
+	ctx, end := instrument.Op(ctx, "media", "UpdateFiles")
+	defer end()
+
+	coll := r.db.Collection(media.CollectionName)
+
+	objID, err := bson.ObjectIDFromHex(m.ID)
+	if err != nil {
+		return config.ErrMediaNotFound
+	}
+
+	result, err := coll.UpdateOne(ctx, bson.M{"_id": objID}, bson.M{"$set": bson.M{
+		"size":     m.Size,
+		"width":    m.Width,
+		"height":   m.Height,
+		"variants": m.Variants,
+	}})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return config.ErrMediaNotFound
+	}
+
+	return nil
+}
+
+func (r repo) Delete(ctx context.Context, id string) error {
+	ctx, end := instrument.Op(ctx, "media", "Delete")
+	defer end()
+
+	coll := r.db.Collection(media.CollectionName)
+
+	objID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return config.ErrMediaNotFound
+	}
+
+	result, err := coll.DeleteOne(ctx, bson.M{"_id": objID})
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return config.ErrMediaNotFound
+	}
+
+	return nil
+}
+
+func (r repo) EnsureIndexes(ctx context.Context) error {
+	coll := r.db.Collection(media.CollectionName)
+
+	_, err := coll.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "created_at", Value: -1}},
+		Options: options.Index().SetName("created_at_desc"),
+	})
+	return err
+}
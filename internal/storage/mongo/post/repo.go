@@ -5,6 +5,7 @@ import (
 	"errors"
 	"news-svc/config"
 	"news-svc/internal/entity/post"
+	"news-svc/internal/storage/mongo/instrument"
 	"time"
 
 	"go.mongodb.org/mongo-driver/v2/bson"
@@ -21,6 +22,9 @@ func New(db *mongo.Database) repo {
 }
 
 func (r repo) Create(ctx context.Context, p *post.Post) (string, error) {
+	ctx, end := instrument.Op(ctx, "post", "Create")
+	defer end()
+
 	coll := r.db.Collection(post.CollectionName)
 
 	now := time.Now()
@@ -41,6 +45,9 @@ func (r repo) Create(ctx context.Context, p *post.Post) (string, error) {
 }
 
 func (r repo) GetAll(ctx context.Context, page, limit int64) (posts []*post.Post, total int64, err error) {
+	ctx, end := instrument.Op(ctx, "post", "GetAll")
+	defer end()
+
 	coll := r.db.Collection(post.CollectionName)
 
 	skip := max((page-1)*limit, 0)
@@ -65,7 +72,43 @@ func (r repo) GetAll(ctx context.Context, page, limit int64) (posts []*post.Post
 	return
 }
 
+// GetAllSorted is GetAll in the given order, SortNewest or
+// SortReactions; ties and unknown orders fall back to newest first.
+func (r repo) GetAllSorted(ctx context.Context, sort string, page, limit int64) (posts []*post.Post, total int64, err error) {
+	ctx, end := instrument.Op(ctx, "post", "GetAllSorted")
+	defer end()
+
+	coll := r.db.Collection(post.CollectionName)
+
+	order := bson.D{{Key: "created_at", Value: -1}}
+	if sort == post.SortReactions {
+		order = append(bson.D{{Key: "reaction_count", Value: -1}}, order...)
+	}
+
+	opts := options.Find().
+		SetSkip(max((page-1)*limit, 0)).
+		SetLimit(limit).
+		SetSort(order)
+
+	total, err = coll.CountDocuments(ctx, bson.D{})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	cursor, err := coll.Find(ctx, bson.D{}, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	err = cursor.All(ctx, &posts)
+	return
+}
+
 func (r repo) GetByID(ctx context.Context, id string) (*post.Post, error) {
+	ctx, end := instrument.Op(ctx, "post", "GetByID")
+	defer end()
+
 	coll := r.db.Collection(post.CollectionName)
 
 	objID, err := bson.ObjectIDFromHex(id)
@@ -85,7 +128,51 @@ func (r repo) GetByID(ctx context.Context, id string) (*post.Post, error) {
 	return &post, nil
 }
 
+// GetByIDs returns the posts with the given IDs in no particular order.
+// IDs that do not match a post are skipped.
+func (r repo) GetByIDs(ctx context.Context, ids []string) (posts []*post.Post, err error) {
+	ctx, end := instrument.Op(ctx, "post", "GetByIDs")
+	defer end()
+
+	coll := r.db.Collection(post.CollectionName)
+
+	objIDs, err := post.ObjectIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := coll.Find(ctx, bson.M{"_id": bson.M{"$in": objIDs}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	err = cursor.All(ctx, &posts)
+	return
+}
+
+func (r repo) GetBySlug(ctx context.Context, slug string) (*post.Post, error) {
+	ctx, end := instrument.Op(ctx, "post", "GetBySlug")
+	defer end()
+
+	coll := r.db.Collection(post.CollectionName)
+
+	var p post.Post
+	err := coll.FindOne(ctx, bson.M{"slug": slug}).Decode(&p)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, config.ErrPostNotFound
+		}
+		return nil, err
+	}
+
+	return &p, nil
+}
+
 func (r repo) Update(ctx context.Context, p *post.Post) error {
+	ctx, end := instrument.Op(ctx, "post", "Update")
+	defer end()
+
 	coll := r.db.Collection(post.CollectionName)
 
 	objID, err := bson.ObjectIDFromHex(p.ID)
@@ -95,14 +182,19 @@ func (r repo) Update(ctx context.Context, p *post.Post) error {
 
 	p.UpdatedAt = time.Now()
 
-	update := bson.M{
-		"$set": bson.M{
-			"title":      p.Title,
-			"content":    p.Content,
-			"updated_at": p.UpdatedAt,
-		},
+	set := bson.M{
+		"title":       p.Title,
+		"content":     p.Content,
+		"summary":     p.Summary,
+		"cover_image": p.CoverImage,
+		"updated_at":  p.UpdatedAt,
+	}
+	if p.Status != "" {
+		set["status"] = p.Status
 	}
 
+	update := bson.M{"$set": set}
+
 	result, err := coll.UpdateOne(ctx, bson.M{"_id": objID}, update)
 	if err != nil {
 		return err
@@ -115,7 +207,110 @@ func (r repo) Update(ctx context.Context, p *post.Post) error {
 	return nil
 }
 
+// AddReaction adjusts the count for one reaction kind and the total by
+// delta in a single atomic update and returns the new counts.
+func (r repo) AddReaction(ctx context.Context, id, kind string, delta int64) (map[string]int64, error) {
+	ctx, end := instrument.Op(ctx, "post", "AddReaction")
+	defer end()
+
+	coll := r.db.Collection(post.CollectionName)
+
+	objID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	update := bson.M{"$inc": bson.M{
+		"reactions." + kind: delta,
+		"reaction_count":    delta,
+	}}
+	opts := options.FindOneAndUpdate().
+		SetReturnDocument(options.After).
+		SetProjection(bson.M{"reactions": 1})
+
+	var p post.Post
+	err = coll.FindOneAndUpdate(ctx, bson.M{"_id": objID}, update, opts).Decode(&p)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, config.ErrPostNotFound
+		}
+		return nil, err
+	}
+
+	return p.Reactions, nil
+}
+
+// SetRelated replaces the related posts of a post. An empty list
+// removes them.
+func (r repo) SetRelated(ctx context.Context, id string, related []string) error {
+	ctx, end := instrument.Op(ctx, "post", "SetRelated")
+	defer end()
+
+	coll := r.db.Collection(post.CollectionName)
+
+	objID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	update := bson.M{"$unset": bson.M{"related": ""}}
+	if len(related) > 0 {
+		relatedIDs, err := post.ObjectIDs(related)
+		if err != nil {
+			return err
+		}
+		update = bson.M{"$set": bson.M{"related": relatedIDs}}
+	}
+
+	result, err := coll.UpdateOne(ctx, bson.M{"_id": objID}, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return config.ErrPostNotFound
+	}
+
+	return nil
+}
+
+// RelatedTo returns the IDs of the posts that list id as related.
+func (r repo) RelatedTo(ctx context.Context, id string) ([]string, error) {
+	ctx, end := instrument.Op(ctx, "post", "RelatedTo")
+	defer end()
+
+	coll := r.db.Collection(post.CollectionName)
+
+	objID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := options.Find().SetProjection(bson.M{"_id": 1})
+	cursor, err := coll.Find(ctx, bson.M{"related": objID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		ID bson.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, len(rows))
+	for i, row := range rows {
+		ids[i] = row.ID.Hex()
+	}
+	return ids, nil
+}
+
 func (r repo) Delete(ctx context.Context, id string) error {
+	ctx, end := instrument.Op(ctx, "post", "Delete")
+	defer end()
+
 	coll := r.db.Collection(post.CollectionName)
 
 	objID, err := bson.ObjectIDFromHex(id)
@@ -132,10 +327,75 @@ func (r repo) Delete(ctx context.Context, id string) error {
 		return config.ErrPostNotFound
 	}
 
-	return nil
+	// deletions leave no trace to poll for, so record them
+	_, err = r.db.Collection(post.TombstonesCollectionName).UpdateOne(ctx,
+		bson.M{"_id": objID},
+		bson.M{"$set": bson.M{"deleted_at": time.Now()}},
+		options.UpdateOne().SetUpsert(true),
+	)
+	return err
+}
+
+// ChangedSince returns up to limit posts created or updated at or after
+// since, oldest change first.
+func (r repo) ChangedSince(ctx context.Context, since time.Time, limit int64) (posts []*post.Post, err error) {
+	ctx, end := instrument.Op(ctx, "post", "ChangedSince")
+	defer end()
+
+	coll := r.db.Collection(post.CollectionName)
+
+	opts := options.Find().
+		SetLimit(limit).
+		SetSort(bson.D{{Key: "updated_at", Value: 1}, {Key: "_id", Value: 1}})
+
+	cursor, err := coll.Find(ctx, bson.M{"updated_at": bson.M{"$gte": since}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	err = cursor.All(ctx, &posts)
+	return
+}
+
+// DeletedSince returns the IDs and deletion times of up to limit posts
+// deleted at or after since, oldest first. Deletions are only kept for
+// post.TombstoneTTL.
+func (r repo) DeletedSince(ctx context.Context, since time.Time, limit int64) (map[string]time.Time, error) {
+	ctx, end := instrument.Op(ctx, "post", "DeletedSince")
+	defer end()
+
+	coll := r.db.Collection(post.TombstonesCollectionName)
+
+	opts := options.Find().
+		SetLimit(limit).
+		SetSort(bson.D{{Key: "deleted_at", Value: 1}, {Key: "_id", Value: 1}})
+
+	cursor, err := coll.Find(ctx, bson.M{"deleted_at": bson.M{"$gte": since}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		ID        bson.ObjectID `bson:"_id"`
+		DeletedAt time.Time     `bson:"deleted_at"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	deleted := make(map[string]time.Time, len(rows))
+	for _, row := range rows {
+		deleted[row.ID.Hex()] = row.DeletedAt
+	}
+	return deleted, nil
 }
 
 func (r repo) Search(ctx context.Context, query string, page, limit int64) (posts []*post.Post, total int64, err error) {
+	ctx, end := instrument.Op(ctx, "post", "Search")
+	defer end()
+
 	coll := r.db.Collection(post.CollectionName)
 
 	skip := max((page-1)*limit, 0)
@@ -168,6 +428,9 @@ func (r repo) Search(ctx context.Context, query string, page, limit int64) (post
 }
 
 func (r repo) GetRecent(ctx context.Context, limit int64) (posts []*post.Post, err error) {
+	ctx, end := instrument.Op(ctx, "post", "GetRecent")
+	defer end()
+
 	coll := r.db.Collection(post.CollectionName)
 
 	opts := options.Find().
@@ -184,6 +447,245 @@ func (r repo) GetRecent(ctx context.Context, limit int64) (posts []*post.Post, e
 	return
 }
 
+// GetRecentBy returns the newest posts matching f.
+func (r repo) GetRecentBy(ctx context.Context, f post.Filter, limit int64) (posts []*post.Post, err error) {
+	ctx, end := instrument.Op(ctx, "post", "GetRecentBy")
+	defer end()
+
+	coll := r.db.Collection(post.CollectionName)
+
+	opts := options.Find().
+		SetLimit(limit).
+		SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := coll.Find(ctx, filterDoc(f), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	err = cursor.All(ctx, &posts)
+	return
+}
+
+// GetAllBy is GetAll restricted to posts matching f.
+func (r repo) GetAllBy(ctx context.Context, f post.Filter, page, limit int64) (posts []*post.Post, total int64, err error) {
+	ctx, end := instrument.Op(ctx, "post", "GetAllBy")
+	defer end()
+
+	coll := r.db.Collection(post.CollectionName)
+
+	skip := max((page-1)*limit, 0)
+	filter := filterDoc(f)
+
+	total, err = coll.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSkip(skip).
+		SetLimit(limit).
+		SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	err = cursor.All(ctx, &posts)
+	return
+}
+
+// CountBy returns the number of posts matching f.
+func (r repo) CountBy(ctx context.Context, f post.Filter) (int64, error) {
+	ctx, end := instrument.Op(ctx, "post", "CountBy")
+	defer end()
+
+	return r.db.Collection(post.CollectionName).CountDocuments(ctx, filterDoc(f))
+}
+
+// Summaries returns posts matching f, oldest first, with only their ID
+// and timestamps loaded. It is meant for listings of many posts where
+// the content is not needed.
+func (r repo) Summaries(ctx context.Context, f post.Filter, skip, limit int64) (posts []*post.Post, err error) {
+	ctx, end := instrument.Op(ctx, "post", "Summaries")
+	defer end()
+
+	coll := r.db.Collection(post.CollectionName)
+
+	opts := options.Find().
+		SetProjection(bson.M{"_id": 1, "created_at": 1, "updated_at": 1}).
+		SetSkip(skip).
+		SetLimit(limit).
+		SetSort(bson.D{{Key: "created_at", Value: 1}})
+
+	cursor, err := coll.Find(ctx, filterDoc(f), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	err = cursor.All(ctx, &posts)
+	return
+}
+
+// Terms returns the distinct values of an array field ("tags" or
+// "categories") among posts matching f, with the number of posts using
+// each and the newest update among them.
+func (r repo) Terms(ctx context.Context, field string, f post.Filter) (terms []post.Term, err error) {
+	ctx, end := instrument.Op(ctx, "post", "Terms")
+	defer end()
+
+	coll := r.db.Collection(post.CollectionName)
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filterDoc(f)}},
+		{{Key: "$unwind", Value: "$" + field}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$" + field},
+			{Key: "count", Value: bson.M{"$sum": 1}},
+			{Key: "updated_at", Value: bson.M{"$max": "$updated_at"}},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}},
+	}
+
+	cursor, err := coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	err = cursor.All(ctx, &terms)
+	return
+}
+
+// Export streams every post matching f to fn, oldest first.
+// Iteration stops at the first error returned by fn.
+func (r repo) Export(ctx context.Context, f post.Filter, fn func(*post.Post) error) error {
+	ctx, end := instrument.Op(ctx, "post", "Export")
+	defer end()
+
+	coll := r.db.Collection(post.CollectionName)
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+
+	cursor, err := coll.Find(ctx, filterDoc(f), opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var p post.Post
+		if err := cursor.Decode(&p); err != nil {
+			return err
+		}
+		if err := fn(&p); err != nil {
+			return err
+		}
+	}
+
+	return cursor.Err()
+}
+
+// BulkUpsert writes posts in a single unordered BulkWrite. Posts are matched
+// by ID when set, otherwise by slug; posts with neither are inserted.
+// Zero timestamps are filled with the current time.
+//
+// Write errors for individual documents are returned in failed, keyed by
+// the post's index in posts; err is reserved for failures of the batch itself.
+func (r repo) BulkUpsert(ctx context.Context, posts []*post.Post) (failed map[int]error, err error) {
+	ctx, end := instrument.Op(ctx, "post", "BulkUpsert")
+	defer end()
+
+	if len(posts) == 0 {
+		return nil, nil
+	}
+
+	coll := r.db.Collection(post.CollectionName)
+
+	now := time.Now()
+	models := make([]mongo.WriteModel, 0, len(posts))
+	for _, p := range posts {
+		if p.CreatedAt.IsZero() {
+			p.CreatedAt = now
+		}
+		if p.UpdatedAt.IsZero() {
+			p.UpdatedAt = p.CreatedAt
+		}
+
+		switch {
+		case p.ID != "":
+			objID, err := bson.ObjectIDFromHex(p.ID)
+			if err != nil {
+				return nil, err
+			}
+			models = append(models, mongo.NewReplaceOneModel().
+				SetFilter(bson.M{"_id": objID}).
+				SetReplacement(p).
+				SetUpsert(true))
+		case p.Slug != "":
+			models = append(models, mongo.NewReplaceOneModel().
+				SetFilter(bson.M{"slug": p.Slug}).
+				SetReplacement(p).
+				SetUpsert(true))
+		default:
+			models = append(models, mongo.NewInsertOneModel().SetDocument(p))
+		}
+	}
+
+	_, err = coll.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+
+	var bulkErr mongo.BulkWriteException
+	if errors.As(err, &bulkErr) && len(bulkErr.WriteErrors) > 0 && bulkErr.WriteConcernError == nil {
+		failed = make(map[int]error, len(bulkErr.WriteErrors))
+		for _, we := range bulkErr.WriteErrors {
+			failed[we.Index] = errors.New(we.Message)
+		}
+		return failed, nil
+	}
+
+	return nil, err
+}
+
+func filterDoc(f post.Filter) bson.M {
+	filter := bson.M{}
+
+	created := bson.M{}
+	if !f.From.IsZero() {
+		created["$gte"] = f.From
+	}
+	if !f.To.IsZero() {
+		created["$lte"] = f.To
+	}
+	if len(created) > 0 {
+		filter["created_at"] = created
+	}
+
+	switch f.Status {
+	case "":
+	case post.StatusPublished:
+		// documents without a status predate drafts and count as published
+		filter["status"] = bson.M{"$in": bson.A{post.StatusPublished, "", nil}}
+	default:
+		filter["status"] = f.Status
+	}
+
+	if f.Tag != "" {
+		filter["tags"] = f.Tag
+	}
+	if f.Category != "" {
+		filter["categories"] = f.Category
+	}
+	if f.Author != "" {
+		filter["author"] = f.Author
+	}
+
+	return filter
+}
+
 func (r repo) EnsureIndexes(ctx context.Context) error {
 	coll := r.db.Collection(post.CollectionName)
 
@@ -199,8 +701,39 @@ func (r repo) EnsureIndexes(ctx context.Context) error {
 			Keys:    bson.D{{Key: "created_at", Value: -1}},
 			Options: options.Index().SetName("created_at_desc"),
 		},
+		{
+			Keys:    bson.D{{Key: "tags", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("tags_created_at"),
+		},
+		{
+			Keys:    bson.D{{Key: "categories", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("categories_created_at"),
+		},
+		{
+			Keys:    bson.D{{Key: "author", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("author_created_at"),
+		},
+		{
+			Keys:    bson.D{{Key: "reaction_count", Value: -1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("reaction_count_created_at"),
+		},
+		{
+			Keys:    bson.D{{Key: "related", Value: 1}},
+			Options: options.Index().SetName("related"),
+		},
+		{
+			Keys:    bson.D{{Key: "slug", Value: 1}},
+			Options: options.Index().SetName("slug_unique").SetUnique(true).SetSparse(true),
+		},
+	}
+
+	if _, err := coll.Indexes().CreateMany(ctx, indexes); err != nil {
+		return err
 	}
 
-	_, err := coll.Indexes().CreateMany(ctx, indexes)
+	_, err := r.db.Collection(post.TombstonesCollectionName).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "deleted_at", Value: 1}},
+		Options: options.Index().SetName("deleted_at_ttl").SetExpireAfterSeconds(int32(post.TombstoneTTL.Seconds())),
+	})
 	return err
 }
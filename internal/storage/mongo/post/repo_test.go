@@ -341,6 +341,244 @@ func TestGetRecent(t *testing.T) {
 	assert.Len(t, allRecentPosts, 5)
 }
 
+func TestGetRecentBy(t *testing.T) {
+	ctx := context.Background()
+	_, repo, cleanup := setupTest(t)
+	defer cleanup()
+
+	posts := []*post.Post{
+		{Title: "Go 1", Content: "C", Author: "jane", Tags: []string{"go"}},
+		{Title: "Rust", Content: "C", Author: "john", Tags: []string{"rust"}},
+		{Title: "Go 2", Content: "C", Author: "john", Tags: []string{"go", "news"}},
+		{Title: "Go draft", Content: "C", Tags: []string{"go"}, Status: post.StatusDraft},
+	}
+	for _, p := range posts {
+		_, err := repo.Create(ctx, p)
+		require.NoError(t, err)
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	found, err := repo.GetRecentBy(ctx, post.Filter{Tag: "go", Status: post.StatusPublished}, 10)
+	require.NoError(t, err)
+	require.Len(t, found, 2)
+	assert.Equal(t, "Go 2", found[0].Title)
+	assert.Equal(t, "Go 1", found[1].Title)
+
+	found, err = repo.GetRecentBy(ctx, post.Filter{Author: "john"}, 1)
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, "Go 2", found[0].Title)
+}
+
+func TestSummariesAndTerms(t *testing.T) {
+	ctx := context.Background()
+	_, repo, cleanup := setupTest(t)
+	defer cleanup()
+
+	posts := []*post.Post{
+		{Title: "A", Content: "C", Tags: []string{"go", "news"}, Categories: []string{"tech"}},
+		{Title: "B", Content: "C", Tags: []string{"go"}},
+		{Title: "C", Content: "C", Tags: []string{"secret"}, Status: post.StatusDraft},
+	}
+	for _, p := range posts {
+		_, err := repo.Create(ctx, p)
+		require.NoError(t, err)
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	published := post.Filter{Status: post.StatusPublished}
+
+	count, err := repo.CountBy(ctx, published)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	summaries, err := repo.Summaries(ctx, published, 1, 10)
+	require.NoError(t, err)
+	require.Len(t, summaries, 1)
+	assert.Empty(t, summaries[0].Title)
+	assert.False(t, summaries[0].UpdatedAt.IsZero())
+
+	tags, err := repo.Terms(ctx, "tags", published)
+	require.NoError(t, err)
+	require.Len(t, tags, 2)
+	assert.Equal(t, "go", tags[0].Name)
+	assert.Equal(t, int64(2), tags[0].Count)
+	assert.True(t, tags[0].UpdatedAt.Equal(posts[1].UpdatedAt))
+	assert.Equal(t, "news", tags[1].Name)
+
+	categories, err := repo.Terms(ctx, "categories", published)
+	require.NoError(t, err)
+	require.Len(t, categories, 1)
+	assert.Equal(t, "tech", categories[0].Name)
+
+	byTag, total, err := repo.GetAllBy(ctx, post.Filter{Tag: "go"}, 1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	require.Len(t, byTag, 1)
+	assert.Equal(t, "B", byTag[0].Title)
+}
+
+func TestExport(t *testing.T) {
+	ctx := context.Background()
+	_, repo, cleanup := setupTest(t)
+	defer cleanup()
+
+	err := createMultiplePosts(ctx, repo, 3)
+	require.NoError(t, err)
+
+	draft := &post.Post{Title: "Draft", Content: "Draft", Status: post.StatusDraft}
+	_, err = repo.Create(ctx, draft)
+	require.NoError(t, err)
+
+	var titles []string
+	err = repo.Export(ctx, post.Filter{}, func(p *post.Post) error {
+		titles = append(titles, p.Title)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Title A", "Title B", "Title C", "Draft"}, titles)
+
+	titles = nil
+	err = repo.Export(ctx, post.Filter{Status: post.StatusDraft}, func(p *post.Post) error {
+		titles = append(titles, p.Title)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Draft"}, titles)
+
+	titles = nil
+	err = repo.Export(ctx, post.Filter{From: draft.CreatedAt}, func(p *post.Post) error {
+		titles = append(titles, p.Title)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Draft"}, titles)
+}
+
+func TestBulkUpsert(t *testing.T) {
+	ctx := context.Background()
+	_, repo, cleanup := setupTest(t)
+	defer cleanup()
+
+	existing := createSamplePost()
+	id, err := repo.Create(ctx, existing)
+	require.NoError(t, err)
+
+	created := time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC)
+	posts := []*post.Post{
+		{ID: id, Title: "Replaced", Content: "Replaced"},
+		{Slug: "by-slug", Title: "Slug", Content: "Slug", CreatedAt: created},
+		{Title: "Plain", Content: "Plain"},
+	}
+
+	failed, err := repo.BulkUpsert(ctx, posts)
+	require.NoError(t, err)
+	assert.Empty(t, failed)
+
+	replaced, err := repo.GetByID(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, "Replaced", replaced.Title)
+
+	// running the same import again must not create duplicates
+	posts[1].Title = "Slug v2"
+	failed, err = repo.BulkUpsert(ctx, posts[1:2])
+	require.NoError(t, err)
+	assert.Empty(t, failed)
+
+	all, total, err := repo.GetAll(ctx, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+
+	var slugged *post.Post
+	for _, p := range all {
+		if p.Slug == "by-slug" {
+			slugged = p
+		}
+	}
+	require.NotNil(t, slugged)
+	assert.Equal(t, "Slug v2", slugged.Title)
+	assert.True(t, created.Equal(slugged.CreatedAt))
+}
+
+func TestGetByIDs(t *testing.T) {
+	ctx := context.Background()
+	_, repo, cleanup := setupTest(t)
+	defer cleanup()
+
+	require.NoError(t, createMultiplePosts(ctx, repo, 3))
+	all, _, err := repo.GetAll(ctx, 1, 10)
+	require.NoError(t, err)
+
+	posts, err := repo.GetByIDs(ctx, []string{all[0].ID, all[2].ID, bson.NewObjectID().Hex()})
+	require.NoError(t, err)
+	require.Len(t, posts, 2)
+	assert.ElementsMatch(t, []string{all[0].ID, all[2].ID}, []string{posts[0].ID, posts[1].ID})
+
+	_, err = repo.GetByIDs(ctx, []string{"nope"})
+	assert.Error(t, err)
+}
+
+func TestAddReaction(t *testing.T) {
+	ctx := context.Background()
+	_, repo, cleanup := setupTest(t)
+	defer cleanup()
+
+	id, err := repo.Create(ctx, createSamplePost())
+	require.NoError(t, err)
+
+	counts, err := repo.AddReaction(ctx, id, "like", 1)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int64{"like": 1}, counts)
+
+	_, err = repo.AddReaction(ctx, id, "sad", 1)
+	require.NoError(t, err)
+	counts, err = repo.AddReaction(ctx, id, "like", -1)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int64{"like": 0, "sad": 1}, counts)
+
+	p, err := repo.GetByID(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), p.ReactionCount)
+
+	// an edit must not reset the counts
+	p.Title = "Edited"
+	require.NoError(t, repo.Update(ctx, p))
+	p, err = repo.GetByID(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), p.Reactions["sad"])
+
+	_, err = repo.AddReaction(ctx, bson.NewObjectID().Hex(), "like", 1)
+	assert.ErrorIs(t, err, config.ErrPostNotFound)
+}
+
+func TestGetAllSorted(t *testing.T) {
+	ctx := context.Background()
+	_, repo, cleanup := setupTest(t)
+	defer cleanup()
+
+	require.NoError(t, createMultiplePosts(ctx, repo, 3))
+	all, _, err := repo.GetAll(ctx, 1, 10)
+	require.NoError(t, err)
+
+	// the oldest post gets the most reactions
+	oldest := all[2].ID
+	for range 2 {
+		_, err = repo.AddReaction(ctx, oldest, "love", 1)
+		require.NoError(t, err)
+	}
+	_, err = repo.AddReaction(ctx, all[1].ID, "like", 1)
+	require.NoError(t, err)
+
+	sorted, total, err := repo.GetAllSorted(ctx, post.SortReactions, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	assert.Equal(t, []string{oldest, all[1].ID, all[0].ID}, []string{sorted[0].ID, sorted[1].ID, sorted[2].ID})
+
+	sorted, _, err = repo.GetAllSorted(ctx, post.SortNewest, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, all[0].ID, sorted[0].ID)
+}
+
 func TestEnsureIndexes(t *testing.T) {
 	ctx := context.Background()
 	db, repo, cleanup := setupTest(t)
@@ -358,3 +596,62 @@ func TestEnsureIndexes(t *testing.T) {
 	err = repo.EnsureIndexes(ctx)
 	assert.NoError(t, err)
 }
+
+func TestSetRelated(t *testing.T) {
+	ctx := context.Background()
+	_, repo, cleanup := setupTest(t)
+	defer cleanup()
+
+	a, err := repo.Create(ctx, createSamplePost())
+	require.NoError(t, err)
+	b, err := repo.Create(ctx, createSamplePost())
+	require.NoError(t, err)
+	c, err := repo.Create(ctx, createSamplePost())
+	require.NoError(t, err)
+
+	require.NoError(t, repo.SetRelated(ctx, a, []string{b, c}))
+	require.NoError(t, repo.SetRelated(ctx, b, []string{c}))
+
+	p, err := repo.GetByID(ctx, a)
+	require.NoError(t, err)
+	assert.Equal(t, []string{b, c}, p.Related)
+
+	ids, err := repo.RelatedTo(ctx, c)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{a, b}, ids)
+
+	require.NoError(t, repo.SetRelated(ctx, a, nil))
+	p, err = repo.GetByID(ctx, a)
+	require.NoError(t, err)
+	assert.Empty(t, p.Related)
+
+	err = repo.SetRelated(ctx, bson.NewObjectID().Hex(), []string{b})
+	assert.ErrorIs(t, err, config.ErrPostNotFound)
+}
+
+func TestChangedAndDeletedSince(t *testing.T) {
+	ctx := context.Background()
+	_, repo, cleanup := setupTest(t)
+	defer cleanup()
+
+	since := time.Now().Add(-time.Second)
+
+	a, err := repo.Create(ctx, createSamplePost())
+	require.NoError(t, err)
+	b, err := repo.Create(ctx, createSamplePost())
+	require.NoError(t, err)
+	require.NoError(t, repo.Delete(ctx, a))
+
+	changed, err := repo.ChangedSince(ctx, since, 10)
+	require.NoError(t, err)
+	require.Len(t, changed, 1)
+	assert.Equal(t, b, changed[0].ID)
+
+	deleted, err := repo.DeletedSince(ctx, since, 10)
+	require.NoError(t, err)
+	assert.Contains(t, deleted, a)
+
+	deleted, err = repo.DeletedSince(ctx, time.Now().Add(time.Minute), 10)
+	require.NoError(t, err)
+	assert.Empty(t, deleted)
+}
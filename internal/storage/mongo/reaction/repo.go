@@ -0,0 +1,111 @@
+package reaction
+
+import (
+	"context"
+	"news-svc/internal/entity/reaction"
+	"news-svc/internal/storage/mongo/instrument"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type repo struct {
+	db *mongo.Database
+}
+
+func New(db *mongo.Database) repo {
+	return repo{db}
+}
+
+// Add records a reaction. It reports false, without an error, when the
+// reactor has already reacted to the post with the same kind.
+func (r repo) Add(ctx context.Context, rc *reaction.Reaction) (bool, error) {
+	ctx, end := instrument.Op(ctx, "reaction", "Add")
+	defer end()
+
+	coll := r.db.Collection(reaction.CollectionName)
+
+	if rc.CreatedAt.IsZero() {
+		rc.CreatedAt = time.Now()
+	}
+
+	_, err := coll.InsertOne(ctx, rc)
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Remove deletes a reaction and reports whether there was one.
+func (r repo) Remove(ctx context.Context, postID, reactor, kind string) (bool, error) {
+	ctx, end := instrument.Op(ctx, "reaction", "Remove")
+	defer end()
+
+	coll := r.db.Collection(reaction.CollectionName)
+
+	objID, err := bson.ObjectIDFromHex(postID)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := coll.DeleteOne(ctx, bson.M{"reactor": reactor, "post_id": objID, "kind": kind})
+	if err != nil {
+		return false, err
+	}
+	return result.DeletedCount > 0, nil
+}
+
+// Mine returns the kinds the reactor has reacted with, keyed by post ID.
+func (r repo) Mine(ctx context.Context, reactor string, postIDs []string) (map[string][]string, error) {
+	ctx, end := instrument.Op(ctx, "reaction", "Mine")
+	defer end()
+
+	coll := r.db.Collection(reaction.CollectionName)
+
+	ids := make(bson.A, 0, len(postIDs))
+	for _, id := range postIDs {
+		if objID, err := bson.ObjectIDFromHex(id); err == nil {
+			ids = append(ids, objID)
+		}
+	}
+
+	filter := bson.M{"reactor": reactor, "post_id": bson.M{"$in": ids}}
+	cursor, err := coll.Find(ctx, filter, options.Find().SetProjection(bson.M{"post_id": 1, "kind": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	mine := make(map[string][]string)
+	for cursor.Next(ctx) {
+		var rc reaction.Reaction
+		if err := cursor.Decode(&rc); err != nil {
+			return nil, err
+		}
+		mine[rc.PostID] = append(mine[rc.PostID], rc.Kind)
+	}
+
+	return mine, cursor.Err()
+}
+
+func (r repo) EnsureIndexes(ctx context.Context) error {
+	coll := r.db.Collection(reaction.CollectionName)
+
+	indexes := []mongo.IndexModel{
+		{
+			// one reaction of each kind per reader and post; also serves
+			// the lookups by reader for a page of posts
+			Keys: bson.D{
+				{Key: "reactor", Value: 1},
+				{Key: "post_id", Value: 1},
+				{Key: "kind", Value: 1},
+			},
+			Options: options.Index().SetName("reactor_post_kind_unique").SetUnique(true),
+		},
+	}
+
+	_, err := coll.Indexes().CreateMany(ctx, indexes)
+	return err
+}
@@ -0,0 +1,325 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"news-svc/config"
+	"news-svc/internal/entity/webhook"
+	"news-svc/internal/storage/mongo/instrument"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// duplicateKey is the server error code for unique index violations.
+const duplicateKey = 11000
+
+type repo struct {
+	db *mongo.Database
+}
+
+func New(db *mongo.Database) repo {
+	return repo{db}
+}
+
+func (r repo) Create(ctx context.Context, w *webhook.Webhook) (string, error) {
+	ctx, end := instrument.Op(ctx, "webhook", "Create")
+	defer end()
+
+	coll := r.db.Collection(webhook.CollectionName)
+
+	result, err := coll.InsertOne(ctx, w)
+	if err != nil {
+		return "", err
+	}
+
+	oid, ok := result.InsertedID.(bson.ObjectID)
+	if !ok {
+		return "", errors.New("failed to get inserted ID")
+	}
+
+	w.ID = oid.Hex()
+	return w.ID, nil
+}
+
+func (r repo) GetByID(ctx context.Context, id string) (*webhook.Webhook, error) {
+	ctx, end := instrument.Op(ctx, "webhook", "GetByID")
+	defer end()
+
+	coll := r.db.Collection(webhook.CollectionName)
+
+	objID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, config.ErrWebhookNotFound
+	}
+
+	var w webhook.Webhook
+	err = coll.FindOne(ctx, bson.M{"_id": objID}).Decode(&w)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, config.ErrWebhookNotFound
+		}
+		return nil, err
+	}
+
+	return &w, nil
+}
+
+// GetAll returns every webhook, oldest first.
+func (r repo) GetAll(ctx context.Context) (hooks []*webhook.Webhook, err error) {
+	ctx, end := instrument.Op(ctx, "webhook", "GetAll")
+	defer end()
+
+	coll := r.db.Collection(webhook.CollectionName)
+
+	cursor, err := coll.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	err = cursor.All(ctx, &hooks)
+	return
+}
+
+// Subscribed returns the active webhooks subscribed to event.
+func (r repo) Subscribed(ctx context.Context, event string) (hooks []*webhook.Webhook, err error) {
+	ctx, end := instrument.Op(ctx, "webhook", "Subscribed")
+	defer end()
+
+	coll := r.db.Collection(webhook.CollectionName)
+
+	cursor, err := coll.Find(ctx, bson.M{"active": true, "events": event})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	err = cursor.All(ctx, &hooks)
+	return
+}
+
+func (r repo) SetActive(ctx context.Context, id string, active bool) error {
+	ctx, end := instrument.Op(ctx, "webhook", "SetActive")
+	defer end()
+
+	coll := r.db.Collection(webhook.CollectionName)
+
+	objID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return config.ErrWebhookNotFound
+	}
+
+	result, err := coll.UpdateOne(ctx, bson.M{"_id": objID}, bson.M{"$set": bson.M{"active": active}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return config.ErrWebhookNotFound
+	}
+
+	return nil
+}
+
+// Delete removes a webhook together with its deliveries.
+func (r repo) Delete(ctx context.Context, id string) error {
+	ctx, end := instrument.Op(ctx, "webhook", "Delete")
+	defer end()
+
+	objID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return config.ErrWebhookNotFound
+	}
+
+	result, err := r.db.Collection(webhook.CollectionName).DeleteOne(ctx, bson.M{"_id": objID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return config.ErrWebhookNotFound
+	}
+
+	_, err = r.db.Collection(webhook.DeliveriesCollectionName).DeleteMany(ctx, bson.M{"webhook_id": objID})
+	return err
+}
+
+// Enqueue stores new deliveries and sets their IDs. Deliveries of an
+// event a webhook has already been queued, e.g. by another instance,
+// are skipped.
+func (r repo) Enqueue(ctx context.Context, deliveries []*webhook.Delivery) error {
+	ctx, end := instrument.Op(ctx, "webhook", "Enqueue")
+	defer end()
+
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	coll := r.db.Collection(webhook.DeliveriesCollectionName)
+
+	docs := make([]any, len(deliveries))
+	for i, d := range deliveries {
+		if d.ID == "" {
+			d.ID = bson.NewObjectID().Hex()
+		}
+		docs[i] = d
+	}
+
+	_, err := coll.InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+
+	var bulkErr mongo.BulkWriteException
+	if errors.As(err, &bulkErr) && bulkErr.WriteConcernError == nil {
+		for _, we := range bulkErr.WriteErrors {
+			if we.Code != duplicateKey {
+				return err
+			}
+		}
+		return nil
+	}
+	return err
+}
+
+// Claim takes the pending delivery that has been due the longest and
+// hides it from other workers until the lease runs out, so a delivery
+// whose worker died is picked up again. It returns nil when nothing is
+// due.
+func (r repo) Claim(ctx context.Context, now time.Time, lease time.Duration) (*webhook.Delivery, error) {
+	ctx, end := instrument.Op(ctx, "webhook", "Claim")
+	defer end()
+
+	coll := r.db.Collection(webhook.DeliveriesCollectionName)
+
+	filter := bson.M{
+		"status":          webhook.DeliveryPending,
+		"next_attempt_at": bson.M{"$lte": now},
+		"$or": bson.A{
+			bson.M{"locked_until": bson.M{"$exists": false}},
+			bson.M{"locked_until": bson.M{"$lte": now}},
+		},
+	}
+	update := bson.M{"$set": bson.M{"locked_until": now.Add(lease)}}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "next_attempt_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var d webhook.Delivery
+	err := coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&d)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &d, nil
+}
+
+// Record adds an attempt to a claimed delivery, sets its new status and
+// next attempt time, and releases it.
+func (r repo) Record(ctx context.Context, id string, a webhook.Attempt, status string, next time.Time) error {
+	ctx, end := instrument.Op(ctx, "webhook", "Record")
+	defer end()
+
+	coll := r.db.Collection(webhook.DeliveriesCollectionName)
+
+	objID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return config.ErrDeliveryNotFound
+	}
+
+	update := bson.M{
+		"$push":  bson.M{"attempts": a},
+		"$set":   bson.M{"status": status, "next_attempt_at": next, "updated_at": a.At},
+		"$unset": bson.M{"locked_until": ""},
+	}
+	result, err := coll.UpdateOne(ctx, bson.M{"_id": objID}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return config.ErrDeliveryNotFound
+	}
+
+	return nil
+}
+
+func (r repo) GetDelivery(ctx context.Context, id string) (*webhook.Delivery, error) {
+	ctx, end := instrument.Op(ctx, "webhook", "GetDelivery")
+	defer end()
+
+	coll := r.db.Collection(webhook.DeliveriesCollectionName)
+
+	objID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, config.ErrDeliveryNotFound
+	}
+
+	var d webhook.Delivery
+	err = coll.FindOne(ctx, bson.M{"_id": objID}).Decode(&d)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, config.ErrDeliveryNotFound
+		}
+		return nil, err
+	}
+
+	return &d, nil
+}
+
+// Deliveries returns a page of a webhook's deliveries, newest first.
+func (r repo) Deliveries(ctx context.Context, webhookID string, page, limit int64) (deliveries []*webhook.Delivery, total int64, err error) {
+	ctx, end := instrument.Op(ctx, "webhook", "Deliveries")
+	defer end()
+
+	coll := r.db.Collection(webhook.DeliveriesCollectionName)
+
+	objID, err := bson.ObjectIDFromHex(webhookID)
+	if err != nil {
+		return nil, 0, config.ErrWebhookNotFound
+	}
+	filter := bson.M{"webhook_id": objID}
+
+	total, err = coll.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSkip(max((page-1)*limit, 0)).
+		SetLimit(limit).
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}})
+
+	cursor, err := coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	err = cursor.All(ctx, &deliveries)
+	return
+}
+
+func (r repo) EnsureIndexes(ctx context.Context) error {
+	retention := int32(webhook.DeliveryRetention.Seconds())
+
+	_, err := r.db.Collection(webhook.DeliveriesCollectionName).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "webhook_id", Value: 1}, {Key: "event_id", Value: 1}},
+			Options: options.Index().SetName("webhook_event_unique").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}},
+			Options: options.Index().SetName("status_next_attempt_at"),
+		},
+		{
+			Keys:    bson.D{{Key: "webhook_id", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("webhook_created_at"),
+		},
+		{
+			Keys:    bson.D{{Key: "created_at", Value: 1}},
+			Options: options.Index().SetName("created_at_ttl").SetExpireAfterSeconds(retention),
+		},
+	})
+	return err
+}
@@ -14,5 +14,13 @@ func main() {
 		os.Exit(1)
 	}
 
+	if len(os.Args) > 1 {
+		if err := app.RunCommand(cfg, os.Args[1], os.Args[2:]); err != nil {
+			slog.Error("command failed", "command", os.Args[1], "err", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	app.Run(cfg)
 }
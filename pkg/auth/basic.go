@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+)
+
+type ctxKey struct{}
+
+// Basic - protects handlers with HTTP Basic authentication against
+// a single configured account.
+type Basic struct {
+	user     string
+	password string
+}
+
+// NewBasic - creates Basic auth for the given credentials. An empty
+// password disables access entirely rather than allowing anyone in.
+func NewBasic(user, password string) Basic {
+	return Basic{user, password}
+}
+
+// Wrap - rejects requests without valid credentials with 401.
+func (b Basic) Wrap(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := b.Check(r)
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Basic realm="admin", charset="UTF-8"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		next(w, r.WithContext(context.WithValue(r.Context(), ctxKey{}, user)))
+	}
+}
+
+// Check - returns the user name when r carries valid credentials.
+func (b Basic) Check(r *http.Request) (string, bool) {
+	user, password, ok := r.BasicAuth()
+	if !ok || !b.valid(user, password) {
+		return "", false
+	}
+	return user, true
+}
+
+func (b Basic) valid(user, password string) bool {
+	if b.password == "" {
+		return false
+	}
+
+	userOK := subtle.ConstantTimeCompare([]byte(user), []byte(b.user)) == 1
+	passOK := subtle.ConstantTimeCompare([]byte(password), []byte(b.password)) == 1
+	return userOK && passOK
+}
+
+// User - returns the authenticated user name stored by Wrap, if any.
+func User(ctx context.Context) string {
+	user, _ := ctx.Value(ctxKey{}).(string)
+	return user
+}
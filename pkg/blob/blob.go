@@ -0,0 +1,34 @@
+// Package blob stores opaque binary objects under string keys.
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path"
+	"strings"
+)
+
+var (
+	ErrNotFound   = errors.New("blob: not found")
+	ErrInvalidKey = errors.New("blob: invalid key")
+)
+
+// Store - a flat key/value store for binary objects. Keys are
+// slash-separated relative paths such as "ab12/original.jpg".
+type Store interface {
+	// Put writes the content of r under key, replacing any existing object.
+	Put(ctx context.Context, key string, r io.Reader) error
+	// Open returns a reader for key, or ErrNotFound.
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes key. Deleting a missing key is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// validKey rejects keys that could escape the store's namespace.
+func validKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, `\`) {
+		return false
+	}
+	return path.Clean(key) == key && !strings.HasPrefix(key, "../") && key != ".."
+}
@@ -0,0 +1,61 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// GridFS - a Store backed by a MongoDB GridFS bucket. The key is used
+// as both the file ID and its name.
+type GridFS struct {
+	bucket *mongo.GridFSBucket
+}
+
+// NewGridFS - creates a GridFS store using the named bucket of db.
+func NewGridFS(db *mongo.Database, bucket string) *GridFS {
+	return &GridFS{db.GridFSBucket(options.GridFSBucket().SetName(bucket))}
+}
+
+func (g *GridFS) Put(ctx context.Context, key string, r io.Reader) error {
+	if !validKey(key) {
+		return ErrInvalidKey
+	}
+
+	// GridFS files are immutable; replacing means delete and re-upload
+	if err := g.Delete(ctx, key); err != nil {
+		return err
+	}
+
+	return g.bucket.UploadFromStreamWithID(ctx, key, key, r)
+}
+
+func (g *GridFS) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	if !validKey(key) {
+		return nil, ErrInvalidKey
+	}
+
+	stream, err := g.bucket.OpenDownloadStream(ctx, key)
+	if errors.Is(err, mongo.ErrFileNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return stream, nil
+}
+
+func (g *GridFS) Delete(ctx context.Context, key string) error {
+	if !validKey(key) {
+		return ErrInvalidKey
+	}
+
+	err := g.bucket.Delete(ctx, key)
+	if errors.Is(err, mongo.ErrFileNotFound) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,44 @@
+package blob
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocal(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewLocal(t.TempDir())
+	require.NoError(t, err)
+
+	require.NoError(t, store.Put(ctx, "ab/original.jpg", strings.NewReader("v1")))
+	require.NoError(t, store.Put(ctx, "ab/original.jpg", strings.NewReader("v2")))
+
+	rc, err := store.Open(ctx, "ab/original.jpg")
+	require.NoError(t, err)
+	data, _ := io.ReadAll(rc)
+	rc.Close()
+	assert.Equal(t, "v2", string(data))
+
+	require.NoError(t, store.Delete(ctx, "ab/original.jpg"))
+	require.NoError(t, store.Delete(ctx, "ab/original.jpg"))
+
+	_, err = store.Open(ctx, "ab/original.jpg")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestLocalRejectsTraversal(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewLocal(t.TempDir())
+	require.NoError(t, err)
+
+	for _, key := range []string{"../x", "/etc/passwd", "a/../../x", "", "a//b", `a\b`} {
+		assert.ErrorIs(t, store.Put(ctx, key, strings.NewReader("x")), ErrInvalidKey, key)
+		_, err := store.Open(ctx, key)
+		assert.ErrorIs(t, err, ErrInvalidKey, key)
+	}
+}
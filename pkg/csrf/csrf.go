@@ -0,0 +1,126 @@
+// Package csrf protects state-changing requests against cross-site
+// request forgery with a token tied to a per-browser session cookie.
+package csrf
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"log/slog"
+	"net/http"
+)
+
+const (
+	// Header carries the token on protected requests. Pages set it for
+	// every htmx request with hx-headers.
+	Header = "X-CSRF-Token"
+
+	cookieName = "csrf_session"
+	sessionLen = 32
+)
+
+// failurePage is the fragment answering rejected requests. htmx does
+// not swap error responses by itself; pages opt in for responses with
+// the X-CSRF-Failure header.
+const failurePage = `<p class="error" role="alert">This page has expired. Reload it and try again.</p>`
+
+type (
+	tokenKey struct{}
+
+	// Protect - issues and checks tokens.
+	Protect struct {
+		secret []byte
+		secure bool
+		l      *slog.Logger
+	}
+)
+
+// New - creates Protect signing tokens with secret, or with a random
+// secret, valid until the next restart, when it is empty. secure marks
+// the session cookie for HTTPS only.
+func New(secret []byte, secure bool, l *slog.Logger) *Protect {
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		_, _ = rand.Read(secret)
+	}
+	return &Protect{secret: secret, secure: secure, l: l}
+}
+
+// HTTP - gives browsers without one a session cookie, makes its token
+// available to handlers through Token, and rejects requests with
+// methods other than GET, HEAD, OPTIONS and TRACE that do not carry
+// the token in the X-CSRF-Token header with 403 Forbidden.
+func (p *Protect) HTTP(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		session, ok := p.session(r)
+		if !ok {
+			session = newSession()
+			http.SetCookie(w, &http.Cookie{
+				Name:     cookieName,
+				Value:    session,
+				Path:     "/",
+				HttpOnly: true,
+				Secure:   p.secure,
+				SameSite: http.SameSiteLaxMode,
+			})
+		}
+		token := p.token(session)
+
+		if !safe(r.Method) && (!ok || !hmac.Equal([]byte(r.Header.Get(Header)), []byte(token))) {
+			p.l.WarnContext(r.Context(), "CSRF check failed", "method", r.Method, "path", r.URL.Path, "session", ok)
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Header().Set("X-CSRF-Failure", "1")
+			w.Header().Set("HX-Retarget", "body")
+			w.Header().Set("HX-Reswap", "afterbegin")
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(failurePage))
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), tokenKey{}, token)))
+	})
+}
+
+// Token - returns the token for the request's session, to be sent back
+// in the X-CSRF-Token header.
+func Token(ctx context.Context) string {
+	token, _ := ctx.Value(tokenKey{}).(string)
+	return token
+}
+
+// session returns the session ID from the request's cookie.
+func (p *Protect) session(r *http.Request) (string, bool) {
+	c, err := r.Cookie(cookieName)
+	if err != nil {
+		return "", false
+	}
+	b, err := base64.RawURLEncoding.DecodeString(c.Value)
+	if err != nil || len(b) != sessionLen {
+		return "", false
+	}
+	return c.Value, true
+}
+
+// token derives the token for a session, so none need to be stored.
+func (p *Protect) token(session string) string {
+	mac := hmac.New(sha256.New, p.secret)
+	mac.Write([]byte(session))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func newSession() string {
+	b := make([]byte, sessionLen)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// safe reports whether requests with method must not change state.
+func safe(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package csrf
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestHandler() (http.Handler, *string) {
+	var token string
+	p := New([]byte("secret"), false, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	return p.HTTP(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token = Token(r.Context())
+	})), &token
+}
+
+func TestIssuesSessionOnGet(t *testing.T) {
+	h, token := newTestHandler()
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/posts", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	require.Len(t, rr.Result().Cookies(), 1)
+	c := rr.Result().Cookies()[0]
+	assert.Equal(t, cookieName, c.Name)
+	assert.True(t, c.HttpOnly)
+	assert.Equal(t, http.SameSiteLaxMode, c.SameSite)
+	assert.NotEmpty(t, *token)
+	assert.NotEqual(t, c.Value, *token)
+}
+
+func TestChecksUnsafeMethods(t *testing.T) {
+	h, token := newTestHandler()
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/posts", nil))
+	session := rr.Result().Cookies()[0]
+	valid := *token
+
+	tests := []struct {
+		name    string
+		cookie  bool
+		header  string
+		allowed bool
+	}{
+		{"valid", true, valid, true},
+		{"missing token", true, "", false},
+		{"wrong token", true, valid[1:] + "x", false},
+		{"no session", false, valid, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/posts", nil)
+			if tt.cookie {
+				req.AddCookie(session)
+			}
+			if tt.header != "" {
+				req.Header.Set(Header, tt.header)
+			}
+			*token = ""
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+
+			if tt.allowed {
+				assert.Equal(t, http.StatusOK, rr.Code)
+				assert.Equal(t, valid, *token)
+				return
+			}
+			assert.Equal(t, http.StatusForbidden, rr.Code)
+			assert.Empty(t, *token, "handler must not run")
+			assert.Equal(t, "1", rr.Header().Get("X-CSRF-Failure"))
+			assert.Equal(t, "body", rr.Header().Get("HX-Retarget"))
+			assert.Contains(t, rr.Body.String(), "Reload it and try again")
+		})
+	}
+}
+
+func TestTokenDependsOnSecret(t *testing.T) {
+	a := New([]byte("a"), false, slog.Default())
+	b := New([]byte("b"), false, slog.Default())
+
+	session := newSession()
+	assert.Equal(t, a.token(session), a.token(session))
+	assert.NotEqual(t, a.token(session), b.token(session))
+	assert.NotEqual(t, a.token(session), a.token(newSession()))
+}
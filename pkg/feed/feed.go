@@ -0,0 +1,273 @@
+// Package feed encodes syndication feeds in RSS 2.0, Atom 1.0 and
+// JSON Feed 1.1 from a single format-neutral description.
+package feed
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"time"
+)
+
+const (
+	ContentTypeRSS  = "application/rss+xml; charset=utf-8"
+	ContentTypeAtom = "application/atom+xml; charset=utf-8"
+	ContentTypeJSON = "application/feed+json; charset=utf-8"
+)
+
+type (
+	// Feed - a channel of items. Link is the HTML page the feed mirrors,
+	// Self the URL of the feed document itself.
+	Feed struct {
+		Title       string
+		Description string
+		Link        string
+		Self        string
+		Updated     time.Time
+		Items       []Item
+	}
+
+	// Item - a single entry. ID must be stable and globally unique;
+	// the permalink is a good choice.
+	Item struct {
+		ID         string
+		Title      string
+		Link       string
+		Summary    string
+		Content    string
+		Author     string
+		Categories []string
+		Published  time.Time
+		Updated    time.Time
+	}
+)
+
+// Updated - returns the newest Updated time among items, or the zero time.
+func Updated(items []Item) time.Time {
+	var newest time.Time
+	for _, it := range items {
+		if it.Updated.After(newest) {
+			newest = it.Updated
+		}
+	}
+	return newest
+}
+
+// WriteRSS - encodes f as RSS 2.0.
+func WriteRSS(w io.Writer, f Feed) error {
+	type (
+		guid struct {
+			IsPermaLink bool   `xml:"isPermaLink,attr"`
+			Value       string `xml:",chardata"`
+		}
+		atomLink struct {
+			Href string `xml:"href,attr"`
+			Rel  string `xml:"rel,attr"`
+			Type string `xml:"type,attr"`
+		}
+		item struct {
+			Title       string   `xml:"title"`
+			Link        string   `xml:"link"`
+			GUID        guid     `xml:"guid"`
+			Description string   `xml:"description"`
+			Author      string   `xml:"dc:creator,omitempty"`
+			Categories  []string `xml:"category"`
+			PubDate     string   `xml:"pubDate"`
+		}
+		channel struct {
+			Title         string   `xml:"title"`
+			Link          string   `xml:"link"`
+			Self          atomLink `xml:"atom:link"`
+			Description   string   `xml:"description"`
+			LastBuildDate string   `xml:"lastBuildDate,omitempty"`
+			Items         []item   `xml:"item"`
+		}
+		rss struct {
+			XMLName xml.Name `xml:"rss"`
+			Version string   `xml:"version,attr"`
+			Atom    string   `xml:"xmlns:atom,attr"`
+			DC      string   `xml:"xmlns:dc,attr"`
+			Channel channel  `xml:"channel"`
+		}
+	)
+
+	doc := rss{
+		Version: "2.0",
+		Atom:    "http://www.w3.org/2005/Atom",
+		DC:      "http://purl.org/dc/elements/1.1/",
+		Channel: channel{
+			Title:       f.Title,
+			Link:        f.Link,
+			Self:        atomLink{Href: f.Self, Rel: "self", Type: "application/rss+xml"},
+			Description: f.Description,
+		},
+	}
+	if !f.Updated.IsZero() {
+		doc.Channel.LastBuildDate = f.Updated.UTC().Format(time.RFC1123Z)
+	}
+
+	for _, it := range f.Items {
+		description := it.Content
+		if description == "" {
+			description = it.Summary
+		}
+		doc.Channel.Items = append(doc.Channel.Items, item{
+			Title:       it.Title,
+			Link:        it.Link,
+			GUID:        guid{IsPermaLink: it.ID == it.Link, Value: it.ID},
+			Description: description,
+			Author:      it.Author,
+			Categories:  it.Categories,
+			PubDate:     it.Published.UTC().Format(time.RFC1123Z),
+		})
+	}
+
+	return writeXML(w, doc)
+}
+
+// WriteAtom - encodes f as Atom 1.0.
+func WriteAtom(w io.Writer, f Feed) error {
+	type (
+		link struct {
+			Href string `xml:"href,attr"`
+			Rel  string `xml:"rel,attr,omitempty"`
+			Type string `xml:"type,attr,omitempty"`
+		}
+		text struct {
+			Type  string `xml:"type,attr,omitempty"`
+			Value string `xml:",chardata"`
+		}
+		person struct {
+			Name string `xml:"name"`
+		}
+		category struct {
+			Term string `xml:"term,attr"`
+		}
+		entry struct {
+			ID         string     `xml:"id"`
+			Title      string     `xml:"title"`
+			Link       link       `xml:"link"`
+			Published  string     `xml:"published"`
+			Updated    string     `xml:"updated"`
+			Author     *person    `xml:"author,omitempty"`
+			Categories []category `xml:"category"`
+			Summary    *text      `xml:"summary,omitempty"`
+			Content    *text      `xml:"content,omitempty"`
+		}
+		atom struct {
+			XMLName  xml.Name `xml:"http://www.w3.org/2005/Atom feed"`
+			ID       string   `xml:"id"`
+			Title    string   `xml:"title"`
+			Subtitle string   `xml:"subtitle,omitempty"`
+			Updated  string   `xml:"updated"`
+			Links    []link   `xml:"link"`
+			Entries  []entry  `xml:"entry"`
+		}
+	)
+
+	doc := atom{
+		ID:       f.Self,
+		Title:    f.Title,
+		Subtitle: f.Description,
+		Updated:  f.Updated.UTC().Format(time.RFC3339),
+		Links: []link{
+			{Href: f.Self, Rel: "self", Type: "application/atom+xml"},
+			{Href: f.Link, Rel: "alternate", Type: "text/html"},
+		},
+	}
+
+	for _, it := range f.Items {
+		e := entry{
+			ID:        it.ID,
+			Title:     it.Title,
+			Link:      link{Href: it.Link, Rel: "alternate"},
+			Published: it.Published.UTC().Format(time.RFC3339),
+			Updated:   it.Updated.UTC().Format(time.RFC3339),
+		}
+		if it.Author != "" {
+			e.Author = &person{Name: it.Author}
+		}
+		for _, c := range it.Categories {
+			e.Categories = append(e.Categories, category{Term: c})
+		}
+		if it.Summary != "" {
+			e.Summary = &text{Value: it.Summary}
+		}
+		if it.Content != "" {
+			e.Content = &text{Type: "html", Value: it.Content}
+		}
+		doc.Entries = append(doc.Entries, e)
+	}
+
+	return writeXML(w, doc)
+}
+
+// WriteJSON - encodes f as JSON Feed 1.1.
+func WriteJSON(w io.Writer, f Feed) error {
+	type (
+		author struct {
+			Name string `json:"name"`
+		}
+		item struct {
+			ID            string   `json:"id"`
+			URL           string   `json:"url"`
+			Title         string   `json:"title"`
+			ContentHTML   string   `json:"content_html,omitempty"`
+			Summary       string   `json:"summary,omitempty"`
+			DatePublished string   `json:"date_published"`
+			DateModified  string   `json:"date_modified"`
+			Authors       []author `json:"authors,omitempty"`
+			Tags          []string `json:"tags,omitempty"`
+		}
+		jsonFeed struct {
+			Version     string `json:"version"`
+			Title       string `json:"title"`
+			HomePageURL string `json:"home_page_url"`
+			FeedURL     string `json:"feed_url"`
+			Description string `json:"description,omitempty"`
+			Items       []item `json:"items"`
+		}
+	)
+
+	doc := jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       f.Title,
+		HomePageURL: f.Link,
+		FeedURL:     f.Self,
+		Description: f.Description,
+		Items:       make([]item, 0, len(f.Items)),
+	}
+
+	for _, it := range f.Items {
+		i := item{
+			ID:            it.ID,
+			URL:           it.Link,
+			Title:         it.Title,
+			ContentHTML:   it.Content,
+			Summary:       it.Summary,
+			DatePublished: it.Published.UTC().Format(time.RFC3339),
+			DateModified:  it.Updated.UTC().Format(time.RFC3339),
+			Tags:          it.Categories,
+		}
+		if it.Author != "" {
+			i.Authors = []author{{Name: it.Author}}
+		}
+		doc.Items = append(doc.Items, i)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+func writeXML(w io.Writer, v any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	return enc.Close()
+}
@@ -0,0 +1,114 @@
+package feed
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sampleFeed() Feed {
+	published := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	items := []Item{
+		{
+			ID:         "https://news.example/posts/1",
+			Title:      "First & foremost",
+			Link:       "https://news.example/posts/1",
+			Content:    "<p>Body</p>",
+			Author:     "Jane",
+			Categories: []string{"go"},
+			Published:  published,
+			Updated:    published.Add(time.Hour),
+		},
+		{
+			ID:        "https://news.example/posts/2",
+			Title:     "Second",
+			Link:      "https://news.example/posts/2",
+			Content:   "Plain",
+			Published: published,
+			Updated:   published,
+		},
+	}
+
+	return Feed{
+		Title:   "News",
+		Link:    "https://news.example/posts",
+		Self:    "https://news.example/feed.xml",
+		Updated: Updated(items),
+		Items:   items,
+	}
+}
+
+func TestUpdated(t *testing.T) {
+	f := sampleFeed()
+	assert.Equal(t, time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC), f.Updated)
+	assert.True(t, Updated(nil).IsZero())
+}
+
+func TestWriteRSS(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteRSS(&buf, sampleFeed()))
+
+	var doc struct {
+		Version string `xml:"version,attr"`
+		Channel struct {
+			Title string `xml:"title"`
+			Items []struct {
+				Title       string `xml:"title"`
+				GUID        string `xml:"guid"`
+				Description string `xml:"description"`
+				PubDate     string `xml:"pubDate"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &doc))
+
+	assert.Equal(t, "2.0", doc.Version)
+	assert.Equal(t, "News", doc.Channel.Title)
+	require.Len(t, doc.Channel.Items, 2)
+	assert.Equal(t, "First & foremost", doc.Channel.Items[0].Title)
+	assert.Equal(t, "<p>Body</p>", doc.Channel.Items[0].Description)
+	assert.Equal(t, "Mon, 01 Jan 2024 10:00:00 +0000", doc.Channel.Items[0].PubDate)
+}
+
+func TestWriteAtom(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteAtom(&buf, sampleFeed()))
+
+	var doc struct {
+		XMLName xml.Name
+		Updated string `xml:"updated"`
+		Entries []struct {
+			ID      string `xml:"id"`
+			Updated string `xml:"updated"`
+			Author  struct {
+				Name string `xml:"name"`
+			} `xml:"author"`
+		} `xml:"entry"`
+	}
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &doc))
+
+	assert.Equal(t, "http://www.w3.org/2005/Atom", doc.XMLName.Space)
+	assert.Equal(t, "2024-01-01T11:00:00Z", doc.Updated)
+	require.Len(t, doc.Entries, 2)
+	assert.Equal(t, "Jane", doc.Entries[0].Author.Name)
+	assert.Equal(t, "2024-01-01T11:00:00Z", doc.Entries[0].Updated)
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteJSON(&buf, sampleFeed()))
+
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+
+	assert.Equal(t, "https://jsonfeed.org/version/1.1", doc["version"])
+	assert.Equal(t, "https://news.example/feed.xml", doc["feed_url"])
+	items := doc["items"].([]any)
+	require.Len(t, items, 2)
+	assert.Equal(t, []any{"go"}, items[0].(map[string]any)["tags"])
+}
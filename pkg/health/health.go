@@ -0,0 +1,118 @@
+// Package health serves liveness and readiness probes.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// checkTimeout bounds how long readiness waits for all checks.
+const checkTimeout = 3 * time.Second
+
+// Status values reported by the probes.
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+type (
+	// Check - reports whether a dependency is usable.
+	Check func(ctx context.Context) error
+
+	// Health - tracks the checks that decide readiness. Register them with
+	// Add before serving.
+	Health struct {
+		names    []string
+		checks   []Check
+		draining atomic.Bool
+	}
+
+	// Report - the JSON body of a probe response.
+	Report struct {
+		Status string            `json:"status"`
+		Checks map[string]Result `json:"checks,omitempty"`
+	}
+
+	// Result - the outcome of one check.
+	Result struct {
+		Status string `json:"status"`
+		Error  string `json:"error,omitempty"`
+	}
+)
+
+// New - creates Health without any checks.
+func New() *Health {
+	return &Health{}
+}
+
+// Add - makes readiness depend on c, reported under name.
+func (h *Health) Add(name string, c Check) {
+	h.names = append(h.names, name)
+	h.checks = append(h.checks, c)
+}
+
+// Drain - fails readiness from now on, so that load balancers stop
+// sending traffic before the server shuts down.
+func (h *Health) Drain() {
+	h.draining.Store(true)
+}
+
+// Live - answers 200 for as long as the process can serve requests.
+func (h *Health) Live(w http.ResponseWriter, r *http.Request) {
+	write(w, http.StatusOK, Report{Status: StatusOK})
+}
+
+// Ready - runs every check at once and answers 200 when all of them
+// pass, or 503 with the failures otherwise or while draining.
+func (h *Health) Ready(w http.ResponseWriter, r *http.Request) {
+	report := h.Run(r.Context())
+	code := http.StatusOK
+	if report.Status != StatusOK {
+		code = http.StatusServiceUnavailable
+	}
+	write(w, code, report)
+}
+
+// Run - runs every check and reports the outcome.
+func (h *Health) Run(ctx context.Context) Report {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	results := make([]Result, len(h.checks))
+	var wg sync.WaitGroup
+	for i, c := range h.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = Result{Status: StatusOK}
+			if err := c(ctx); err != nil {
+				results[i] = Result{Status: StatusFail, Error: err.Error()}
+			}
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]Result, len(h.checks)+1)}
+	if h.draining.Load() {
+		report.Status = StatusFail
+		report.Checks["shutdown"] = Result{Status: StatusFail, Error: "shutting down"}
+	}
+	for i, name := range h.names {
+		report.Checks[name] = results[i]
+		if results[i].Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+	return report
+}
+
+func write(w http.ResponseWriter, code int, report Report) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,58 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func serve(t *testing.T, h http.HandlerFunc) (int, Report) {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	h(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	var report Report
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&report))
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	return rr.Code, report
+}
+
+func TestReady(t *testing.T) {
+	var mongoErr error
+	h := New()
+	h.Add("mongo", func(ctx context.Context) error { return mongoErr })
+	h.Add("outbox", func(ctx context.Context) error { return nil })
+
+	code, report := serve(t, h.Ready)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, StatusOK, report.Status)
+	assert.Equal(t, Result{Status: StatusOK}, report.Checks["mongo"])
+
+	mongoErr = errors.New("server selection timeout")
+	code, report = serve(t, h.Ready)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, StatusFail, report.Status)
+	assert.Equal(t, Result{Status: StatusFail, Error: "server selection timeout"}, report.Checks["mongo"])
+	assert.Equal(t, Result{Status: StatusOK}, report.Checks["outbox"])
+}
+
+func TestDrain(t *testing.T) {
+	h := New()
+	h.Add("mongo", func(ctx context.Context) error { return nil })
+	h.Drain()
+
+	code, report := serve(t, h.Ready)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, StatusFail, report.Checks["shutdown"].Status)
+
+	// still alive while draining
+	code, report = serve(t, h.Live)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, StatusOK, report.Status)
+}
@@ -54,6 +54,14 @@ func ShutdownTimeout(timeout time.Duration) Option {
 	}
 }
 
+// OnShutdown - registers fn to be called when Shutdown starts, e.g. to
+// end long-lived streams that would otherwise keep it waiting.
+func OnShutdown(fn func()) Option {
+	return func(s *Server) {
+		s.server.RegisterOnShutdown(fn)
+	}
+}
+
 // New - creates instance of new http server.
 func New(handler http.Handler, opts ...Option) *Server {
 	httpServer := &http.Server{
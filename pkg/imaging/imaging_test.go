@@ -0,0 +1,124 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// exifSegment builds an APP1 segment with a little-endian IFD0 holding
+// only the orientation tag, followed by a marker string standing in for
+// GPS data.
+func exifSegment(orientation uint16) []byte {
+	tiff := []byte("II*\x00")
+	tiff = binary.LittleEndian.AppendUint32(tiff, 8)
+	tiff = binary.LittleEndian.AppendUint16(tiff, 1)
+	tiff = binary.LittleEndian.AppendUint16(tiff, tagOrientation)
+	tiff = binary.LittleEndian.AppendUint16(tiff, 3) // SHORT
+	tiff = binary.LittleEndian.AppendUint32(tiff, 1)
+	tiff = binary.LittleEndian.AppendUint16(tiff, orientation)
+	tiff = append(tiff, 0, 0, 0, 0, 0, 0)
+	tiff = append(tiff, "GPS 51.5N 0.1W"...)
+
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	seg := []byte{0xFF, markerAPP1}
+	seg = binary.BigEndian.AppendUint16(seg, uint16(len(payload)+2))
+	return append(seg, payload...)
+}
+
+func jpegWithExif(t *testing.T, orientation uint16) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, 8, 4)), nil))
+
+	data := buf.Bytes()
+	out := append([]byte{}, data[:2]...)
+	out = append(out, exifSegment(orientation)...)
+	return append(out, data[2:]...)
+}
+
+func TestStripJPEG(t *testing.T) {
+	data := jpegWithExif(t, 6)
+	assert.Equal(t, 6, Orientation(data))
+
+	stripped, err := StripMetadata(data)
+	require.NoError(t, err)
+
+	assert.NotContains(t, string(stripped), "GPS")
+	assert.Equal(t, 1, Orientation(stripped))
+
+	img, err := jpeg.Decode(bytes.NewReader(stripped))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 8, 4), img.Bounds())
+}
+
+func TestStripPNG(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewGray(image.Rect(0, 0, 2, 2))))
+	data := buf.Bytes()
+
+	// insert a tEXt chunk right after IHDR (8 byte magic + 25 byte chunk)
+	text := []byte("tEXtLocation\x00home")
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(text)-4))
+	chunk = append(chunk, text...)
+	chunk = binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(text))
+	withText := append(append(append([]byte{}, data[:33]...), chunk...), data[33:]...)
+
+	_, err := png.Decode(bytes.NewReader(withText))
+	require.NoError(t, err)
+
+	stripped, err := StripMetadata(withText)
+	require.NoError(t, err)
+
+	assert.Equal(t, data, stripped)
+}
+
+func TestStripMalformed(t *testing.T) {
+	_, err := StripMetadata([]byte{0xFF, 0xD8, 0xFF, 0xE1, 0x40})
+	assert.ErrorIs(t, err, ErrMalformed)
+
+	gif := []byte("GIF89a...")
+	out, err := StripMetadata(gif)
+	require.NoError(t, err)
+	assert.Equal(t, gif, out)
+}
+
+func TestResize(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 400, 300))
+
+	assert.Equal(t, image.Rect(0, 0, 100, 75), Resize(img, 100).Bounds())
+	assert.Same(t, img, Resize(img, 400))
+	assert.Same(t, img, Resize(img, 800))
+}
+
+func TestOrient(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 3, 2))
+	red := color.NRGBA{255, 0, 0, 255}
+	img.Set(0, 0, red)
+
+	cases := map[int]image.Point{
+		1: {0, 0},
+		2: {2, 0},
+		3: {2, 1},
+		4: {0, 1},
+		5: {0, 0},
+		6: {1, 0},
+		7: {1, 2},
+		8: {0, 2},
+	}
+
+	for orientation, want := range cases {
+		out := Orient(img, orientation)
+		if orientation >= 5 {
+			assert.Equal(t, image.Rect(0, 0, 2, 3), out.Bounds(), "orientation %d", orientation)
+		}
+		assert.Equal(t, red, color.NRGBAModel.Convert(out.At(want.X, want.Y)), "orientation %d", orientation)
+	}
+}
@@ -0,0 +1,170 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+var ErrMalformed = errors.New("imaging: malformed image")
+
+const (
+	markerSOS   = 0xDA
+	markerAPP1  = 0xE1 // Exif and XMP
+	markerAPP13 = 0xED // Photoshop / IPTC
+	markerCOM   = 0xFE
+
+	tagOrientation = 0x0112
+)
+
+var (
+	jpegSOI   = []byte{0xFF, 0xD8}
+	pngMagic  = []byte("\x89PNG\r\n\x1a\n")
+	exifMagic = []byte("Exif\x00\x00")
+
+	// pngDropped are the PNG chunks that carry metadata rather than pixels.
+	pngDropped = map[string]bool{"eXIf": true, "tEXt": true, "zTXt": true, "iTXt": true, "tIME": true}
+)
+
+// StripMetadata removes Exif (including GPS location), XMP, IPTC and
+// comments from a JPEG or PNG without re-encoding it. Colour profiles
+// are kept. Other formats are returned unchanged.
+func StripMetadata(data []byte) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(data, jpegSOI):
+		return stripJPEG(data)
+	case bytes.HasPrefix(data, pngMagic):
+		return stripPNG(data)
+	default:
+		return data, nil
+	}
+}
+
+// Orientation returns the Exif orientation of a JPEG, or 1 (upright)
+// when there is none.
+func Orientation(data []byte) int {
+	if !bytes.HasPrefix(data, jpegSOI) {
+		return 1
+	}
+
+	orientation := 1
+	_, _ = jpegSegments(data, func(marker byte, segment []byte) bool {
+		payload := segment[4:]
+		if marker != markerAPP1 || !bytes.HasPrefix(payload, exifMagic) {
+			return true
+		}
+		if o, ok := exifOrientation(payload[len(exifMagic):]); ok {
+			orientation = o
+		}
+		return false
+	})
+
+	return orientation
+}
+
+func stripJPEG(data []byte) ([]byte, error) {
+	out := make([]byte, 0, len(data))
+	out = append(out, jpegSOI...)
+
+	sos, err := jpegSegments(data, func(marker byte, segment []byte) bool {
+		if marker != markerAPP1 && marker != markerAPP13 && marker != markerCOM {
+			out = append(out, segment...)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// everything from the start of scan onwards is image data
+	return append(out, data[sos:]...), nil
+}
+
+// jpegSegments calls fn with every marker segment before the start of
+// scan until fn returns false, and returns the offset of the start of
+// scan. Only length-prefixed segments occur there, so the walk is simple.
+func jpegSegments(data []byte, fn func(marker byte, segment []byte) bool) (int, error) {
+	i := len(jpegSOI)
+	for {
+		if i+4 > len(data) || data[i] != 0xFF {
+			return 0, ErrMalformed
+		}
+		marker := data[i+1]
+		if marker == 0xFF {
+			// fill byte
+			i++
+			continue
+		}
+		if marker == markerSOS {
+			return i, nil
+		}
+
+		n := int(binary.BigEndian.Uint16(data[i+2:]))
+		if n < 2 || i+2+n > len(data) {
+			return 0, ErrMalformed
+		}
+		if !fn(marker, data[i:i+2+n]) {
+			return i, nil
+		}
+		i += 2 + n
+	}
+}
+
+// exifOrientation reads the orientation tag from IFD0 of a TIFF block.
+func exifOrientation(tiff []byte) (int, bool) {
+	if len(tiff) < 8 {
+		return 0, false
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:4]) {
+	case "II*\x00":
+		order = binary.LittleEndian
+	case "MM\x00*":
+		order = binary.BigEndian
+	default:
+		return 0, false
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 0, false
+	}
+
+	count := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < count; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 0, false
+		}
+		if order.Uint16(tiff[entry:]) == tagOrientation {
+			o := int(order.Uint16(tiff[entry+8:]))
+			return o, o >= 1 && o <= 8
+		}
+	}
+
+	return 0, false
+}
+
+func stripPNG(data []byte) ([]byte, error) {
+	out := make([]byte, 0, len(data))
+	out = append(out, pngMagic...)
+
+	for i := len(pngMagic); i < len(data); {
+		if i+12 > len(data) {
+			return nil, ErrMalformed
+		}
+		n := int(binary.BigEndian.Uint32(data[i:]))
+		end := i + 12 + n
+		if n < 0 || end > len(data) {
+			return nil, ErrMalformed
+		}
+
+		if !pngDropped[string(data[i+4:i+8])] {
+			out = append(out, data[i:end]...)
+		}
+		i = end
+	}
+
+	return out, nil
+}
@@ -0,0 +1,46 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	posts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "posts_total",
+		Help:      "Post writes by action: created, updated or deleted.",
+	}, []string{"action"})
+
+	comments = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "comments_total",
+		Help:      "New comments by the status they were given.",
+	}, []string{"status"})
+
+	webhookDeliveries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhook_deliveries_total",
+		Help:      "Webhook delivery attempts by the status they left the delivery in.",
+	}, []string{"status"})
+)
+
+// Actions counted by CountPost.
+const (
+	PostCreated = "created"
+	PostUpdated = "updated"
+	PostDeleted = "deleted"
+)
+
+// CountPost - counts a successful post write.
+func CountPost(action string) {
+	posts.WithLabelValues(action).Inc()
+}
+
+// CountComment - counts a new comment by its status.
+func CountComment(status string) {
+	comments.WithLabelValues(status).Inc()
+}
+
+// CountDelivery - counts a webhook delivery attempt by the resulting
+// delivery status.
+func CountDelivery(status string) {
+	webhookDeliveries.WithLabelValues(status).Inc()
+}
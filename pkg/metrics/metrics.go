@@ -0,0 +1,33 @@
+// Package metrics collects Prometheus metrics for the service: HTTP
+// traffic per route pattern, MongoDB operation latency per repository
+// method and connection pool state, and business counters. Collectors
+// are package-level, as they describe the whole process, and served
+// from their own registry by Handler.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "news"
+
+var registry = prometheus.NewRegistry()
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests, httpDuration, httpInFlight,
+		mongoDuration, poolOpen, poolInUse, poolCheckoutFailures, poolCleared,
+		posts, comments, webhookDeliveries,
+	)
+}
+
+// Handler - serves every metric in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{Registry: registry})
+}
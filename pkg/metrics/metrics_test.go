@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/event"
+)
+
+func TestHTTPLabelsByPattern(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /things/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("id") == "missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("ok"))
+	})
+	h := HTTP(mux)
+
+	for _, path := range []string{"/things/1", "/things/2", "/things/missing", "/nowhere"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(httpRequests.WithLabelValues("GET /things/{id}", "200")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(httpRequests.WithLabelValues("GET /things/{id}", "404")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(httpRequests.WithLabelValues(unmatched, "404")))
+	assert.Equal(t, 0.0, testutil.ToFloat64(httpInFlight))
+}
+
+func TestHTTPKeepsResponseController(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /stream", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("data: 1\n\n"))
+		assert.NoError(t, http.NewResponseController(w).Flush())
+	})
+
+	rr := httptest.NewRecorder()
+	HTTP(mux).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/stream", nil))
+
+	assert.True(t, rr.Flushed)
+}
+
+func TestMongoOp(t *testing.T) {
+	done := MongoOp("post", "GetByID")
+	done()
+
+	assert.Equal(t, 1, testutil.CollectAndCount(mongoDuration, "news_mongo_operation_duration_seconds"))
+}
+
+func TestPoolMonitor(t *testing.T) {
+	m := PoolMonitor()
+	const addr = "db:27017"
+
+	for _, typ := range []string{event.ConnectionCreated, event.ConnectionCreated, event.ConnectionCheckedOut, event.ConnectionClosed} {
+		m.Event(&event.PoolEvent{Type: typ, Address: addr})
+	}
+	m.Event(&event.PoolEvent{Type: event.ConnectionCheckOutFailed, Address: addr, Reason: event.ReasonTimedOut})
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(poolOpen.WithLabelValues(addr)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(poolInUse.WithLabelValues(addr)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(poolCheckoutFailures.WithLabelValues(addr, event.ReasonTimedOut)))
+}
+
+func TestHandler(t *testing.T) {
+	CountPost(PostCreated)
+
+	rr := httptest.NewRecorder()
+	Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	body, _ := io.ReadAll(rr.Body)
+	assert.True(t, strings.HasPrefix(rr.Header().Get("Content-Type"), "text/plain"))
+	assert.Contains(t, string(body), `news_posts_total{action="created"}`)
+	assert.Contains(t, string(body), "go_goroutines")
+}
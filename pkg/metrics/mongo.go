@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.mongodb.org/mongo-driver/v2/event"
+)
+
+var (
+	mongoDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "mongo",
+		Name:      "operation_duration_seconds",
+		Help:      "Time spent in repository methods by repository and method.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"repo", "method"})
+
+	poolOpen = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "mongo",
+		Name:      "pool_connections",
+		Help:      "Open connections in the driver's pool by server address.",
+	}, []string{"address"})
+
+	poolInUse = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "mongo",
+		Name:      "pool_connections_in_use",
+		Help:      "Connections checked out of the driver's pool by server address.",
+	}, []string{"address"})
+
+	poolCheckoutFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "mongo",
+		Name:      "pool_checkout_failures_total",
+		Help:      "Failed connection checkouts by server address and reason.",
+	}, []string{"address", "reason"})
+
+	poolCleared = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "mongo",
+		Name:      "pool_cleared_total",
+		Help:      "Times the driver's pool was cleared, e.g. after network errors, by server address.",
+	}, []string{"address"})
+)
+
+// MongoOp - starts timing a repository method. Call the returned
+// function when the method returns:
+//
+//	defer metrics.MongoOp("post", "GetByID")()
+func MongoOp(repo, method string) func() {
+	start := time.Now()
+	return func() {
+		mongoDuration.WithLabelValues(repo, method).Observe(time.Since(start).Seconds())
+	}
+}
+
+// PoolMonitor - tracks the driver's connection pools.
+func PoolMonitor() *event.PoolMonitor {
+	return &event.PoolMonitor{
+		Event: func(e *event.PoolEvent) {
+			switch e.Type {
+			case event.ConnectionCreated:
+				poolOpen.WithLabelValues(e.Address).Inc()
+			case event.ConnectionClosed:
+				poolOpen.WithLabelValues(e.Address).Dec()
+			case event.ConnectionCheckedOut:
+				poolInUse.WithLabelValues(e.Address).Inc()
+			case event.ConnectionCheckedIn:
+				poolInUse.WithLabelValues(e.Address).Dec()
+			case event.ConnectionCheckOutFailed:
+				poolCheckoutFailures.WithLabelValues(e.Address, e.Reason).Inc()
+			case event.ConnectionPoolCleared:
+				poolCleared.WithLabelValues(e.Address).Inc()
+			}
+		},
+	}
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// AccessLog - logs every request once it is served: method, path, the
+// mux pattern that matched it, status, response size and duration.
+// Server errors are logged at error level. Like metrics.HTTP it must
+// sit between the handlers that replace the request and the mux, as
+// the mux records the pattern on the request it was given.
+func AccessLog(logger *slog.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rw := newResponseWriter(w)
+			next.ServeHTTP(rw, r)
+
+			level := slog.LevelInfo
+			if rw.code >= http.StatusInternalServerError {
+				level = slog.LevelError
+			}
+			logger.LogAttrs(r.Context(), level, "http request",
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.String("route", r.Pattern),
+				slog.Int("status", rw.code),
+				slog.Int("bytes", rw.bytes),
+				slog.Duration("duration", time.Since(start)),
+			)
+		})
+	}
+}
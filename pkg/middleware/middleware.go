@@ -0,0 +1,52 @@
+// Package middleware provides the HTTP handler wrappers every request
+// passes through: request IDs, access logs and panic recovery.
+package middleware
+
+import "net/http"
+
+// Middleware - wraps a handler with behaviour of its own.
+type Middleware func(http.Handler) http.Handler
+
+// Chain - wraps h in mws so that the first one sees a request first:
+//
+//	Chain(mux, RequestID, AccessLog(logger))
+//
+// is RequestID(AccessLog(logger)(mux)).
+func Chain(h http.Handler, mws ...Middleware) http.Handler {
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+	return h
+}
+
+// responseWriter remembers the status code and size of a response.
+type responseWriter struct {
+	http.ResponseWriter
+	code        int
+	bytes       int
+	wroteHeader bool
+}
+
+func newResponseWriter(w http.ResponseWriter) *responseWriter {
+	return &responseWriter{ResponseWriter: w, code: http.StatusOK}
+}
+
+func (w *responseWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.code = code
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += n
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,153 @@
+package middleware
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainOrder(t *testing.T) {
+	var order []string
+	mark := func(name string) Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+	h := Chain(http.NotFoundHandler(), mark("a"), mark("b"), mark("c"))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, []string{"a", "b", "c"}, order)
+}
+
+func TestRequestID(t *testing.T) {
+	var seen string
+	h := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = GetRequestID(r.Context())
+	}))
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Len(t, seen, 32)
+	assert.Equal(t, seen, rr.Header().Get(RequestIDHeader))
+
+	tests := []struct {
+		name   string
+		header string
+		keep   bool
+	}{
+		{"from proxy", "edge-1234", true},
+		{"with spaces", "a b", false},
+		{"with newline", "a\nrequest_id=forged", false},
+		{"too long", strings.Repeat("x", maxRequestIDLen+1), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(RequestIDHeader, tt.header)
+			h.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, tt.keep, seen == tt.header)
+			assert.NotEmpty(t, seen)
+		})
+	}
+}
+
+func TestAccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(LogHandler(slog.NewTextHandler(&buf, nil)))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /things/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("hello"))
+	})
+	h := Chain(mux, RequestID, AccessLog(logger))
+
+	req := httptest.NewRequest(http.MethodGet, "/things/1", nil)
+	req.Header.Set(RequestIDHeader, "req-1")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	line := buf.String()
+	assert.Contains(t, line, "level=INFO")
+	assert.Contains(t, line, "method=GET")
+	assert.Contains(t, line, "path=/things/1")
+	assert.Contains(t, line, `route="GET /things/{id}"`)
+	assert.Contains(t, line, "status=201")
+	assert.Contains(t, line, "bytes=5")
+	assert.Contains(t, line, "duration=")
+	assert.Contains(t, line, "request_id=req-1")
+}
+
+func TestRecover(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+
+	h := Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		panic("boom")
+	}), AccessLog(logger), Recover(logger))
+
+	rr := httptest.NewRecorder()
+	require.NotPanics(t, func() {
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.Equal(t, "text/html; charset=utf-8", rr.Header().Get("Content-Type"))
+	assert.Contains(t, rr.Body.String(), "Something went wrong")
+	assert.Contains(t, buf.String(), "panic=boom")
+	assert.Contains(t, buf.String(), "goroutine")
+	assert.Contains(t, buf.String(), "status=500")
+}
+
+func TestRecoverAfterWrite(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+
+	h := Recover(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("partial"))
+		panic("boom")
+	}))
+
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+}
+
+func TestRecoverKeepsResponseController(t *testing.T) {
+	rr := httptest.NewRecorder()
+	h := Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("data: 1\n\n"))
+		assert.NoError(t, http.NewResponseController(w).Flush())
+	}), AccessLog(slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))), Recover(slog.Default()))
+
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/stream", nil))
+
+	assert.True(t, rr.Flushed)
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	rr := httptest.NewRecorder()
+	SecurityHeaders(false)(ok).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/posts", nil))
+
+	assert.Equal(t, ContentSecurityPolicy, rr.Header().Get("Content-Security-Policy"))
+	assert.Contains(t, rr.Header().Get("Content-Security-Policy"), "frame-ancestors 'none'")
+	assert.Equal(t, "nosniff", rr.Header().Get("X-Content-Type-Options"))
+	assert.Equal(t, "strict-origin-when-cross-origin", rr.Header().Get("Referrer-Policy"))
+	assert.Empty(t, rr.Header().Get("Strict-Transport-Security"))
+
+	rr = httptest.NewRecorder()
+	SecurityHeaders(true)(ok).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/posts", nil))
+	assert.Equal(t, "max-age=31536000; includeSubDomains", rr.Header().Get("Strict-Transport-Security"))
+}
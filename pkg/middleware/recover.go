@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+)
+
+// errorPage is served when a handler panics.
+const errorPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Server error</title>
+</head>
+<body>
+<h1>Something went wrong</h1>
+<p>The page could not be shown. Please try again later.</p>
+</body>
+</html>
+`
+
+// Recover - turns a panic in a handler into a 500 error page and logs
+// it with its stack. When the handler had already started the response
+// the page cannot be sent, so the response is cut short instead.
+func Recover(logger *slog.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rw := newResponseWriter(w)
+			defer func() {
+				v := recover()
+				if v == nil {
+					return
+				}
+				// the server aborts the response quietly for this one
+				if err, ok := v.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+					panic(v)
+				}
+
+				logger.ErrorContext(r.Context(), "handler panic",
+					"panic", fmt.Sprint(v),
+					"stack", string(debug.Stack()),
+				)
+				if rw.wroteHeader {
+					panic(http.ErrAbortHandler)
+				}
+
+				h := w.Header()
+				h.Set("Content-Type", "text/html; charset=utf-8")
+				h.Set("Cache-Control", "no-store")
+				h.Del("Content-Length")
+				rw.WriteHeader(http.StatusInternalServerError)
+				rw.Write([]byte(errorPage))
+			}()
+
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
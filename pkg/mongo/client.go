@@ -3,12 +3,21 @@ package mongo
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"net/url"
 	"time"
 
 	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
 
+const (
+	_retryMin = 500 * time.Millisecond
+	_retryMax = 10 * time.Second
+)
+
 type Database interface {
 	// Instance returns the primary Mongo database instance.
 	Instance() *mongo.Database
@@ -23,30 +32,58 @@ type Mongo struct {
 	db     *mongo.Database
 }
 
-// New creates a new Mongo instance using provided config and slog logger.
-func New(ctx context.Context, user, password, host, port, name string) (*Mongo, error) {
-	uri := fmt.Sprintf(
-		"mongodb://%s:%s@%s:%s/?authSource=admin",
-		user, password, host, port,
-	)
-
-	clientOpts := options.Client().ApplyURI(uri)
+// URI - builds a connection string for a single server, escaping the
+// credentials. Without a user no credentials are sent.
+func URI(user, password, host, port string) string {
+	u := url.URL{
+		Scheme:   "mongodb",
+		Host:     net.JoinHostPort(host, port),
+		Path:     "/",
+		RawQuery: "authSource=admin",
+	}
+	if user != "" {
+		u.User = url.UserPassword(user, password)
+	}
+	return u.String()
+}
 
-	ctxConn, cancel := context.WithTimeout(ctx, 10*time.Second)
-	defer cancel()
+// New connects to the server or cluster at uri and opens database name.
+// Until the server answers a ping it keeps trying, backing off with
+// jitter, so the app can start before Mongo does; it gives up when ctx
+// is done.
+func New(ctx context.Context, uri, name string, opts ...Option) (*Mongo, error) {
+	s := settings{client: options.Client().ApplyURI(uri), logger: slog.Default()}
+	for _, opt := range opts {
+		opt(&s)
+	}
 
-	client, err := mongo.Connect(clientOpts)
+	client, err := mongo.Connect(s.client)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := client.Ping(ctxConn, nil); err != nil {
-		return nil, err
-	}
+	delay := _retryMin
+	for attempt := 1; ; attempt++ {
+		err = client.Ping(ctx, nil)
+		if err == nil {
+			break
+		}
+		if ctx.Err() != nil {
+			client.Disconnect(context.Background())
+			return nil, fmt.Errorf("mongo unreachable after %d attempts: %w", attempt, err)
+		}
 
-	database := client.Database(name)
+		// somewhere between half and all of the delay
+		wait := delay/2 + rand.N(delay/2+1)
+		s.logger.Warn("mongo unreachable, retrying", "attempt", attempt, "retry_in", wait, "err", err)
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+		}
+		delay = min(2*delay, _retryMax)
+	}
 
-	return &Mongo{client: client, db: database}, nil
+	return &Mongo{client: client, db: client.Database(name)}, nil
 }
 
 // Instance returns the Mongo database.
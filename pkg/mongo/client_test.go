@@ -0,0 +1,67 @@
+package mongo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/mongo/readpref"
+	"go.mongodb.org/mongo-driver/v2/mongo/writeconcern"
+)
+
+func TestURI(t *testing.T) {
+	assert.Equal(t, "mongodb://admin:p%40ss%3Aw%2Frd@db:27017/?authSource=admin", URI("admin", "p@ss:w/rd", "db", "27017"))
+	assert.Equal(t, "mongodb://localhost:27017/?authSource=admin", URI("", "", "localhost", "27017"))
+}
+
+func TestParseReadPreference(t *testing.T) {
+	rp, err := ParseReadPreference("secondaryPreferred")
+	require.NoError(t, err)
+	assert.Equal(t, readpref.SecondaryPreferredMode, rp.Mode())
+
+	_, err = ParseReadPreference("anywhere")
+	assert.Error(t, err)
+}
+
+func TestParseWriteConcern(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    any
+		wantErr bool
+	}{
+		{"majority", "majority", false},
+		{"2", 2, false},
+		{"dc-east", "dc-east", false},
+		{"-1", nil, true},
+		{"", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			wc, err := ParseWriteConcern(tt.in)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, &writeconcern.WriteConcern{W: tt.want}, wc)
+		})
+	}
+}
+
+func TestTLSConfigMissingCA(t *testing.T) {
+	_, err := TLSConfig(t.TempDir() + "/ca.pem")
+	assert.Error(t, err)
+}
+
+func TestNewGivesUpWithContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1500*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := New(ctx, "mongodb://127.0.0.1:1/?connectTimeoutMS=100", "test", Timeouts(0, 200*time.Millisecond))
+
+	assert.ErrorContains(t, err, "mongo unreachable after")
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
@@ -0,0 +1,151 @@
+package mongo
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/event"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"go.mongodb.org/mongo-driver/v2/mongo/readpref"
+	"go.mongodb.org/mongo-driver/v2/mongo/writeconcern"
+)
+
+// settings collects what the options configure.
+type settings struct {
+	client *options.ClientOptions
+	logger *slog.Logger
+}
+
+// Option - configures the client beyond the connection string. Options
+// override what the connection string says.
+type Option func(*settings)
+
+// Logger - reports connection retries to l instead of slog.Default.
+func Logger(l *slog.Logger) Option {
+	return func(s *settings) {
+		s.logger = l
+	}
+}
+
+// PoolMonitor - reports connection pool events to m.
+func PoolMonitor(m *event.PoolMonitor) Option {
+	return func(s *settings) {
+		s.client.SetPoolMonitor(m)
+	}
+}
+
+// CommandMonitor - reports every command sent to the server to m.
+func CommandMonitor(m *event.CommandMonitor) Option {
+	return func(s *settings) {
+		s.client.SetMonitor(m)
+	}
+}
+
+// ReplicaSet - only connects to members of the named replica set.
+func ReplicaSet(name string) Option {
+	return func(s *settings) {
+		s.client.SetReplicaSet(name)
+	}
+}
+
+// TLS - encrypts connections with cfg, see TLSConfig.
+func TLS(cfg *tls.Config) Option {
+	return func(s *settings) {
+		s.client.SetTLSConfig(cfg)
+	}
+}
+
+// PoolSize - keeps at least min and at most max connections per
+// server. Zero leaves a bound at its default.
+func PoolSize(min, max uint64) Option {
+	return func(s *settings) {
+		if min > 0 {
+			s.client.SetMinPoolSize(min)
+		}
+		if max > 0 {
+			s.client.SetMaxPoolSize(max)
+		}
+	}
+}
+
+// Timeouts - bounds opening a connection and finding a suitable server
+// for an operation. Zero leaves a timeout at its default.
+func Timeouts(connect, serverSelection time.Duration) Option {
+	return func(s *settings) {
+		if connect > 0 {
+			s.client.SetConnectTimeout(connect)
+		}
+		if serverSelection > 0 {
+			s.client.SetServerSelectionTimeout(serverSelection)
+		}
+	}
+}
+
+// ReadPreference - sends reads to the members rp selects, see
+// ParseReadPreference.
+func ReadPreference(rp *readpref.ReadPref) Option {
+	return func(s *settings) {
+		s.client.SetReadPreference(rp)
+	}
+}
+
+// WriteConcern - waits for writes to be acknowledged as wc says, see
+// ParseWriteConcern.
+func WriteConcern(wc *writeconcern.WriteConcern) Option {
+	return func(s *settings) {
+		s.client.SetWriteConcern(wc)
+	}
+}
+
+// TLSConfig - returns a TLS configuration trusting the certificates in
+// the PEM file caFile, or the system roots when caFile is empty.
+func TLSConfig(caFile string) (*tls.Config, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if caFile == "" {
+		return cfg, nil
+	}
+
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+	cfg.RootCAs = x509.NewCertPool()
+	if !cfg.RootCAs.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in %s", caFile)
+	}
+	return cfg, nil
+}
+
+// ParseReadPreference - parses a read preference mode such as
+// "primary" or "secondaryPreferred".
+func ParseReadPreference(mode string) (*readpref.ReadPref, error) {
+	m, err := readpref.ModeFromString(mode)
+	if err != nil {
+		return nil, err
+	}
+	return readpref.New(m)
+}
+
+// ParseWriteConcern - parses a write concern: "majority", a number of
+// members, or a tag set name.
+func ParseWriteConcern(w string) (*writeconcern.WriteConcern, error) {
+	switch w {
+	case "":
+		return nil, errors.New("empty write concern")
+	case "majority":
+		return writeconcern.Majority(), nil
+	}
+	if n, err := strconv.Atoi(w); err == nil {
+		if n < 0 {
+			return nil, fmt.Errorf("invalid write concern %q", w)
+		}
+		return &writeconcern.WriteConcern{W: n}, nil
+	}
+	return writeconcern.Custom(w), nil
+}
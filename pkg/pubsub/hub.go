@@ -0,0 +1,100 @@
+// Package pubsub is an in-process publish/subscribe hub. Publishing
+// never blocks: subscribers that fall behind are dropped and have to
+// subscribe again.
+package pubsub
+
+import "sync"
+
+// Hub - fans out published values to every subscriber.
+type Hub[T any] struct {
+	mu     sync.RWMutex
+	subs   map[*Subscription[T]]struct{}
+	buffer int
+	closed bool
+}
+
+// Subscription - receives published values on C until it is closed,
+// dropped for being too slow, or the hub is closed.
+type Subscription[T any] struct {
+	C <-chan T
+
+	c    chan T
+	hub  *Hub[T]
+	once sync.Once
+}
+
+// New - creates a hub whose subscribers can fall behind by up to
+// buffer values before they are dropped.
+func New[T any](buffer int) *Hub[T] {
+	return &Hub[T]{subs: make(map[*Subscription[T]]struct{}), buffer: buffer}
+}
+
+// Subscribe - registers a new subscriber. After Close the subscription
+// is returned already closed.
+func (h *Hub[T]) Subscribe() *Subscription[T] {
+	c := make(chan T, h.buffer)
+	s := &Subscription[T]{C: c, c: c, hub: h}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		s.once.Do(func() { close(c) })
+		return s
+	}
+	h.subs[s] = struct{}{}
+	return s
+}
+
+// Publish - delivers v to every subscriber with room in its buffer and
+// drops the others.
+func (h *Hub[T]) Publish(v T) {
+	var slow []*Subscription[T]
+
+	h.mu.RLock()
+	for s := range h.subs {
+		select {
+		case s.c <- v:
+		default:
+			slow = append(slow, s)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, s := range slow {
+		s.Close()
+	}
+}
+
+// Len - returns the number of subscribers.
+func (h *Hub[T]) Len() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.subs)
+}
+
+// Close - closes every subscription; later ones start closed.
+func (h *Hub[T]) Close() {
+	h.mu.Lock()
+	h.closed = true
+	subs := h.subs
+	h.subs = make(map[*Subscription[T]]struct{})
+	h.mu.Unlock()
+
+	for s := range subs {
+		s.close()
+	}
+}
+
+// Close - unsubscribes. C is closed once buffered values are read.
+func (s *Subscription[T]) Close() {
+	s.hub.mu.Lock()
+	delete(s.hub.subs, s)
+	s.hub.mu.Unlock()
+
+	s.close()
+}
+
+func (s *Subscription[T]) close() {
+	s.once.Do(func() { close(s.c) })
+}
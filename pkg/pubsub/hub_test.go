@@ -0,0 +1,84 @@
+package pubsub
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func drain[T any](s *Subscription[T]) []T {
+	var got []T
+	for v := range s.C {
+		got = append(got, v)
+	}
+	return got
+}
+
+func TestPublish(t *testing.T) {
+	h := New[int](4)
+	a, b := h.Subscribe(), h.Subscribe()
+
+	h.Publish(1)
+	h.Publish(2)
+	h.Close()
+
+	assert.Equal(t, []int{1, 2}, drain(a))
+	assert.Equal(t, []int{1, 2}, drain(b))
+}
+
+func TestSlowSubscriberIsDropped(t *testing.T) {
+	h := New[int](1)
+	slow, fast := h.Subscribe(), h.Subscribe()
+
+	h.Publish(1)
+	<-fast.C
+	h.Publish(2)
+
+	assert.Equal(t, 1, h.Len())
+	assert.Equal(t, []int{1}, drain(slow))
+
+	h.Close()
+	assert.Equal(t, []int{2}, drain(fast))
+}
+
+func TestUnsubscribe(t *testing.T) {
+	h := New[int](1)
+	s := h.Subscribe()
+	s.Close()
+	s.Close()
+
+	h.Publish(1)
+	assert.Empty(t, drain(s))
+	assert.Zero(t, h.Len())
+}
+
+func TestSubscribeAfterClose(t *testing.T) {
+	h := New[int](1)
+	h.Close()
+
+	s := h.Subscribe()
+	h.Publish(1)
+	assert.Empty(t, drain(s))
+}
+
+func TestConcurrentUse(t *testing.T) {
+	h := New[int](8)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			s := h.Subscribe()
+			drain(s)
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				h.Publish(j)
+			}
+		}()
+	}
+	h.Close()
+	wg.Wait()
+}
@@ -0,0 +1,68 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepEvery is how often Memory forgets buckets that have refilled.
+const sweepEvery = time.Minute
+
+type (
+	// Memory - a Store for a single instance.
+	Memory struct {
+		mu      sync.Mutex
+		buckets map[string]*bucket
+		swept   time.Time
+		now     func() time.Time
+	}
+
+	bucket struct {
+		tokens float64
+		at     time.Time
+		full   time.Duration
+	}
+)
+
+// NewMemory - creates an empty in-memory store.
+func NewMemory() *Memory {
+	return &Memory{buckets: make(map[string]*bucket), now: time.Now}
+}
+
+func (m *Memory) Take(ctx context.Context, key string, l Limit) (bool, time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	m.sweep(now)
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.Burst), at: now}
+		m.buckets[key] = b
+	}
+	b.tokens = l.refill(b.tokens, now.Sub(b.at))
+	b.at = now
+	b.full = l.full()
+
+	if b.tokens < 1 {
+		return false, l.wait(b.tokens), nil
+	}
+	b.tokens--
+	return true, 0, nil
+}
+
+// sweep drops buckets that would be full by now.
+func (m *Memory) sweep(now time.Time) {
+	if now.Sub(m.swept) < sweepEvery {
+		return
+	}
+	m.swept = now
+
+	for key, b := range m.buckets {
+		if now.Sub(b.at) >= b.full {
+			delete(m.buckets, key)
+		}
+	}
+}
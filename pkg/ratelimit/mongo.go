@@ -0,0 +1,73 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// Mongo - a Store shared by every instance. Each bucket is a document
+// updated in a single round trip, timed by the server's clock so that
+// instances need not agree on the time. Buckets are deleted once they
+// would be full again.
+type Mongo struct {
+	coll *mongo.Collection
+}
+
+// NewMongo - creates a store using the named collection of db.
+func NewMongo(db *mongo.Database, collection string) *Mongo {
+	return &Mongo{db.Collection(collection)}
+}
+
+// EnsureIndexes creates the index that expires refilled buckets.
+func (m *Mongo) EnsureIndexes(ctx context.Context) error {
+	_, err := m.coll.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetName("expires_at_ttl").SetExpireAfterSeconds(0),
+	})
+	return err
+}
+
+func (m *Mongo) Take(ctx context.Context, key string, l Limit) (bool, time.Duration, error) {
+	burst := float64(l.Burst)
+	elapsed := bson.M{"$subtract": bson.A{"$$NOW", bson.M{"$ifNull": bson.A{"$at", "$$NOW"}}}}
+	hasToken := bson.M{"$gte": bson.A{"$tokens", 1}}
+
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"tokens": bson.M{"$min": bson.A{burst, bson.M{"$add": bson.A{
+				bson.M{"$ifNull": bson.A{"$tokens", burst}},
+				// elapsed is in milliseconds
+				bson.M{"$multiply": bson.A{elapsed, l.rate() / 1000}},
+			}}}},
+			"at":         "$$NOW",
+			"expires_at": bson.M{"$add": bson.A{"$$NOW", l.full().Milliseconds()}},
+		}}},
+		{{Key: "$set", Value: bson.M{
+			"ok":     hasToken,
+			"tokens": bson.M{"$cond": bson.A{hasToken, bson.M{"$subtract": bson.A{"$tokens", 1}}, "$tokens"}},
+		}}},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var b struct {
+		Tokens float64 `bson:"tokens"`
+		OK     bool    `bson:"ok"`
+	}
+	err := m.coll.FindOneAndUpdate(ctx, bson.M{"_id": key}, update, opts).Decode(&b)
+	// two first requests raced to create the bucket; the loser updates it
+	if mongo.IsDuplicateKeyError(err) {
+		err = m.coll.FindOneAndUpdate(ctx, bson.M{"_id": key}, update, opts).Decode(&b)
+	}
+	if err != nil {
+		return false, 0, err
+	}
+
+	if !b.OK {
+		return false, l.wait(b.Tokens), nil
+	}
+	return true, 0, nil
+}
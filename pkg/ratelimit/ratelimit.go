@@ -0,0 +1,100 @@
+// Package ratelimit limits how often clients may call the server using
+// token buckets, kept in memory or in MongoDB.
+package ratelimit
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type (
+	// Limit - a token bucket that holds up to Burst requests and refills
+	// at PerMinute requests a minute.
+	Limit struct {
+		PerMinute float64
+		Burst     int
+	}
+
+	// Store - keeps the buckets.
+	Store interface {
+		// Take removes a token from the bucket under key, which starts
+		// full. When the bucket is empty it reports false and how long
+		// until the next token.
+		Take(ctx context.Context, key string, l Limit) (ok bool, wait time.Duration, err error)
+	}
+
+	// Limiter - applies a Limit per client to each group of requests.
+	Limiter struct {
+		store  Store
+		limits map[string]Limit
+		group  func(*http.Request) string
+		key    func(*http.Request) string
+		l      *slog.Logger
+	}
+)
+
+// rate returns the refill rate in tokens per second.
+func (l Limit) rate() float64 {
+	return l.PerMinute / 60
+}
+
+// wait returns how long the bucket takes to refill from tokens to one.
+func (l Limit) wait(tokens float64) time.Duration {
+	if l.PerMinute <= 0 {
+		return time.Hour
+	}
+	return time.Duration((1 - tokens) / l.rate() * float64(time.Second))
+}
+
+// full returns how long an empty bucket takes to refill, after which a
+// bucket is as good as new and may be forgotten.
+func (l Limit) full() time.Duration {
+	if l.PerMinute <= 0 {
+		return time.Hour
+	}
+	return time.Duration(float64(l.Burst) / l.rate() * float64(time.Second))
+}
+
+// refill adds the tokens earned over elapsed to tokens.
+func (l Limit) refill(tokens float64, elapsed time.Duration) float64 {
+	return math.Min(float64(l.Burst), tokens+elapsed.Seconds()*l.rate())
+}
+
+// New - creates a Limiter. group names the limit a request falls under,
+// or returns "" for requests that are not limited; key identifies the
+// client, e.g. by ClientIP.
+func New(store Store, limits map[string]Limit, group, key func(*http.Request) string, l *slog.Logger) *Limiter {
+	return &Limiter{store: store, limits: limits, group: group, key: key, l: l}
+}
+
+// HTTP - answers 429 Too Many Requests, with Retry-After, once a client
+// has used up its bucket for the group of the request. Should the store
+// fail, requests are let through.
+func (lm *Limiter) HTTP(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		group := lm.group(r)
+		limit, ok := lm.limits[group]
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ok, wait, err := lm.store.Take(r.Context(), group+":"+lm.key(r), limit)
+		if err != nil {
+			lm.l.ErrorContext(r.Context(), "rate limit store error", "err", err)
+			ok = true
+		}
+		if !ok {
+			secs := int(math.Ceil(wait.Seconds()))
+			w.Header().Set("Retry-After", strconv.Itoa(max(secs, 1)))
+			http.Error(w, "too many requests, try again later", http.StatusTooManyRequests)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
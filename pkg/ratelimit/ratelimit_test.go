@@ -0,0 +1,128 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryTake(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	m := NewMemory()
+	m.now = func() time.Time { return now }
+	l := Limit{PerMinute: 60, Burst: 2}
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		ok, _, err := m.Take(ctx, "a", l)
+		require.NoError(t, err)
+		assert.True(t, ok)
+	}
+	ok, wait, _ := m.Take(ctx, "a", l)
+	assert.False(t, ok)
+	assert.Equal(t, time.Second, wait)
+
+	// other clients have buckets of their own
+	ok, _, _ = m.Take(ctx, "b", l)
+	assert.True(t, ok)
+
+	now = now.Add(500 * time.Millisecond)
+	ok, wait, _ = m.Take(ctx, "a", l)
+	assert.False(t, ok)
+	assert.Equal(t, 500*time.Millisecond, wait)
+
+	now = now.Add(500 * time.Millisecond)
+	ok, _, _ = m.Take(ctx, "a", l)
+	assert.True(t, ok)
+}
+
+func TestMemorySweep(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	m := NewMemory()
+	m.now = func() time.Time { return now }
+	l := Limit{PerMinute: 60, Burst: 5}
+
+	m.Take(context.Background(), "a", l)
+	now = now.Add(sweepEvery)
+	m.Take(context.Background(), "b", l)
+
+	assert.NotContains(t, m.buckets, "a")
+	assert.Contains(t, m.buckets, "b")
+}
+
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, Limit) (bool, time.Duration, error) {
+	return false, 0, errors.New("down")
+}
+
+func TestHTTP(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	group := func(r *http.Request) string {
+		if r.URL.Path == "/healthz" {
+			return ""
+		}
+		return "read"
+	}
+	key := func(r *http.Request) string { return r.RemoteAddr }
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	limits := map[string]Limit{"read": {PerMinute: 6, Burst: 1}}
+	h := New(NewMemory(), limits, group, key, logger).HTTP(ok)
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		return rr
+	}
+
+	assert.Equal(t, http.StatusOK, serve("/posts").Code)
+	rr := serve("/posts")
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	assert.Equal(t, "10", rr.Header().Get("Retry-After"))
+	assert.Equal(t, http.StatusOK, serve("/healthz").Code)
+
+	// a broken store does not take the site down
+	h = New(failingStore{}, limits, group, key, logger).HTTP(ok)
+	assert.Equal(t, http.StatusOK, serve("/posts").Code)
+}
+
+func TestClientIP(t *testing.T) {
+	trusted, err := ParsePrefixes([]string{"10.0.0.0/8", " 192.0.2.1 "})
+	require.NoError(t, err)
+	clientIP := ClientIP(trusted)
+
+	tests := []struct {
+		name   string
+		remote string
+		xff    string
+		want   string
+	}{
+		{"direct", "203.0.113.7:4000", "", "203.0.113.7"},
+		{"untrusted proxy header ignored", "203.0.113.7:4000", "198.51.100.1", "203.0.113.7"},
+		{"trusted proxy", "10.0.0.2:4000", "198.51.100.1", "198.51.100.1"},
+		{"spoofed entry before proxies", "10.0.0.2:4000", "1.1.1.1, 198.51.100.1, 192.0.2.1", "198.51.100.1"},
+		{"only proxies", "10.0.0.2:4000", "10.0.0.3", "10.0.0.3"},
+		{"garbage", "10.0.0.2:4000", "not-an-ip", "10.0.0.2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remote
+			if tt.xff != "" {
+				r.Header.Set("X-Forwarded-For", tt.xff)
+			}
+			assert.Equal(t, tt.want, clientIP(r))
+		})
+	}
+
+	_, err = ParsePrefixes([]string{"proxy.local"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,195 @@
+// Package sanitize renders user-supplied post content as safe HTML.
+//
+// Content is either plain text, which is escaped and split into
+// paragraphs, or HTML, which is reduced to a small allowlist of
+// formatting elements and attributes.
+package sanitize
+
+import (
+	"html"
+	"net/url"
+	"strings"
+
+	xhtml "golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var (
+	// allowed maps permitted elements to their permitted attributes.
+	allowed = map[atom.Atom][]string{
+		atom.P: nil, atom.Br: nil, atom.Hr: nil,
+		atom.B: nil, atom.Strong: nil, atom.I: nil, atom.Em: nil, atom.U: nil, atom.S: nil,
+		atom.H2: nil, atom.H3: nil, atom.H4: nil,
+		atom.Ul: nil, atom.Ol: nil, atom.Li: nil,
+		atom.Blockquote: nil, atom.Pre: nil, atom.Code: nil,
+		atom.Figure: nil, atom.Figcaption: nil,
+		atom.A:   {"href", "title"},
+		atom.Img: {"src", "srcset", "sizes", "alt", "title", "width", "height", "loading"},
+	}
+
+	// dropped elements are removed together with everything inside them.
+	dropped = map[atom.Atom]bool{
+		atom.Script: true, atom.Style: true, atom.Iframe: true, atom.Object: true,
+		atom.Embed: true, atom.Noscript: true, atom.Template: true, atom.Svg: true, atom.Math: true,
+	}
+
+	urlAttrs = map[string]bool{"href": true, "src": true}
+)
+
+// Render returns content as safe HTML. Content without any markup is
+// treated as plain text so that line breaks survive.
+func Render(content string) string {
+	if !strings.ContainsRune(content, '<') {
+		return Text(content)
+	}
+	return HTML(content)
+}
+
+// Text escapes plain text, turning blank lines into paragraphs and
+// single newlines into <br>.
+func Text(s string) string {
+	s = strings.ReplaceAll(strings.TrimSpace(s), "\r\n", "\n")
+	if s == "" {
+		return ""
+	}
+
+	var b strings.Builder
+	for _, para := range strings.Split(s, "\n\n") {
+		para = strings.TrimSpace(para)
+		if para == "" {
+			continue
+		}
+		b.WriteString("<p>")
+		b.WriteString(strings.ReplaceAll(html.EscapeString(para), "\n", "<br>"))
+		b.WriteString("</p>")
+	}
+	return b.String()
+}
+
+// HTML strips everything outside the allowlist from s. Text is kept
+// (escaped) even when its surrounding element is removed.
+func HTML(s string) string {
+	var (
+		b     strings.Builder
+		z     = xhtml.NewTokenizer(strings.NewReader(s))
+		skip  int         // depth inside dropped elements
+		stack []atom.Atom // open allowed elements, to close dangling ones
+	)
+
+	for {
+		tt := z.Next()
+		if tt == xhtml.ErrorToken {
+			break
+		}
+		tok := z.Token()
+
+		switch tt {
+		case xhtml.StartTagToken, xhtml.SelfClosingTagToken:
+			if dropped[tok.DataAtom] {
+				if tt == xhtml.StartTagToken {
+					skip++
+				}
+				continue
+			}
+			attrs, ok := allowed[tok.DataAtom]
+			if skip > 0 || !ok {
+				continue
+			}
+			b.WriteString(startTag(tok, attrs))
+			if tt == xhtml.StartTagToken && !void(tok.DataAtom) {
+				stack = append(stack, tok.DataAtom)
+			}
+
+		case xhtml.EndTagToken:
+			if dropped[tok.DataAtom] {
+				skip = max(skip-1, 0)
+				continue
+			}
+			if skip > 0 {
+				continue
+			}
+			// only close what was opened, innermost match first
+			for i := len(stack) - 1; i >= 0; i-- {
+				if stack[i] == tok.DataAtom {
+					for j := len(stack) - 1; j >= i; j-- {
+						b.WriteString("</" + stack[j].String() + ">")
+					}
+					stack = stack[:i]
+					break
+				}
+			}
+
+		case xhtml.TextToken:
+			if skip == 0 {
+				b.WriteString(html.EscapeString(tok.Data))
+			}
+		}
+	}
+
+	for i := len(stack) - 1; i >= 0; i-- {
+		b.WriteString("</" + stack[i].String() + ">")
+	}
+
+	return b.String()
+}
+
+func startTag(tok xhtml.Token, allowedAttrs []string) string {
+	var b strings.Builder
+	b.WriteString("<" + tok.DataAtom.String())
+
+	for _, a := range tok.Attr {
+		if a.Namespace != "" || !contains(allowedAttrs, a.Key) {
+			continue
+		}
+		if urlAttrs[a.Key] && !SafeURL(a.Val) {
+			continue
+		}
+		if a.Key == "srcset" && !safeSrcset(a.Val) {
+			continue
+		}
+		b.WriteString(" " + a.Key + `="` + html.EscapeString(a.Val) + `"`)
+	}
+
+	if tok.DataAtom == atom.A {
+		b.WriteString(` rel="noopener"`)
+	}
+
+	b.WriteString(">")
+	return b.String()
+}
+
+// SafeURL reports whether u is a relative URL or uses http(s) or mailto.
+func SafeURL(u string) bool {
+	parsed, err := url.Parse(strings.TrimSpace(u))
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(parsed.Scheme) {
+	case "", "http", "https", "mailto":
+		return true
+	}
+	return false
+}
+
+func safeSrcset(v string) bool {
+	for _, candidate := range strings.Split(v, ",") {
+		fields := strings.Fields(candidate)
+		if len(fields) == 0 || !SafeURL(fields[0]) {
+			return false
+		}
+	}
+	return true
+}
+
+func void(a atom.Atom) bool {
+	return a == atom.Br || a == atom.Hr || a == atom.Img
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
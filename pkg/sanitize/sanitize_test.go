@@ -0,0 +1,45 @@
+package sanitize
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestText(t *testing.T) {
+	assert.Equal(t, "<p>a &lt;b&gt;<br>c</p><p>d</p>", Text("a <b>\nc\n\n\nd\n"))
+	assert.Equal(t, "", Text("  \n "))
+}
+
+func TestRenderDetectsMarkup(t *testing.T) {
+	assert.Equal(t, "<p>plain &amp; simple</p>", Render("plain & simple"))
+	assert.Equal(t, "<p>rich</p>", Render("<p>rich</p>"))
+}
+
+func TestHTML(t *testing.T) {
+	cases := map[string]string{
+		`<p onclick="x()">hi <strong>there</strong></p>`:            `<p>hi <strong>there</strong></p>`,
+		`<script>alert(1)</script>ok`:                               `ok`,
+		`<div><span>kept text</span></div>`:                         `kept text`,
+		`<a href="javascript:alert(1)">x</a>`:                       `<a rel="noopener">x</a>`,
+		`<a href="https://example.com" target="_blank">x</a>`:       `<a href="https://example.com" rel="noopener">x</a>`,
+		`<img src="/media/1" alt="a &quot;b&quot;" onerror="x()">`:  `<img src="/media/1" alt="a &#34;b&#34;">`,
+		`<img src="data:image/png;base64,AAAA">`:                    `<img>`,
+		`<img srcset="/a 1x, javascript:x 2x" src="/a">`:            `<img src="/a">`,
+		`<p>unclosed <em>tags`:                                      `<p>unclosed <em>tags</em></p>`,
+		`</p>stray close`:                                           `stray close`,
+		`<style>p{}</style><iframe src="https://evil"></iframe>end`: `end`,
+	}
+
+	for in, want := range cases {
+		assert.Equal(t, want, HTML(in), in)
+	}
+}
+
+func TestSafeURL(t *testing.T) {
+	assert.True(t, SafeURL("/media/1"))
+	assert.True(t, SafeURL("https://example.com"))
+	assert.True(t, SafeURL("mailto:a@example.com"))
+	assert.False(t, SafeURL("JavaScript:alert(1)"))
+	assert.False(t, SafeURL("vbscript:x"))
+}
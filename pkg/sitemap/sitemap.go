@@ -0,0 +1,80 @@
+// Package sitemap encodes sitemaps.org XML sitemaps and sitemap indexes.
+package sitemap
+
+import (
+	"encoding/xml"
+	"io"
+	"time"
+)
+
+const (
+	// MaxURLs is the protocol limit of URLs per sitemap (and of sitemaps
+	// per index).
+	MaxURLs = 50000
+
+	ContentType = "application/xml; charset=utf-8"
+
+	xmlns = "http://www.sitemaps.org/schemas/sitemap/0.9"
+)
+
+// URL - a single entry of a sitemap or sitemap index.
+type URL struct {
+	Loc     string
+	LastMod time.Time
+}
+
+type entry struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+// WriteURLSet - encodes urls as a <urlset> sitemap.
+func WriteURLSet(w io.Writer, urls []URL) error {
+	doc := struct {
+		XMLName xml.Name `xml:"urlset"`
+		Xmlns   string   `xml:"xmlns,attr"`
+		URLs    []entry  `xml:"url"`
+	}{Xmlns: xmlns, URLs: entries(urls)}
+
+	return write(w, doc)
+}
+
+// WriteIndex - encodes sitemaps as a <sitemapindex>.
+func WriteIndex(w io.Writer, sitemaps []URL) error {
+	doc := struct {
+		XMLName  xml.Name `xml:"sitemapindex"`
+		Xmlns    string   `xml:"xmlns,attr"`
+		Sitemaps []entry  `xml:"sitemap"`
+	}{Xmlns: xmlns, Sitemaps: entries(sitemaps)}
+
+	return write(w, doc)
+}
+
+// Pages - returns how many sitemaps of at most MaxURLs are needed for n URLs.
+func Pages(n int64) int64 {
+	return (n + MaxURLs - 1) / MaxURLs
+}
+
+func entries(urls []URL) []entry {
+	out := make([]entry, 0, len(urls))
+	for _, u := range urls {
+		e := entry{Loc: u.Loc}
+		if !u.LastMod.IsZero() {
+			e.LastMod = u.LastMod.UTC().Format(time.RFC3339)
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+func write(w io.Writer, v any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	return enc.Close()
+}
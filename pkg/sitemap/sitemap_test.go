@@ -0,0 +1,51 @@
+package sitemap
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteURLSet(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteURLSet(&buf, []URL{
+		{Loc: "https://news.example/posts/1", LastMod: time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)},
+		{Loc: "https://news.example/posts?a=1&b=2"},
+	})
+	require.NoError(t, err)
+
+	var doc struct {
+		XMLName xml.Name
+		URLs    []struct {
+			Loc     string `xml:"loc"`
+			LastMod string `xml:"lastmod"`
+		} `xml:"url"`
+	}
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &doc))
+
+	assert.Equal(t, "urlset", doc.XMLName.Local)
+	assert.Equal(t, xmlns, doc.XMLName.Space)
+	require.Len(t, doc.URLs, 2)
+	assert.Equal(t, "2024-01-01T10:00:00Z", doc.URLs[0].LastMod)
+	assert.Equal(t, "https://news.example/posts?a=1&b=2", doc.URLs[1].Loc)
+	assert.Empty(t, doc.URLs[1].LastMod)
+}
+
+func TestWriteIndex(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteIndex(&buf, []URL{{Loc: "https://news.example/sitemaps/posts/1.xml"}})
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "<sitemapindex")
+	assert.Contains(t, buf.String(), "<loc>https://news.example/sitemaps/posts/1.xml</loc>")
+}
+
+func TestPages(t *testing.T) {
+	assert.Equal(t, int64(0), Pages(0))
+	assert.Equal(t, int64(1), Pages(1))
+	assert.Equal(t, int64(1), Pages(MaxURLs))
+	assert.Equal(t, int64(2), Pages(MaxURLs+1))
+}
@@ -0,0 +1,86 @@
+package spam
+
+import (
+	"context"
+	"math"
+	"sync"
+)
+
+// MinExamples is how many spam and how many legitimate examples Bayes
+// needs before it scores anything other than 0.5.
+const MinExamples = 10
+
+const (
+	ham = iota
+	spam
+)
+
+// Bayes - a multinomial naive Bayes classifier kept in memory. It
+// starts empty; feed it past decisions with Learn on startup.
+type Bayes struct {
+	mu     sync.RWMutex
+	docs   [2]int
+	words  [2]int
+	counts map[string]*[2]int
+}
+
+var _ Classifier = (*Bayes)(nil)
+
+// NewBayes - creates an untrained classifier.
+func NewBayes() *Bayes {
+	return &Bayes{counts: make(map[string]*[2]int)}
+}
+
+func (b *Bayes) Learn(_ context.Context, text string, isSpam bool) error {
+	class := ham
+	if isSpam {
+		class = spam
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.docs[class]++
+	for _, t := range Tokens(text) {
+		c, ok := b.counts[t]
+		if !ok {
+			c = new([2]int)
+			b.counts[t] = c
+		}
+		c[class]++
+		b.words[class]++
+	}
+
+	return nil
+}
+
+// Score compares the log-likelihood of text under both classes, with
+// add-one smoothing for words seen in only one of them.
+func (b *Bayes) Score(_ context.Context, text string) (float64, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.docs[ham] < MinExamples || b.docs[spam] < MinExamples {
+		return 0.5, nil
+	}
+
+	total := float64(b.docs[ham] + b.docs[spam])
+	logp := [2]float64{
+		math.Log(float64(b.docs[ham]) / total),
+		math.Log(float64(b.docs[spam]) / total),
+	}
+
+	vocab := float64(len(b.counts))
+	for _, t := range Tokens(text) {
+		c, ok := b.counts[t]
+		if !ok {
+			// unseen words say nothing about either class
+			continue
+		}
+		for class := range logp {
+			logp[class] += math.Log((float64(c[class]) + 1) / (float64(b.words[class]) + vocab))
+		}
+	}
+
+	return 1 / (1 + math.Exp(logp[ham]-logp[spam])), nil
+}
@@ -0,0 +1,53 @@
+package spam
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokens(t *testing.T) {
+	assert.Equal(t,
+		[]string{"cheap", "pills", "at", "host:spam.example", "don", "miss", "out"},
+		Tokens("Cheap PILLS at https://spam.example/buy?x=1 -- don't miss out!"))
+	assert.Empty(t, Tokens("a ! ?"))
+}
+
+func TestBayesNeedsTraining(t *testing.T) {
+	ctx := context.Background()
+	b := NewBayes()
+
+	score, err := b.Score(ctx, "cheap pills")
+	require.NoError(t, err)
+	assert.Equal(t, 0.5, score)
+
+	for range MinExamples {
+		require.NoError(t, b.Learn(ctx, "cheap pills", true))
+	}
+	score, _ = b.Score(ctx, "cheap pills")
+	assert.Equal(t, 0.5, score, "no legitimate examples yet")
+}
+
+func TestBayesScores(t *testing.T) {
+	ctx := context.Background()
+	b := NewBayes()
+
+	for i := range MinExamples {
+		require.NoError(t, b.Learn(ctx, fmt.Sprintf("buy cheap pills now at https://pills%d.example", i), true))
+		require.NoError(t, b.Learn(ctx, fmt.Sprintf("great article about the election, thanks %d", i), false))
+	}
+
+	spamScore, err := b.Score(ctx, "cheap pills here")
+	require.NoError(t, err)
+	hamScore, err := b.Score(ctx, "thanks for the article")
+	require.NoError(t, err)
+	neutral, err := b.Score(ctx, "zebra")
+	require.NoError(t, err)
+
+	assert.Greater(t, spamScore, 0.9)
+	assert.Less(t, hamScore, 0.1)
+	assert.InDelta(t, 0.5, neutral, 0.01)
+}
@@ -0,0 +1,45 @@
+// Package spam scores user-submitted text for spam.
+package spam
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"unicode"
+)
+
+// Classifier - scores text and learns from moderator decisions.
+// Implementations must be safe for concurrent use.
+type Classifier interface {
+	// Score returns the probability, between 0 and 1, that text is spam.
+	Score(ctx context.Context, text string) (float64, error)
+	// Learn records that text was judged to be spam or not.
+	Learn(ctx context.Context, text string, spam bool) error
+}
+
+const (
+	minTokenLen = 2
+	maxTokenLen = 40
+)
+
+// Tokens splits text into lowercase words. Links contribute their host
+// as a single "host:" token, which is usually what gives spam away.
+func Tokens(text string) []string {
+	var tokens []string
+	for _, field := range strings.Fields(text) {
+		if u, err := url.Parse(strings.Trim(field, `"'<>()[],.`)); err == nil && u.Host != "" &&
+			(u.Scheme == "http" || u.Scheme == "https") {
+			tokens = append(tokens, "host:"+strings.ToLower(u.Hostname()))
+			continue
+		}
+
+		for _, word := range strings.FieldsFunc(strings.ToLower(field), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}) {
+			if n := len([]rune(word)); n >= minTokenLen && n <= maxTokenLen {
+				tokens = append(tokens, word)
+			}
+		}
+	}
+	return tokens
+}
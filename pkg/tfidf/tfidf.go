@@ -0,0 +1,99 @@
+// Package tfidf compares texts by the words they share, weighting each
+// word by how rare it is across the compared texts.
+package tfidf
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// minWordLen drops initials, numbering and the like.
+const minWordLen = 3
+
+// stopWords are common English words that say nothing about a topic.
+var stopWords = map[string]bool{}
+
+func init() {
+	for _, w := range strings.Fields(`
+		about above after again against all also and any are because been before
+		being below between both but can could did does doing down during each
+		few for from further had has have having her here hers herself him
+		himself his how into its itself just more most much must not now off
+		once only other our ours ourselves out over own same she should some
+		such than that the their theirs them themselves then there these they
+		this those through too under until very was were what when where which
+		while who whom why will with would you your yours yourself yourselves
+		said says new one two get got may might like make made many well
+	`) {
+		stopWords[w] = true
+	}
+}
+
+// Vector maps words to their weight in one text.
+type Vector map[string]float64
+
+// Tokenize splits text into lowercase words, leaving out stop words and
+// words shorter than three letters.
+func Tokenize(text string) []string {
+	var words []string
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len([]rune(w)) >= minWordLen && !stopWords[w] {
+			words = append(words, w)
+		}
+	}
+	return words
+}
+
+// Vectors weights the words of each document by term frequency times
+// smoothed inverse document frequency. Vectors have unit length, so
+// Cosine of two of them is their dot product.
+func Vectors(docs [][]string) []Vector {
+	df := make(map[string]int)
+	for _, doc := range docs {
+		seen := make(map[string]bool, len(doc))
+		for _, w := range doc {
+			if !seen[w] {
+				seen[w] = true
+				df[w]++
+			}
+		}
+	}
+
+	n := float64(len(docs))
+	vectors := make([]Vector, len(docs))
+	for i, doc := range docs {
+		v := make(Vector, len(doc))
+		for _, w := range doc {
+			v[w]++
+		}
+
+		var norm float64
+		for w, tf := range v {
+			weight := tf * (math.Log((1+n)/(1+float64(df[w]))) + 1)
+			v[w] = weight
+			norm += weight * weight
+		}
+		norm = math.Sqrt(norm)
+		for w := range v {
+			v[w] /= norm
+		}
+		vectors[i] = v
+	}
+	return vectors
+}
+
+// Cosine returns the cosine similarity of two unit vectors, from 0 for
+// no shared words to 1 for the same text.
+func Cosine(a, b Vector) float64 {
+	if len(b) < len(a) {
+		a, b = b, a
+	}
+	var dot float64
+	for w, x := range a {
+		dot += x * b[w]
+	}
+	return dot
+}
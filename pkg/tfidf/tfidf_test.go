@@ -0,0 +1,38 @@
+package tfidf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenize(t *testing.T) {
+	assert.Equal(t,
+		[]string{"city", "council", "approves", "budget", "2024"},
+		Tokenize("The City Council approves its budget for 2024, as expected by no-one... ok?")[:5])
+	assert.Empty(t, Tokenize("it is what it is"))
+}
+
+func TestVectors(t *testing.T) {
+	vs := Vectors([][]string{
+		Tokenize("council budget vote delayed"),
+		Tokenize("council budget passes after long vote"),
+		Tokenize("football club wins the cup final"),
+		nil,
+	})
+
+	assert.InDelta(t, 1, Cosine(vs[0], vs[0]), 1e-9)
+	assert.Greater(t, Cosine(vs[0], vs[1]), 0.3)
+	assert.Zero(t, Cosine(vs[0], vs[2]))
+	assert.Zero(t, Cosine(vs[0], vs[3]))
+	assert.Equal(t, Cosine(vs[0], vs[1]), Cosine(vs[1], vs[0]))
+}
+
+func TestRareWordsWeighMore(t *testing.T) {
+	vs := Vectors([][]string{
+		{"news", "election"},
+		{"news", "weather"},
+		{"news", "sport"},
+	})
+	assert.Greater(t, vs[0]["election"], vs[0]["news"])
+}
@@ -0,0 +1,76 @@
+package tracing
+
+import (
+	"context"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/event"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// CommandMonitor - traces each MongoDB command as a client span under
+// the span of the code that issued it; commands issued outside any span
+// are not traced. Command bodies are left out, as they may hold
+// personal data.
+func CommandMonitor() *event.CommandMonitor {
+	var spans sync.Map // request ID -> trace.Span
+
+	end := func(requestID int64, err error) {
+		s, ok := spans.LoadAndDelete(requestID)
+		if !ok {
+			return
+		}
+		span := s.(trace.Span)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+
+	return &event.CommandMonitor{
+		Started: func(ctx context.Context, e *event.CommandStartedEvent) {
+			if !trace.SpanContextFromContext(ctx).IsValid() {
+				return
+			}
+
+			attrs := []attribute.KeyValue{
+				semconv.DBSystemMongoDB,
+				semconv.DBNamespace(e.DatabaseName),
+				semconv.DBOperationName(e.CommandName),
+			}
+			name := e.CommandName
+			if coll, ok := collection(e.Command, e.CommandName); ok {
+				attrs = append(attrs, semconv.DBCollectionName(coll))
+				name += " " + coll
+			}
+
+			_, span := otel.Tracer(instrumentation).Start(ctx, name,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(attrs...),
+			)
+			spans.Store(e.RequestID, span)
+		},
+		Succeeded: func(ctx context.Context, e *event.CommandSucceededEvent) {
+			end(e.RequestID, nil)
+		},
+		Failed: func(ctx context.Context, e *event.CommandFailedEvent) {
+			end(e.RequestID, e.Failure)
+		},
+	}
+}
+
+// collection returns the collection a command works on, which most
+// commands carry as the value of their name.
+func collection(cmd bson.Raw, name string) (string, bool) {
+	v, err := cmd.LookupErr(name)
+	if err != nil {
+		return "", false
+	}
+	return v.StringValueOK()
+}
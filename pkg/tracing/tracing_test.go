@@ -0,0 +1,99 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/event"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// record installs a tracer provider that keeps ended spans in memory.
+func record(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	sr := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+	return sr
+}
+
+func TestHTTPContinuesTrace(t *testing.T) {
+	sr := record(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /things/{id}", func(w http.ResponseWriter, r *http.Request) {
+		_, span := Start(r.Context(), "service.thing.Get")
+		span.End()
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/things/1", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	HTTP(mux).ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := sr.Ended()
+	require.Len(t, spans, 2)
+	child, server := spans[0], spans[1]
+
+	assert.Equal(t, "GET /things/{id}", server.Name())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", server.Parent().SpanID().String())
+	assert.Equal(t, codes.Error, server.Status().Code)
+	assert.Equal(t, server.SpanContext().SpanID(), child.Parent().SpanID())
+}
+
+func TestStartChildWithoutSpan(t *testing.T) {
+	sr := record(t)
+
+	_, span := StartChild(context.Background(), "repo.post.GetByID")
+	span.End()
+
+	assert.Empty(t, sr.Ended())
+}
+
+func TestCommandMonitor(t *testing.T) {
+	sr := record(t)
+	m := CommandMonitor()
+
+	ctx, parent := Start(context.Background(), "repo.post.GetByID")
+	cmd, err := bson.Marshal(bson.D{{Key: "find", Value: "posts"}, {Key: "filter", Value: bson.D{}}})
+	require.NoError(t, err)
+
+	m.Started(ctx, &event.CommandStartedEvent{Command: cmd, DatabaseName: "news", CommandName: "find", RequestID: 7})
+	m.Succeeded(ctx, &event.CommandSucceededEvent{CommandFinishedEvent: event.CommandFinishedEvent{CommandName: "find", RequestID: 7}})
+	// commands outside a trace are left alone
+	m.Started(context.Background(), &event.CommandStartedEvent{Command: cmd, DatabaseName: "news", CommandName: "find", RequestID: 8})
+	parent.End()
+
+	spans := sr.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "find posts", spans[0].Name())
+	assert.Equal(t, parent.SpanContext().SpanID(), spans[0].Parent().SpanID())
+}
+
+func TestLogHandler(t *testing.T) {
+	record(t)
+	var buf bytes.Buffer
+	logger := slog.New(LogHandler(slog.NewTextHandler(&buf, nil)))
+
+	ctx, span := Start(context.Background(), "op")
+	defer span.End()
+	logger.ErrorContext(ctx, "failed")
+
+	assert.Contains(t, buf.String(), "trace_id="+span.SpanContext().TraceID().String())
+	assert.Contains(t, buf.String(), "span_id="+span.SpanContext().SpanID().String())
+}
@@ -0,0 +1,31 @@
+// Package useragent inspects User-Agent headers.
+package useragent
+
+import "strings"
+
+// botMarkers are substrings of lowercased User-Agent headers sent by
+// crawlers, link previewers, monitors and HTTP libraries. Matching by
+// substring keeps the list short: "bot" alone covers Googlebot, bingbot,
+// Twitterbot and most others.
+var botMarkers = []string{
+	"bot", "crawl", "spider", "slurp", "scrape", "archiver",
+	"facebookexternalhit", "embedly", "preview", "whatsapp", "telegram",
+	"monitor", "uptime", "pingdom", "lighthouse", "headless",
+	"curl", "wget", "python-", "go-http-client", "java/", "okhttp",
+	"libwww", "httpclient", "axios", "node-fetch",
+}
+
+// IsBot - reports whether ua looks like an automated client. Empty
+// User-Agents count as bots; every mainstream browser sends one.
+func IsBot(ua string) bool {
+	ua = strings.ToLower(strings.TrimSpace(ua))
+	if ua == "" {
+		return true
+	}
+	for _, m := range botMarkers {
+		if strings.Contains(ua, m) {
+			return true
+		}
+	}
+	return false
+}
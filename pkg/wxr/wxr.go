@@ -0,0 +1,201 @@
+// Package wxr decodes WordPress eXtended RSS (WXR) export files.
+//
+// Exports can be large, so items are decoded one at a time instead of
+// unmarshalling the whole document.
+package wxr
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	nsWP      = "http://wordpress.org/export/1.2/"
+	nsContent = "http://purl.org/rss/1.0/modules/content/"
+
+	// dateLayout is the layout of wp:post_date_gmt and friends.
+	dateLayout = "2006-01-02 15:04:05"
+)
+
+var ErrNotWXR = errors.New("wxr: document has no rss channel")
+
+type (
+	// Author - a wp:author entry of the channel.
+	Author struct {
+		Login       string `xml:"author_login"`
+		Email       string `xml:"author_email"`
+		DisplayName string `xml:"author_display_name"`
+	}
+
+	// Term - a category or tag attached to an item.
+	Term struct {
+		Domain   string `xml:"domain,attr"`
+		Nicename string `xml:"nicename,attr"`
+		Name     string `xml:",chardata"`
+	}
+
+	// Comment - a wp:comment of an item.
+	Comment struct {
+		ID          int64  `xml:"comment_id"`
+		Author      string `xml:"comment_author"`
+		AuthorEmail string `xml:"comment_author_email"`
+		AuthorIP    string `xml:"comment_author_IP"`
+		DateGMT     string `xml:"comment_date_gmt"`
+		Content     string `xml:"comment_content"`
+		Approved    string `xml:"comment_approved"`
+		Type        string `xml:"comment_type"`
+		Parent      int64  `xml:"comment_parent"`
+	}
+
+	// Item - a single exported post, page or attachment.
+	Item struct {
+		Title       string    `xml:"title"`
+		Link        string    `xml:"link"`
+		PubDate     string    `xml:"pubDate"`
+		Creator     string    `xml:"http://purl.org/dc/elements/1.1/ creator"`
+		Content     string    `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+		Excerpt     string    `xml:"http://wordpress.org/export/1.2/excerpt/ encoded"`
+		PostID      int64     `xml:"post_id"`
+		PostDateGMT string    `xml:"post_date_gmt"`
+		ModifiedGMT string    `xml:"post_modified_gmt"`
+		Name        string    `xml:"post_name"`
+		Status      string    `xml:"status"`
+		Type        string    `xml:"post_type"`
+		Terms       []Term    `xml:"category"`
+		Comments    []Comment `xml:"comment"`
+	}
+
+	// Decoder - reads items from a WXR document one by one.
+	Decoder struct {
+		d         *xml.Decoder
+		authors   map[string]Author
+		inChannel bool
+	}
+)
+
+// NewDecoder - creates a Decoder reading from r.
+func NewDecoder(r io.Reader) *Decoder {
+	d := xml.NewDecoder(r)
+	// WordPress declares UTF-8 but older exports are occasionally latin-1;
+	// passing bytes through keeps the importer from failing outright.
+	d.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+
+	return &Decoder{d: d, authors: make(map[string]Author)}
+}
+
+// Next - returns the next item, or io.EOF once the document is exhausted.
+// Authors met on the way are collected and available through Author.
+func (d *Decoder) Next() (*Item, error) {
+	for {
+		tok, err := d.d.Token()
+		if err == io.EOF {
+			if !d.inChannel {
+				return nil, ErrNotWXR
+			}
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch {
+		case start.Name.Local == "channel":
+			d.inChannel = true
+		case start.Name.Local == "author" && start.Name.Space == nsWP:
+			var a Author
+			if err := d.d.DecodeElement(&a, &start); err != nil {
+				return nil, err
+			}
+			d.authors[a.Login] = a
+		case start.Name.Local == "item":
+			item := new(Item)
+			if err := d.d.DecodeElement(item, &start); err != nil {
+				return nil, err
+			}
+			return item, nil
+		}
+	}
+}
+
+// Author - looks up a channel author by login.
+func (d *Decoder) Author(login string) (Author, bool) {
+	a, ok := d.authors[login]
+	return a, ok
+}
+
+// Tags - returns the names of the item's post_tag terms.
+func (i Item) Tags() []string {
+	return i.terms("post_tag")
+}
+
+// Categories - returns the names of the item's category terms.
+func (i Item) Categories() []string {
+	return i.terms("category")
+}
+
+func (i Item) terms(domain string) []string {
+	var names []string
+	for _, t := range i.Terms {
+		if t.Domain == domain {
+			names = append(names, strings.TrimSpace(t.Name))
+		}
+	}
+	return names
+}
+
+// Slug - returns the item's post_name. Unpublished items may have none
+// yet, in which case "wp-<post_id>" keeps them addressable across
+// repeated imports.
+func (i Item) Slug() string {
+	if i.Name != "" {
+		return i.Name
+	}
+	return fmt.Sprintf("wp-%d", i.PostID)
+}
+
+// Published - returns the GMT publish date, falling back to pubDate.
+// Drafts carry no date at all and yield the zero time.
+func (i Item) Published() time.Time {
+	if t, ok := parseDate(i.PostDateGMT); ok {
+		return t
+	}
+	if t, err := time.Parse(time.RFC1123Z, i.PubDate); err == nil {
+		return t.UTC()
+	}
+	return time.Time{}
+}
+
+// Modified - returns the GMT modification date, if any.
+func (i Item) Modified() time.Time {
+	t, _ := parseDate(i.ModifiedGMT)
+	return t
+}
+
+// Date - returns the comment's GMT date, if any.
+func (c Comment) Date() time.Time {
+	t, _ := parseDate(c.DateGMT)
+	return t
+}
+
+func parseDate(s string) (time.Time, bool) {
+	// WordPress writes "0000-00-00 00:00:00" for unscheduled drafts
+	if s == "" || strings.HasPrefix(s, "0000") {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(dateLayout, s)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
@@ -0,0 +1,99 @@
+package wxr
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sample = `<?xml version="1.0" encoding="UTF-8" ?>
+<rss version="2.0"
+	xmlns:excerpt="http://wordpress.org/export/1.2/excerpt/"
+	xmlns:content="http://purl.org/rss/1.0/modules/content/"
+	xmlns:dc="http://purl.org/dc/elements/1.1/"
+	xmlns:wp="http://wordpress.org/export/1.2/">
+<channel>
+	<title>Old News</title>
+	<wp:author>
+		<wp:author_login><![CDATA[jdoe]]></wp:author_login>
+		<wp:author_email><![CDATA[jdoe@example.com]]></wp:author_email>
+		<wp:author_display_name><![CDATA[Jane Doe]]></wp:author_display_name>
+	</wp:author>
+	<item>
+		<title>Hello World</title>
+		<pubDate>Mon, 01 Jan 2024 10:00:00 +0000</pubDate>
+		<dc:creator><![CDATA[jdoe]]></dc:creator>
+		<content:encoded><![CDATA[<p>Body</p>]]></content:encoded>
+		<excerpt:encoded><![CDATA[Short]]></excerpt:encoded>
+		<wp:post_id>42</wp:post_id>
+		<wp:post_date_gmt><![CDATA[2024-01-01 10:00:00]]></wp:post_date_gmt>
+		<wp:post_modified_gmt><![CDATA[2024-01-02 11:30:00]]></wp:post_modified_gmt>
+		<wp:post_name><![CDATA[hello-world]]></wp:post_name>
+		<wp:status><![CDATA[publish]]></wp:status>
+		<wp:post_type><![CDATA[post]]></wp:post_type>
+		<category domain="category" nicename="politics"><![CDATA[Politics]]></category>
+		<category domain="post_tag" nicename="elections"><![CDATA[Elections]]></category>
+		<wp:comment>
+			<wp:comment_id>7</wp:comment_id>
+			<wp:comment_author><![CDATA[Reader]]></wp:comment_author>
+			<wp:comment_date_gmt><![CDATA[2024-01-03 08:00:00]]></wp:comment_date_gmt>
+			<wp:comment_content><![CDATA[Nice]]></wp:comment_content>
+			<wp:comment_approved><![CDATA[1]]></wp:comment_approved>
+			<wp:comment_parent>0</wp:comment_parent>
+		</wp:comment>
+	</item>
+	<item>
+		<title>Draft</title>
+		<wp:post_id>43</wp:post_id>
+		<wp:post_date_gmt><![CDATA[0000-00-00 00:00:00]]></wp:post_date_gmt>
+		<wp:status><![CDATA[draft]]></wp:status>
+		<wp:post_type><![CDATA[page]]></wp:post_type>
+	</item>
+</channel>
+</rss>`
+
+func TestDecoder(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(sample))
+
+	item, err := dec.Next()
+	require.NoError(t, err)
+
+	assert.Equal(t, "Hello World", item.Title)
+	assert.Equal(t, "<p>Body</p>", item.Content)
+	assert.Equal(t, "Short", item.Excerpt)
+	assert.Equal(t, int64(42), item.PostID)
+	assert.Equal(t, "hello-world", item.Name)
+	assert.Equal(t, "publish", item.Status)
+	assert.Equal(t, "post", item.Type)
+	assert.Equal(t, []string{"Politics"}, item.Categories())
+	assert.Equal(t, []string{"Elections"}, item.Tags())
+	assert.Equal(t, time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC), item.Published())
+	assert.Equal(t, time.Date(2024, 1, 2, 11, 30, 0, 0, time.UTC), item.Modified())
+
+	require.Len(t, item.Comments, 1)
+	assert.Equal(t, "Nice", item.Comments[0].Content)
+	assert.Equal(t, time.Date(2024, 1, 3, 8, 0, 0, 0, time.UTC), item.Comments[0].Date())
+
+	author, ok := dec.Author("jdoe")
+	assert.True(t, ok)
+	assert.Equal(t, "Jane Doe", author.DisplayName)
+
+	item, err = dec.Next()
+	require.NoError(t, err)
+	assert.Equal(t, "page", item.Type)
+	assert.True(t, item.Published().IsZero())
+
+	_, err = dec.Next()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestDecoderNotWXR(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`<html><body/></html>`))
+
+	_, err := dec.Next()
+	assert.ErrorIs(t, err, ErrNotWXR)
+}